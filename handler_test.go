@@ -1,13 +1,19 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"math"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"sun-position/handlers"
+	"sun-position/utils"
 )
 
 func TestSunPositionHandlerWithCityName(t *testing.T) {
@@ -182,3 +188,1582 @@ func TestSunPositionHandlerWithEmptyCityName(t *testing.T) {
 	}
 }
 
+
+func TestSunPositionHandlerWithSPAAlgorithm(t *testing.T) {
+	// Create a test request selecting the NREL SPA algorithm
+	req, err := http.NewRequest("GET", "/api/sun-position?city=Khartoum&date=2026-01-28&time=12:00&algorithm=spa", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	handlers.SunPositionHandler(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+
+	var response map[string]interface{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("could not unmarshal response: %v", err)
+	}
+
+	if algorithm, _ := response["algorithm"].(string); algorithm != "spa" {
+		t.Errorf("expected algorithm spa in response, got: %v", response["algorithm"])
+	}
+}
+
+func TestSunPositionHandlerWithInvalidAlgorithm(t *testing.T) {
+	req, err := http.NewRequest("GET", "/api/sun-position?city=Khartoum&date=2026-01-28&time=12:00&algorithm=guess", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	handlers.SunPositionHandler(rr, req)
+
+	if status := rr.Code; status != http.StatusBadRequest {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusBadRequest)
+	}
+}
+
+func TestSunPositionHandlerWithObserverParameters(t *testing.T) {
+	// La Paz, Bolivia at 3640 m with explicit atmospheric conditions
+	req, err := http.NewRequest("GET", "/api/sun-position?lat=-16.5&lon=-68.15&date=2026-06-21&time=07:00&elevation=3640&pressure=650&temp=2", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	handlers.SunPositionHandler(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+
+	// The elevation lowers the horizon, unless observer_height puts the observer on the plateau itself
+	sunrise := func(query string) string {
+		req, _ := http.NewRequest("GET", "/api/sun-position?lat=-16.5&lon=-68.15&date=2026-06-21&time=07:00&elevation=3640&pressure=650&temp=2"+query, nil)
+		rr := httptest.NewRecorder()
+		handlers.SunPositionHandler(rr, req)
+		var response handlers.SunPositionResponse
+		if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+			t.Fatalf("could not parse response: %v %s", err, rr.Body.String())
+		}
+		return response.Sunrise
+	}
+	if raised, plateau := sunrise(""), sunrise("&observer_height=0"); raised >= plateau {
+		t.Errorf("expected an earlier sunrise from the elevation's horizon dip, got %s and %s on the plateau", raised, plateau)
+	}
+
+	// Invalid elevation should be rejected
+	req, err = http.NewRequest("GET", "/api/sun-position?lat=-16.5&lon=-68.15&date=2026-06-21&time=07:00&elevation=high", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr = httptest.NewRecorder()
+	handlers.SunPositionHandler(rr, req)
+
+	if status := rr.Code; status != http.StatusBadRequest {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusBadRequest)
+	}
+}
+
+func TestSunPositionHandlerWithTimeZone(t *testing.T) {
+	testCases := []struct {
+		name           string
+		query          string
+		expectedZone   string
+		expectedOffset string
+	}{
+		{"City zone", "city=Madrid&date=2026-07-01&time=12:00", "Europe/Madrid", "+02:00"},
+		{"Coordinate lookup", "lat=19.076&lon=72.8777&date=2026-01-28&time=12:00", "Asia/Kolkata", "+05:30"},
+		{"Explicit tz", "lat=15.5007&lon=32.5599&date=2026-01-28&time=12:00&tz=UTC", "UTC", "+00:00"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/api/sun-position?"+tc.query, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rr := httptest.NewRecorder()
+			handlers.SunPositionHandler(rr, req)
+
+			if status := rr.Code; status != http.StatusOK {
+				t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+			}
+
+			var response map[string]interface{}
+			if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+				t.Fatalf("could not unmarshal response: %v", err)
+			}
+
+			if zone, _ := response["timezone"].(string); zone != tc.expectedZone {
+				t.Errorf("expected timezone %s, got: %v", tc.expectedZone, response["timezone"])
+			}
+			if offset, _ := response["utc_offset"].(string); offset != tc.expectedOffset {
+				t.Errorf("expected utc_offset %s, got: %v", tc.expectedOffset, response["utc_offset"])
+			}
+		})
+	}
+
+	// Unknown zone names should be rejected
+	req, err := http.NewRequest("GET", "/api/sun-position?city=Khartoum&tz=Mars/Olympus_Mons", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	handlers.SunPositionHandler(rr, req)
+
+	if status := rr.Code; status != http.StatusBadRequest {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusBadRequest)
+	}
+}
+
+func TestSunPositionHandlerIncludesTwilight(t *testing.T) {
+	req, err := http.NewRequest("GET", "/api/sun-position?city=Khartoum&date=2026-01-28&time=12:00", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	handlers.SunPositionHandler(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+
+	var response map[string]interface{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("could not unmarshal response: %v", err)
+	}
+
+	fields := []string{"solar_noon", "day_length", "civil_dawn", "civil_dusk", "nautical_dawn", "nautical_dusk", "astronomical_dawn", "astronomical_dusk"}
+	for _, field := range fields {
+		value, _ := response[field].(string)
+		if value == "" || value == "N/A" {
+			t.Errorf("expected %s in response, got: %v", field, response[field])
+		}
+	}
+}
+
+func TestSunPathHandler(t *testing.T) {
+	req, err := http.NewRequest("GET", "/api/sun-path?city=Khartoum&date=2026-01-28&step=30", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	handlers.SunPathHandler(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+
+	var response handlers.SunPathResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("could not unmarshal response: %v", err)
+	}
+
+	if len(response.Points) != 48 {
+		t.Errorf("expected 48 points, got %d", len(response.Points))
+	}
+	if response.TimeZone != "Africa/Khartoum" {
+		t.Errorf("expected timezone Africa/Khartoum, got %s", response.TimeZone)
+	}
+
+	// The series must match the single-point endpoint
+	single := httptest.NewRecorder()
+	singleReq, _ := http.NewRequest("GET", "/api/sun-position?city=Khartoum&date=2026-01-28&time=09:00", nil)
+	handlers.SunPositionHandler(single, singleReq)
+
+	var position map[string]interface{}
+	if err := json.Unmarshal(single.Body.Bytes(), &position); err != nil {
+		t.Fatalf("could not unmarshal response: %v", err)
+	}
+	if altitude, _ := position["sun_altitude"].(float64); altitude != response.Points[18].Altitude {
+		t.Errorf("expected altitude %v at 09:00, got %v", altitude, response.Points[18].Altitude)
+	}
+}
+
+func TestSunPathHandlerWithInvalidStep(t *testing.T) {
+	for _, step := range []string{"0", "500", "abc"} {
+		req, err := http.NewRequest("GET", "/api/sun-path?city=Khartoum&date=2026-01-28&step="+step, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := httptest.NewRecorder()
+		handlers.SunPathHandler(rr, req)
+
+		if status := rr.Code; status != http.StatusBadRequest {
+			t.Errorf("handler returned wrong status code for step %s: got %v want %v", step, status, http.StatusBadRequest)
+		}
+	}
+}
+
+func TestBatchSunPositionHandler(t *testing.T) {
+	body := `[
+		{"latitude": 15.5007, "longitude": 32.5599, "date": "2026-01-28", "time": "12:00"},
+		{"latitude": 40.4168, "longitude": -3.7038, "timestamp": "2026-06-21T10:00:00Z"},
+		{"latitude": 95, "longitude": 0, "date": "2026-01-28", "time": "12:00"},
+		{"latitude": 15.5007, "longitude": 32.5599, "date": "28/01/2026", "time": "12:00"},
+		{"latitude": 15.5007, "longitude": 32.5599, "timestamp": "yesterday"}
+	]`
+	req, err := http.NewRequest("POST", "/api/sun-position/batch", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	handlers.BatchSunPositionHandler(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+
+	var results []handlers.BatchSunPositionResult
+	if err := json.Unmarshal(rr.Body.Bytes(), &results); err != nil {
+		t.Fatalf("could not unmarshal response: %v", err)
+	}
+	if len(results) != 5 {
+		t.Fatalf("expected 5 results, got %d", len(results))
+	}
+
+	expectError := []bool{false, false, true, true, true}
+	for i, result := range results {
+		if result.Index != i {
+			t.Errorf("expected result %d to have index %d, got %d", i, i, result.Index)
+		}
+		if (result.Error != nil) != expectError[i] {
+			t.Errorf("expected error=%t for entry %d, got %v", expectError[i], i, result.Error)
+		}
+		if !expectError[i] && (result.SunAltitude == nil || result.SunAzimuth == nil) {
+			t.Errorf("expected a position for entry %d, got %+v", i, result)
+		}
+	}
+}
+
+func TestBatchSunPositionHandlerMalformedEntries(t *testing.T) {
+	body := `[
+		{"latitude": "x", "longitude": 32.5599, "date": "2026-01-28", "time": "12:00"},
+		{"latitude": 15.5007, "longitude": 32.5599, "date": "2026-01-28", "time": "12:00"},
+		{"date": "2026-01-28", "time": "12:00"},
+		{"latitude": 15.5007, "date": "2026-01-28", "time": "12:00"},
+		"not an observation"
+	]`
+	req, err := http.NewRequest("POST", "/api/sun-position/batch", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	handlers.BatchSunPositionHandler(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v, body %s", status, http.StatusOK, rr.Body.String())
+	}
+
+	var results []handlers.BatchSunPositionResult
+	if err := json.Unmarshal(rr.Body.Bytes(), &results); err != nil {
+		t.Fatalf("could not unmarshal response: %v", err)
+	}
+	if len(results) != 5 {
+		t.Fatalf("expected 5 results, got %d", len(results))
+	}
+
+	expected := []struct {
+		code  string
+		param string
+	}{
+		{"invalid_body", ""},
+		{"", ""},
+		{"missing_parameter", "latitude"},
+		{"missing_parameter", "longitude"},
+		{"invalid_body", ""},
+	}
+	for i, result := range results {
+		if result.Index != i {
+			t.Errorf("expected result %d to have index %d, got %d", i, i, result.Index)
+		}
+		if expected[i].code == "" {
+			if result.Error != nil || result.SunAltitude == nil {
+				t.Errorf("expected a position for entry %d, got %+v", i, result)
+			}
+			continue
+		}
+		if result.Error == nil {
+			t.Errorf("expected error code %s for entry %d, got a position", expected[i].code, i)
+			continue
+		}
+		if result.Error.Code != expected[i].code || result.Error.Param != expected[i].param {
+			t.Errorf("expected error %s on %q for entry %d, got %s on %q", expected[i].code, expected[i].param, i, result.Error.Code, result.Error.Param)
+		}
+	}
+}
+
+func TestBatchSunPositionHandlerRejectsInvalidRequests(t *testing.T) {
+	testCases := []struct {
+		name   string
+		method string
+		body   string
+		status int
+	}{
+		{"GET method", "GET", "", http.StatusMethodNotAllowed},
+		{"Not an array", "POST", `{"latitude": 1}`, http.StatusBadRequest},
+		{"Malformed JSON", "POST", `[{"latitude": }]`, http.StatusBadRequest},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest(tc.method, "/api/sun-position/batch", strings.NewReader(tc.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rr := httptest.NewRecorder()
+			handlers.BatchSunPositionHandler(rr, req)
+
+			if status := rr.Code; status != tc.status {
+				t.Errorf("handler returned wrong status code: got %v want %v", status, tc.status)
+			}
+		})
+	}
+}
+
+func TestSunPositionHandlerErrorEnvelope(t *testing.T) {
+	testCases := []struct {
+		name  string
+		query string
+		code  string
+		param string
+	}{
+		{"Unknown city", "city=Atlantis", "city_not_found", "city"},
+		{"Latitude not a number", "lat=north&lon=10", "invalid_latitude", "lat"},
+		{"Latitude out of range", "lat=91&lon=10", "invalid_latitude", "lat"},
+		{"Longitude out of range", "lat=10&lon=-181", "invalid_longitude", "lon"},
+		{"Bad date", "lat=10&lon=10&date=28-01-2026&time=12:00", "invalid_date_time", "date"},
+		{"Bad time zone", "lat=10&lon=10&tz=Nowhere/Land", "invalid_time_zone", "tz"},
+		{"Bad algorithm", "lat=10&lon=10&algorithm=guess", "invalid_algorithm", "algorithm"},
+		{"Temperature out of range", "lat=10&lon=10&temp=100", "invalid_temperature", "temp"},
+		{"Latitude not finite", "lat=NaN&lon=NaN&date=2026-01-28&time=12:00", "invalid_latitude", "lat"},
+		{"Longitude not finite", "lat=10&lon=Inf", "invalid_longitude", "lon"},
+		{"Elevation not finite", "lat=10&lon=10&elevation=NaN", "invalid_elevation", "elevation"},
+		{"Observer height negative", "lat=10&lon=10&observer_height=-5", "invalid_height", "observer_height"},
+		{"Observer height not finite", "lat=10&lon=10&observer_height=NaN", "invalid_height", "observer_height"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/api/sun-position?"+tc.query, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rr := httptest.NewRecorder()
+			handlers.SunPositionHandler(rr, req)
+
+			if status := rr.Code; status != http.StatusBadRequest {
+				t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusBadRequest)
+			}
+			if contentType := rr.Header().Get("Content-Type"); contentType != "application/json" {
+				t.Errorf("expected JSON content type, got %s", contentType)
+			}
+
+			var response handlers.ErrorResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+				t.Fatalf("could not unmarshal error response: %v", err)
+			}
+			if response.Error == nil {
+				t.Fatalf("expected an error object, got: %s", rr.Body.String())
+			}
+			if response.Error.Code != tc.code {
+				t.Errorf("expected code %s, got %s", tc.code, response.Error.Code)
+			}
+			if response.Error.Param != tc.param {
+				t.Errorf("expected param %s, got %s", tc.param, response.Error.Param)
+			}
+			if response.Error.Message == "" {
+				t.Errorf("expected a message, got none")
+			}
+		})
+	}
+}
+
+func TestAPINotFoundHandler(t *testing.T) {
+	req, err := http.NewRequest("GET", "/sun-pos/api/sun-positon?city=London", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	handlers.APINotFoundHandler(rr, req)
+
+	if status := rr.Code; status != http.StatusNotFound {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusNotFound)
+	}
+	var response handlers.ErrorResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil || response.Error == nil {
+		t.Fatalf("expected an error envelope, got: %s", rr.Body.String())
+	}
+	if response.Error.Code != "not_found" {
+		t.Errorf("expected code not_found, got %s", response.Error.Code)
+	}
+}
+
+// stubIPProvider returns a fixed location for every IP address
+type stubIPProvider struct {
+	location utils.IPLocation
+}
+
+func (p stubIPProvider) Lookup(ctx context.Context, ip string) (*utils.IPLocation, error) {
+	location := p.location
+	return &location, nil
+}
+
+func TestSunPositionHandlerUsesConfiguredIPProvider(t *testing.T) {
+	utils.SetIPLocationProvider(stubIPProvider{location: utils.IPLocation{Country: "Spain"}})
+	defer utils.SetIPLocationProvider(utils.NewIPAPIProvider())
+
+	req, err := http.NewRequest("GET", "/api/sun-position?date=2026-01-28&time=12:00", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.RemoteAddr = "81.2.69.160:54321"
+
+	rr := httptest.NewRecorder()
+	handlers.SunPositionHandler(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+
+	var response map[string]interface{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("could not unmarshal response: %v", err)
+	}
+	if city, _ := response["city"].(string); city != "Madrid" {
+		t.Errorf("expected the capital of the detected country, got: %v", response["city"])
+	}
+}
+
+func TestSunPositionHandlerMatchesIPCountryByCode(t *testing.T) {
+	testCases := []struct {
+		name     string
+		location utils.IPLocation
+		city     string
+	}{
+		// The localized name is unknown, so only the ISO code identifies the country
+		{"Code only", utils.IPLocation{Country: "Nihon", CountryCode: "JP"}, "Tokyo"},
+		// Niger's capital is not in the city list, which must not fall through to Nigeria
+		{"Niger", utils.IPLocation{Country: "Niger", CountryCode: "NE", Lat: "13.5", Lon: "2.1"}, "Niamey"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			utils.SetIPLocationProvider(stubIPProvider{location: tc.location})
+			defer utils.SetIPLocationProvider(utils.NewIPAPIProvider())
+
+			req, _ := http.NewRequest("GET", "/api/sun-position?date=2026-01-28&time=12:00", nil)
+			req.RemoteAddr = "81.2.69.160:54321"
+			rr := httptest.NewRecorder()
+			handlers.SunPositionHandler(rr, req)
+
+			if status := rr.Code; status != http.StatusOK {
+				t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+			}
+			var response handlers.SunPositionResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+				t.Fatalf("could not unmarshal response: %v", err)
+			}
+			if response.City != tc.city {
+				t.Errorf("expected city %s, got %s", tc.city, response.City)
+			}
+		})
+	}
+}
+
+// recordingIPProvider remembers which IP addresses were looked up
+type recordingIPProvider struct {
+	lookups *[]string
+}
+
+func (p recordingIPProvider) Lookup(ctx context.Context, ip string) (*utils.IPLocation, error) {
+	*p.lookups = append(*p.lookups, ip)
+	return &utils.IPLocation{Country: "Spain"}, nil
+}
+
+func TestSunPositionHandlerClientIPFromTrustedProxies(t *testing.T) {
+	var lookups []string
+	utils.SetIPLocationProvider(recordingIPProvider{lookups: &lookups})
+	defer utils.SetIPLocationProvider(utils.NewIPAPIProvider())
+	defer handlers.SetTrustedProxies(handlers.DefaultTrustedProxies)
+	defer handlers.SetForwardedHeader(handlers.DefaultForwardedHeader)
+
+	// A client's own Forwarded header, passed through by nginx next to the X-Forwarded-For it appends to
+	spoofed := map[string]string{"Forwarded": "for=1.2.3.4", "X-Forwarded-For": "1.2.3.4, 81.2.69.160"}
+
+	testCases := []struct {
+		name           string
+		trustedProxies []string
+		header         string // forwarding header the proxy sets, empty for the default
+		remoteAddr     string
+		headers        map[string]string
+		expected       string // empty when no lookup should happen
+	}{
+		{"Direct client ignores spoofed header", nil, "", "203.0.113.5:1234", map[string]string{"X-Forwarded-For": "81.2.69.160"}, "203.0.113.5"},
+		{"Local nginx", nil, "", "127.0.0.1:1234", map[string]string{"X-Forwarded-For": "1.1.1.1, 81.2.69.160"}, "81.2.69.160"},
+		{"X-Real-IP from local nginx", nil, "X-Real-IP", "127.0.0.1:1234", map[string]string{"X-Real-IP": "81.2.69.160"}, "81.2.69.160"},
+		{"Untrusted hop stops the walk", nil, "", "127.0.0.1:1234", map[string]string{"X-Forwarded-For": "81.2.69.160, 198.51.100.9"}, "198.51.100.9"},
+		{"CDN in trusted range", []string{"127.0.0.1", "198.51.100.0/24"}, "", "127.0.0.1:1234", map[string]string{"X-Forwarded-For": "81.2.69.160, 198.51.100.9"}, "81.2.69.160"},
+		{"RFC 7239 Forwarded", nil, "Forwarded", "127.0.0.1:1234", map[string]string{"Forwarded": `for=192.0.2.60;proto=http, for="[2001:db8:cafe::17]:4711"`}, "2001:db8:cafe::17"},
+		{"Forwarded with unknown client", nil, "Forwarded", "127.0.0.1:1234", map[string]string{"Forwarded": "for=unknown"}, ""},
+		{"Client Forwarded header ignored", nil, "", "127.0.0.1:1234", spoofed, "81.2.69.160"},
+		{"Client X-Forwarded-For ignored", nil, "Forwarded", "127.0.0.1:1234", map[string]string{"Forwarded": "for=81.2.69.160", "X-Forwarded-For": "1.2.3.4"}, "81.2.69.160"},
+		{"Unconfigured X-Real-IP ignored", nil, "", "127.0.0.1:1234", map[string]string{"X-Real-IP": "1.2.3.4"}, ""},
+		{"Private client", nil, "", "192.168.1.20:1234", nil, ""},
+		{"Loopback client", nil, "", "127.0.0.1:1234", nil, ""},
+		{"Link-local client", nil, "", "[fe80::1]:1234", nil, ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			proxies := tc.trustedProxies
+			if proxies == nil {
+				proxies = handlers.DefaultTrustedProxies
+			}
+			if err := handlers.SetTrustedProxies(proxies); err != nil {
+				t.Fatal(err)
+			}
+			header := tc.header
+			if header == "" {
+				header = handlers.DefaultForwardedHeader
+			}
+			if err := handlers.SetForwardedHeader(header); err != nil {
+				t.Fatal(err)
+			}
+			lookups = nil
+
+			req, err := http.NewRequest("GET", "/api/sun-position?date=2026-01-28&time=12:00", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.RemoteAddr = tc.remoteAddr
+			for name, value := range tc.headers {
+				req.Header.Set(name, value)
+			}
+
+			rr := httptest.NewRecorder()
+			handlers.SunPositionHandler(rr, req)
+
+			if status := rr.Code; status != http.StatusOK {
+				t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+			}
+
+			switch {
+			case tc.expected == "" && len(lookups) != 0:
+				t.Errorf("expected no lookup, got %v", lookups)
+			case tc.expected != "" && (len(lookups) != 1 || lookups[0] != tc.expected):
+				t.Errorf("expected a lookup of %s, got %v", tc.expected, lookups)
+			}
+		})
+	}
+
+	if err := handlers.SetTrustedProxies([]string{"not-a-network"}); err == nil {
+		t.Errorf("expected an error for an invalid trusted proxy")
+	}
+	if err := handlers.SetForwardedHeader("X-Client-IP"); err == nil {
+		t.Errorf("expected an error for an unsupported forwarded header")
+	}
+}
+
+func TestCitiesHandler(t *testing.T) {
+	testCases := []struct {
+		name     string
+		query    string
+		expected string
+		total    int
+	}{
+		{"Accent-insensitive", "q=Sao+Paulo", "São Paulo", 1},
+		{"Fuzzy", "q=Khartum&mode=fuzzy", "Khartoum", 1},
+		{"Paginated", "limit=2&offset=0", "", len(utils.CommonCities)},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/api/cities?"+tc.query, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rr := httptest.NewRecorder()
+			handlers.CitiesHandler(rr, req)
+
+			if status := rr.Code; status != http.StatusOK {
+				t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+			}
+
+			var response handlers.CitiesResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+				t.Fatalf("could not unmarshal response: %v", err)
+			}
+			if response.Total != tc.total {
+				t.Errorf("expected %d matches, got %d", tc.total, response.Total)
+			}
+			if tc.expected != "" && (len(response.Cities) == 0 || response.Cities[0].Name != tc.expected) {
+				t.Errorf("expected %s first, got %v", tc.expected, response.Cities)
+			}
+			if response.Limit > 0 && len(response.Cities) > response.Limit {
+				t.Errorf("expected at most %d cities, got %d", response.Limit, len(response.Cities))
+			}
+		})
+	}
+
+	for _, query := range []string{"limit=0", "limit=1000", "offset=-1", "mode=regex"} {
+		req, err := http.NewRequest("GET", "/api/cities?"+query, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := httptest.NewRecorder()
+		handlers.CitiesHandler(rr, req)
+
+		if status := rr.Code; status != http.StatusBadRequest {
+			t.Errorf("handler returned wrong status code for %s: got %v want %v", query, status, http.StatusBadRequest)
+		}
+	}
+}
+
+func TestCountriesHandler(t *testing.T) {
+	req, err := http.NewRequest("GET", "/api/countries?q=united", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	handlers.CountriesHandler(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+
+	var response handlers.CountriesResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("could not unmarshal response: %v", err)
+	}
+	if len(response.Countries) != 0 {
+		t.Errorf("expected no country named United in the city list, got %v", response.Countries)
+	}
+
+	req, _ = http.NewRequest("GET", "/api/countries", nil)
+	rr = httptest.NewRecorder()
+	handlers.CountriesHandler(rr, req)
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("could not unmarshal response: %v", err)
+	}
+	if len(response.Countries) == 0 || response.Countries[0].Name != "Argentina" {
+		t.Errorf("expected countries sorted by name starting with Argentina, got %v", response.Countries)
+	}
+}
+
+func TestSunPositionHandlerWithAccentInsensitiveCity(t *testing.T) {
+	req, err := http.NewRequest("GET", "/api/sun-position?city=Sao+Paulo&date=2026-01-28&time=12:00", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	handlers.SunPositionHandler(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+	if !strings.Contains(rr.Body.String(), `"city":"São Paulo"`) {
+		t.Errorf("expected the canonical city name in the response, got %s", rr.Body.String())
+	}
+}
+
+func TestNearestCityHandler(t *testing.T) {
+	// A point in Versailles, about 17 km west-southwest of central Paris
+	req, err := http.NewRequest("GET", "/api/nearest-city?lat=48.8049&lon=2.1204&limit=2", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	handlers.NearestCityHandler(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+
+	var response handlers.NearestCityResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("could not unmarshal response: %v", err)
+	}
+	if len(response.Cities) != 2 {
+		t.Fatalf("expected 2 cities, got %d", len(response.Cities))
+	}
+	nearest := response.Cities[0]
+	if nearest.City.Name != "Paris" || nearest.Direction != "ENE" {
+		t.Errorf("expected Paris to the east-northeast, got %s to the %s", nearest.City.Name, nearest.Direction)
+	}
+	if nearest.DistanceKm < 15 || nearest.DistanceKm > 20 {
+		t.Errorf("expected Paris about 17 km away, got %.1f km", nearest.DistanceKm)
+	}
+	if response.Cities[1].DistanceKm < nearest.DistanceKm {
+		t.Errorf("expected cities sorted by distance, got %v", response.Cities)
+	}
+
+	testCases := []struct {
+		name  string
+		query string
+		code  string
+	}{
+		{"Missing coordinates", "", "invalid_latitude"},
+		{"Longitude out of range", "lat=48.8&lon=200", "invalid_longitude"},
+		{"Latitude not finite", "lat=NaN&lon=10", "invalid_latitude"},
+		{"Limit too large", "lat=48.8&lon=2.1&limit=500", "invalid_limit"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/api/nearest-city?"+tc.query, nil)
+			rr := httptest.NewRecorder()
+			handlers.NearestCityHandler(rr, req)
+
+			if status := rr.Code; status != http.StatusBadRequest {
+				t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusBadRequest)
+			}
+			if !strings.Contains(rr.Body.String(), `"code":"`+tc.code+`"`) {
+				t.Errorf("expected error code %s, got %s", tc.code, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestSunPositionHandlerNamesNearbyCity(t *testing.T) {
+	testCases := []struct {
+		name  string
+		query string
+		city  string
+	}{
+		{"Near Paris", "lat=48.8049&lon=2.1204", "Paris"},
+		{"Open ocean", "lat=-40&lon=-120", ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/api/sun-position?"+tc.query+"&date=2026-06-21&time=12:00", nil)
+			rr := httptest.NewRecorder()
+			handlers.SunPositionHandler(rr, req)
+
+			if status := rr.Code; status != http.StatusOK {
+				t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+			}
+			var response handlers.SunPositionResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+				t.Fatalf("could not unmarshal response: %v", err)
+			}
+			if response.City != tc.city {
+				t.Errorf("expected city %q, got %q", tc.city, response.City)
+			}
+		})
+	}
+}
+
+func TestSolarCalendarHandler(t *testing.T) {
+	req, err := http.NewRequest("GET", "/api/solar-calendar?city=London&year=2025", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	handlers.SolarCalendarHandler(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+
+	var response handlers.SolarCalendarResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("could not unmarshal response: %v", err)
+	}
+	if len(response.Days) != 365 {
+		t.Fatalf("expected 365 days, got %d", len(response.Days))
+	}
+	if response.Days[0].Date != "2025-01-01" || response.Days[364].Date != "2025-12-31" {
+		t.Errorf("expected the calendar to run from 2025-01-01 to 2025-12-31, got %s to %s", response.Days[0].Date, response.Days[364].Date)
+	}
+	if response.TimeZone != "Europe/London" {
+		t.Errorf("expected time zone Europe/London, got %s", response.TimeZone)
+	}
+	if response.Summary.LongestDay < "2025-06-20" || response.Summary.LongestDay > "2025-06-22" {
+		t.Errorf("expected the longest day at the June solstice, got %s", response.Summary.LongestDay)
+	}
+	if response.Summary.ShortestDay < "2025-12-20" || response.Summary.ShortestDay > "2025-12-22" {
+		t.Errorf("expected the shortest day at the December solstice, got %s", response.Summary.ShortestDay)
+	}
+}
+
+func TestSolarCalendarHandlerCSV(t *testing.T) {
+	req, err := http.NewRequest("GET", "/api/solar-calendar?lat=78.22&lon=15.65&year=2024&format=csv&tz=UTC", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	handlers.SolarCalendarHandler(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+	if contentType := rr.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "text/csv") {
+		t.Errorf("expected a CSV content type, got %s", contentType)
+	}
+
+	lines := strings.Split(strings.TrimSpace(rr.Body.String()), "\n")
+	if len(lines) != 367 {
+		t.Fatalf("expected a header and 366 rows, got %d lines", len(lines))
+	}
+	if lines[0] != "date,sunrise,sunset,solar_noon,max_altitude,day_length,daylight_state" {
+		t.Errorf("unexpected header: %s", lines[0])
+	}
+	// Svalbard is in polar night on New Year's Day
+	if !strings.HasPrefix(lines[1], "2024-01-01,N/A,N/A,") || !strings.HasSuffix(lines[1], ",00:00,polar_night") {
+		t.Errorf("expected polar night on 2024-01-01, got %s", lines[1])
+	}
+}
+
+func TestSolarCalendarHandlerErrors(t *testing.T) {
+	testCases := []struct {
+		name  string
+		query string
+		code  string
+	}{
+		{"Year out of range", "city=London&year=1066", "invalid_year"},
+		{"Year not a number", "city=London&year=next", "invalid_year"},
+		{"Unknown format", "city=London&format=xml", "invalid_format"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/api/solar-calendar?"+tc.query, nil)
+			rr := httptest.NewRecorder()
+			handlers.SolarCalendarHandler(rr, req)
+
+			if status := rr.Code; status != http.StatusBadRequest {
+				t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusBadRequest)
+			}
+			if !strings.Contains(rr.Body.String(), `"code":"`+tc.code+`"`) {
+				t.Errorf("expected error code %s, got %s", tc.code, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestSeasonsHandler(t *testing.T) {
+	req, err := http.NewRequest("GET", "/api/seasons?year=2024", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	handlers.SeasonsHandler(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+	if !strings.Contains(rr.Body.String(), `"march_equinox":"2024-03-20T03:06:`) {
+		t.Errorf("expected the March equinox at 03:06 UTC, got %s", rr.Body.String())
+	}
+
+	req, _ = http.NewRequest("GET", "/api/seasons?year=2024&tz=Asia/Tokyo", nil)
+	rr = httptest.NewRecorder()
+	handlers.SeasonsHandler(rr, req)
+	if !strings.Contains(rr.Body.String(), `"december_solstice":"2024-12-21T18:20:`) || !strings.Contains(rr.Body.String(), "+09:00") {
+		t.Errorf("expected the December solstice in Tokyo time, got %s", rr.Body.String())
+	}
+
+	testCases := []struct {
+		name  string
+		query string
+		code  string
+	}{
+		{"Year too early", "year=1899", "invalid_year"},
+		{"Year too late", "year=2101", "invalid_year"},
+		{"Year not a number", "year=soon", "invalid_year"},
+		{"Unknown time zone", "year=2024&tz=Mars/Olympus", "invalid_time_zone"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/api/seasons?"+tc.query, nil)
+			rr := httptest.NewRecorder()
+			handlers.SeasonsHandler(rr, req)
+
+			if status := rr.Code; status != http.StatusBadRequest {
+				t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusBadRequest)
+			}
+			if !strings.Contains(rr.Body.String(), `"code":"`+tc.code+`"`) {
+				t.Errorf("expected error code %s, got %s", tc.code, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestSunPositionHandlerPolarStates(t *testing.T) {
+	testCases := []struct {
+		name        string
+		query       string
+		state       utils.DaylightState
+		nextSunrise bool
+		nextSunset  bool
+	}{
+		{"Reykjavik in winter", "city=Reykjavik&date=2026-12-21&time=12:00", utils.DaylightNormal, false, false},
+		{"Tromsø polar night", "lat=69.6492&lon=18.9553&date=2026-12-21&time=12:00", utils.PolarNight, true, false},
+		{"Tromsø midnight sun", "lat=69.6492&lon=18.9553&date=2026-06-21&time=12:00", utils.PolarDay, false, true},
+		{"Antarctic summer", "lat=-77.8419&lon=166.6863&date=2026-12-21&time=12:00", utils.PolarDay, false, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/api/sun-position?"+tc.query, nil)
+			rr := httptest.NewRecorder()
+			handlers.SunPositionHandler(rr, req)
+
+			if status := rr.Code; status != http.StatusOK {
+				t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+			}
+			var response handlers.SunPositionResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+				t.Fatalf("could not unmarshal response: %v", err)
+			}
+			if response.DaylightState != tc.state {
+				t.Errorf("expected daylight state %s, got %s", tc.state, response.DaylightState)
+			}
+			if (response.NextSunrise != nil) != tc.nextSunrise || (response.NextSunset != nil) != tc.nextSunset {
+				t.Errorf("expected next sunrise %v and next sunset %v, got %v and %v", tc.nextSunrise, tc.nextSunset, response.NextSunrise, response.NextSunset)
+			}
+			if response.NextSunrise != nil && !response.NextSunrise.After(response.Timestamp) {
+				t.Errorf("expected the next sunrise after %v, got %v", response.Timestamp, response.NextSunrise)
+			}
+		})
+	}
+}
+
+func TestMoonPositionHandler(t *testing.T) {
+	req, err := http.NewRequest("GET", "/api/moon-position?city=London&date=2024-04-23&time=23:00", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	handlers.MoonPositionHandler(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+
+	var response handlers.MoonPositionResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("could not parse response: %v", err)
+	}
+	if response.City != "London" || response.TimeZone != "Europe/London" {
+		t.Errorf("expected London in Europe/London, got %s in %s", response.City, response.TimeZone)
+	}
+	if response.Phase != utils.FullMoon || response.Illumination < 0.99 {
+		t.Errorf("expected a full moon, got %s with illumination %f", response.Phase, response.Illumination)
+	}
+	if response.MoonAltitude <= 0 {
+		t.Errorf("expected the full moon above the horizon before midnight, got altitude %f", response.MoonAltitude)
+	}
+	if response.Moonrise == "N/A" || response.Moonset == "N/A" {
+		t.Errorf("expected a moonrise and a moonset, got %s and %s", response.Moonrise, response.Moonset)
+	}
+
+	testCases := []struct {
+		name  string
+		query string
+		code  string
+	}{
+		{"Unknown city", "city=Atlantis", "city_not_found"},
+		{"Invalid date", "city=London&date=2024-13-01&time=12:00", "invalid_date_time"},
+		{"Unknown time zone", "city=London&tz=Mars/Olympus", "invalid_time_zone"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/api/moon-position?"+tc.query, nil)
+			rr := httptest.NewRecorder()
+			handlers.MoonPositionHandler(rr, req)
+
+			if status := rr.Code; status != http.StatusBadRequest {
+				t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusBadRequest)
+			}
+			if !strings.Contains(rr.Body.String(), `"code":"`+tc.code+`"`) {
+				t.Errorf("expected error code %s, got %s", tc.code, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestIrradianceHandler(t *testing.T) {
+	testCases := []struct {
+		name  string
+		query string
+		model utils.ClearSkyModel
+	}{
+		{"Default model", "city=Madrid&date=2026-06-21&time=14:00", utils.ClearSkyIneichen},
+		{"Haurwitz", "city=Madrid&date=2026-06-21&time=14:00&model=haurwitz", utils.ClearSkyHaurwitz},
+		{"Bird with SPA", "city=Madrid&date=2026-06-21&time=14:00&model=bird&algorithm=spa&aod500=0.2", utils.ClearSkyBird},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/api/irradiance?"+tc.query, nil)
+			rr := httptest.NewRecorder()
+			handlers.IrradianceHandler(rr, req)
+
+			if status := rr.Code; status != http.StatusOK {
+				t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+			}
+			var response handlers.IrradianceResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+				t.Fatalf("could not parse response: %v", err)
+			}
+			if response.Model != tc.model {
+				t.Errorf("expected model %s, got %s", tc.model, response.Model)
+			}
+			// Near noon at midsummer in Madrid the sun is about 70° high
+			if response.GHI < 800 || response.GHI > 1100 || response.DNI < 700 || response.DHI <= 0 {
+				t.Errorf("expected midsummer noon irradiance, got GHI %f, DNI %f, DHI %f", response.GHI, response.DNI, response.DHI)
+			}
+			if response.Extraterrestrial < 1310 || response.Extraterrestrial > 1325 {
+				t.Errorf("expected the aphelion extraterrestrial irradiance, got %f", response.Extraterrestrial)
+			}
+		})
+	}
+
+	req, _ := http.NewRequest("GET", "/api/irradiance?city=Madrid&date=2026-06-21&time=23:30", nil)
+	rr := httptest.NewRecorder()
+	handlers.IrradianceHandler(rr, req)
+	if !strings.Contains(rr.Body.String(), `"ghi":0,"dni":0,"dhi":0`) {
+		t.Errorf("expected no irradiance at night, got %s", rr.Body.String())
+	}
+}
+
+func TestIrradianceHandlerErrors(t *testing.T) {
+	testCases := []struct {
+		name  string
+		query string
+		code  string
+		param string
+	}{
+		{"Unknown model", "city=Madrid&model=cloudy", "invalid_model", "model"},
+		{"Unknown algorithm", "city=Madrid&algorithm=guess", "invalid_algorithm", "algorithm"},
+		{"Turbidity not a number", "city=Madrid&linke_turbidity=high", "invalid_clear_sky_condition", "linke_turbidity"},
+		{"Albedo out of range", "city=Madrid&albedo=2", "invalid_clear_sky_condition", "albedo"},
+		{"Turbidity NaN", "city=Madrid&linke_turbidity=NaN", "invalid_clear_sky_condition", "linke_turbidity"},
+		{"Ozone infinite", "city=Madrid&ozone=Inf", "invalid_clear_sky_condition", "ozone"},
+		{"Unknown city", "city=Atlantis", "city_not_found", "city"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/api/irradiance?"+tc.query, nil)
+			rr := httptest.NewRecorder()
+			handlers.IrradianceHandler(rr, req)
+
+			if status := rr.Code; status != http.StatusBadRequest {
+				t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusBadRequest)
+			}
+			if !strings.Contains(rr.Body.String(), `"code":"`+tc.code+`"`) || !strings.Contains(rr.Body.String(), `"param":"`+tc.param+`"`) {
+				t.Errorf("expected error code %s for %s, got %s", tc.code, tc.param, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestPanelEnergyHandler(t *testing.T) {
+	req, err := http.NewRequest("GET", "/api/panel-energy?city=Sydney&date=2026-06-21", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	handlers.PanelEnergyHandler(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+
+	var response handlers.PanelEnergyResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("could not parse response: %v", err)
+	}
+	// Without a panel orientation the panel is tilted at the latitude toward the equator
+	if response.Panel.Tilt != 34 || response.Panel.Azimuth != 0 {
+		t.Errorf("expected a panel tilted 34° facing north, got %+v", response.Panel)
+	}
+	if response.Transposition != utils.TranspositionHayDavies || response.Model != utils.ClearSkyIneichen {
+		t.Errorf("expected the default models, got %s and %s", response.Model, response.Transposition)
+	}
+	if response.DailyEnergy <= 0 || response.AnnualEnergy < 300*response.DailyEnergy {
+		t.Errorf("expected plausible daily and annual energy, got %f and %f", response.DailyEnergy, response.AnnualEnergy)
+	}
+	if response.Optimal.Azimuth != 0 || response.Optimal.AnnualEnergy < response.AnnualEnergy {
+		t.Errorf("expected a north facing optimum at least as good as the panel, got %+v", response.Optimal)
+	}
+
+	// A vertical east wall collects less than the optimum
+	req, _ = http.NewRequest("GET", "/api/panel-energy?city=Sydney&date=2026-06-21&tilt=90&azimuth=90&transposition=isotropic", nil)
+	rr = httptest.NewRecorder()
+	handlers.PanelEnergyHandler(rr, req)
+	response = handlers.PanelEnergyResponse{}
+	json.Unmarshal(rr.Body.Bytes(), &response)
+	if response.Panel.Tilt != 90 || response.AnnualEnergy >= response.Optimal.AnnualEnergy {
+		t.Errorf("expected the east wall below the optimum, got %f and %f", response.AnnualEnergy, response.Optimal.AnnualEnergy)
+	}
+
+	testCases := []struct {
+		name  string
+		query string
+		code  string
+	}{
+		{"Tilt too steep", "city=Sydney&tilt=95", "invalid_tilt"},
+		{"Azimuth not a number", "city=Sydney&azimuth=south", "invalid_azimuth"},
+		{"Tilt NaN", "city=Sydney&tilt=NaN", "invalid_tilt"},
+		{"Azimuth infinite", "city=Sydney&azimuth=Inf", "invalid_azimuth"},
+		{"Unknown transposition", "city=Sydney&transposition=perez", "invalid_transposition"},
+		{"Invalid date", "city=Sydney&date=21/06/2026", "invalid_date"},
+		{"Albedo out of range", "city=Sydney&albedo=-1", "invalid_clear_sky_condition"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/api/panel-energy?"+tc.query, nil)
+			rr := httptest.NewRecorder()
+			handlers.PanelEnergyHandler(rr, req)
+
+			if status := rr.Code; status != http.StatusBadRequest {
+				t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusBadRequest)
+			}
+			if !strings.Contains(rr.Body.String(), `"code":"`+tc.code+`"`) {
+				t.Errorf("expected error code %s, got %s", tc.code, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestShadowHandler(t *testing.T) {
+	req, err := http.NewRequest("GET", "/api/shadow?city=Madrid&date=2026-06-21&time=14:15&height=10&step=60", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	handlers.ShadowHandler(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+
+	var response handlers.ShadowResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("could not parse response: %v", err)
+	}
+	if response.Shadow == nil {
+		t.Fatalf("expected a shadow at midday, got none")
+	}
+	// At solar noon on the solstice the sun is 73° high and due south
+	if math.Abs(response.Shadow.Length-3.06) > 0.05 || math.Min(response.Shadow.Direction, 360-response.Shadow.Direction) > 2 {
+		t.Errorf("expected a 3.06 m shadow pointing north, got %+v", response.Shadow)
+	}
+	if response.Shadow.TipLatitude <= 40.4168 {
+		t.Errorf("expected the shadow tip north of Madrid, got %f", response.Shadow.TipLatitude)
+	}
+	if len(response.Path) < 14 || len(response.Path) > 16 || response.Step != 60 {
+		t.Errorf("expected about 15 hourly daylight points, got %d at %d minutes", len(response.Path), response.Step)
+	}
+
+	// At night there is no shadow, but the day's path is still returned
+	req, _ = http.NewRequest("GET", "/api/shadow?city=Madrid&date=2026-06-21&time=23:30&height=10", nil)
+	rr = httptest.NewRecorder()
+	handlers.ShadowHandler(rr, req)
+	if !strings.Contains(rr.Body.String(), `"shadow":null`) || strings.Contains(rr.Body.String(), `"path":[]`) {
+		t.Errorf("expected no shadow and a daytime path at night, got %s", rr.Body.String())
+	}
+
+	testCases := []struct {
+		name  string
+		query string
+		code  string
+	}{
+		{"Missing height", "city=Madrid", "invalid_height"},
+		{"Negative height", "city=Madrid&height=-3", "invalid_height"},
+		{"Height too large", "city=Madrid&height=5000", "invalid_height"},
+		{"Height NaN", "city=Madrid&height=NaN", "invalid_height"},
+		{"Height infinite", "city=Madrid&height=Inf", "invalid_height"},
+		{"Invalid step", "city=Madrid&height=10&step=0", "invalid_step"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/api/shadow?"+tc.query, nil)
+			rr := httptest.NewRecorder()
+			handlers.ShadowHandler(rr, req)
+
+			if status := rr.Code; status != http.StatusBadRequest {
+				t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusBadRequest)
+			}
+			if !strings.Contains(rr.Body.String(), `"code":"`+tc.code+`"`) {
+				t.Errorf("expected error code %s, got %s", tc.code, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestSunPositionHandlerBearings(t *testing.T) {
+	req, err := http.NewRequest("GET", "/api/sun-position?city=London&date=2026-06-21&time=12:00", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	handlers.SunPositionHandler(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+
+	var response handlers.SunPositionResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("could not parse response: %v", err)
+	}
+	if response.SunriseAzimuth == nil || response.SunsetAzimuth == nil {
+		t.Fatalf("expected sunrise and sunset azimuths, got %s", rr.Body.String())
+	}
+	if math.Abs(*response.SunriseAzimuth-49.3) > 1 || math.Abs(*response.SunsetAzimuth-310.7) > 1 {
+		t.Errorf("expected the sun to rise at 49° and set at 311°, got %f and %f", *response.SunriseAzimuth, *response.SunsetAzimuth)
+	}
+	bearings := response.SolsticeBearings
+	if bearings.JuneSunrise == nil || bearings.DecemberSunrise == nil || *bearings.DecemberSunrise < 120 {
+		t.Errorf("expected both solstice bearings for London, got %s", rr.Body.String())
+	}
+
+	// No sunrise or sunset during the midnight sun
+	req, _ = http.NewRequest("GET", "/api/sun-position?lat=69.6492&lon=18.9553&date=2026-06-21&time=12:00", nil)
+	rr = httptest.NewRecorder()
+	handlers.SunPositionHandler(rr, req)
+	if strings.Contains(rr.Body.String(), "sunrise_azimuth") || !strings.Contains(rr.Body.String(), `"solstice_bearings":{}`) {
+		t.Errorf("expected no rise or set bearings in Tromsø at midsummer, got %s", rr.Body.String())
+	}
+}
+
+func TestAlignmentsHandler(t *testing.T) {
+	req, err := http.NewRequest("GET", "/api/alignments?lat=40.7128&lon=-74.0060&azimuth=299.6&altitude=0.27&azimuth_tolerance=0.05&altitude_tolerance=0.05&start=2024-01-01&end=2024-12-31&algorithm=spa", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	handlers.AlignmentsHandler(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+
+	var response handlers.AlignmentsResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("could not parse response: %v", err)
+	}
+	if response.TimeZone != "America/New_York" || len(response.Alignments) != 2 {
+		t.Fatalf("expected two Manhattanhenge sunsets in New York time, got %s", rr.Body.String())
+	}
+	if !strings.HasPrefix(response.Alignments[0].Time.Format(time.RFC3339), "2024-05-29T20:") {
+		t.Errorf("expected the first alignment on the evening of May 29, got %v", response.Alignments[0].Time)
+	}
+
+	// Without an altitude, every day the sun passes due south counts
+	req, _ = http.NewRequest("GET", "/api/alignments?city=London&azimuth=180&start=2026-03-01&end=2026-03-07", nil)
+	rr = httptest.NewRecorder()
+	handlers.AlignmentsHandler(rr, req)
+	response = handlers.AlignmentsResponse{}
+	json.Unmarshal(rr.Body.Bytes(), &response)
+	if len(response.Alignments) != 7 || response.Target.AltitudeTolerance != 45 {
+		t.Errorf("expected 7 daily meridian crossings, got %s", rr.Body.String())
+	}
+
+	testCases := []struct {
+		name  string
+		query string
+		code  string
+	}{
+		{"Missing azimuth", "city=London", "invalid_azimuth"},
+		{"Altitude too high", "city=London&azimuth=90&altitude=95", "invalid_altitude"},
+		{"Zero tolerance", "city=London&azimuth=90&azimuth_tolerance=0", "invalid_tolerance"},
+		{"Azimuth NaN", "city=London&azimuth=NaN", "invalid_azimuth"},
+		{"Altitude NaN", "city=London&azimuth=90&altitude=NaN", "invalid_altitude"},
+		{"Azimuth tolerance NaN", "city=London&azimuth=90&azimuth_tolerance=NaN", "invalid_tolerance"},
+		{"Altitude tolerance infinite", "city=London&azimuth=90&altitude=10&altitude_tolerance=Inf", "invalid_tolerance"},
+		{"Invalid start", "city=London&azimuth=90&start=tomorrow", "invalid_date"},
+		{"End before start", "city=London&azimuth=90&start=2026-03-01&end=2026-02-01", "invalid_date_range"},
+		{"Range too long", "city=London&azimuth=90&start=2026-01-01&end=2027-06-01", "invalid_date_range"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/api/alignments?"+tc.query, nil)
+			rr := httptest.NewRecorder()
+			handlers.AlignmentsHandler(rr, req)
+
+			if status := rr.Code; status != http.StatusBadRequest {
+				t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusBadRequest)
+			}
+			if !strings.Contains(rr.Body.String(), `"code":"`+tc.code+`"`) {
+				t.Errorf("expected error code %s, got %s", tc.code, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestHorizonHandlerUpload(t *testing.T) {
+	// Hills rising to 10° from north-east round to north-west
+	csvProfile := "azimuth,elevation\n0,2\n45,10\n315,10\n"
+	jsonProfile := `[{"azimuth": 0, "elevation": 2}, {"azimuth": 45, "elevation": 10}, {"azimuth": 315, "elevation": 10}]`
+
+	for contentType, body := range map[string]string{"text/csv": csvProfile, "application/json": jsonProfile} {
+		t.Run(contentType, func(t *testing.T) {
+			req, err := http.NewRequest("POST", "/api/horizon?city=London&date=2024-06-21", strings.NewReader(body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Content-Type", contentType)
+
+			rr := httptest.NewRecorder()
+			handlers.HorizonHandler(rr, req)
+
+			if status := rr.Code; status != http.StatusOK {
+				t.Fatalf("handler returned wrong status code: got %v want %v, body %s", status, http.StatusOK, rr.Body.String())
+			}
+
+			var response handlers.HorizonResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+				t.Fatalf("could not parse response: %v", err)
+			}
+			if response.Source != "upload" || len(response.Profile) != 3 || len(response.Periods) != 1 {
+				t.Fatalf("expected one period of sun behind the uploaded profile, got %s", rr.Body.String())
+			}
+			// The hills delay first light and bring last light forward
+			if response.FirstLight <= response.Sunrise || response.LastLight >= response.Sunset {
+				t.Errorf("expected first and last light inside %s-%s, got %s-%s", response.Sunrise, response.Sunset, response.FirstLight, response.LastLight)
+			}
+			if response.DirectSun >= response.DayLength || response.DirectSunHours <= 0 {
+				t.Errorf("expected less direct sun than the %s day, got %s", response.DayLength, response.DirectSun)
+			}
+		})
+	}
+}
+
+func TestHorizonHandlerTerrain(t *testing.T) {
+	defer utils.SetTerrain(nil)
+
+	req, _ := http.NewRequest("GET", "/api/horizon?city=London&date=2024-06-21", nil)
+	rr := httptest.NewRecorder()
+	handlers.HorizonHandler(rr, req)
+	if status := rr.Code; status != http.StatusNotFound || !strings.Contains(rr.Body.String(), `"code":"no_elevation_data"`) {
+		t.Fatalf("expected no_elevation_data without terrain, got %v %s", status, rr.Body.String())
+	}
+
+	// A flat sea-level tile around London
+	path := filepath.Join(t.TempDir(), "N51W001.hgt")
+	if err := os.WriteFile(path, make([]byte, 11*11*2), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	terrain, err := utils.LoadTerrain(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	utils.SetTerrain(terrain)
+
+	rr = httptest.NewRecorder()
+	handlers.HorizonHandler(rr, req)
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v, body %s", status, http.StatusOK, rr.Body.String())
+	}
+	var response handlers.HorizonResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("could not parse response: %v", err)
+	}
+	if response.Source != "terrain" || len(response.Profile) != 360 {
+		t.Errorf("expected a profile computed from terrain every degree, got %s with %d points", response.Source, len(response.Profile))
+	}
+
+	// From a tower the flat terrain lies further below the astronomical horizon than from eye height
+	eyeLevel := response.Profile[0].Elevation
+	req, _ = http.NewRequest("GET", "/api/horizon?city=London&date=2024-06-21&observer_height=300", nil)
+	rr = httptest.NewRecorder()
+	handlers.HorizonHandler(rr, req)
+	response = handlers.HorizonResponse{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil || len(response.Profile) == 0 {
+		t.Fatalf("could not parse response: %v %s", err, rr.Body.String())
+	}
+	if elevation := response.Profile[0].Elevation; elevation >= eyeLevel {
+		t.Errorf("expected the terrain lower from 300 m than from eye height, got %f and %f", elevation, eyeLevel)
+	}
+
+	// An explicit zero puts the observer on the ground rather than at eye height
+	req, _ = http.NewRequest("GET", "/api/horizon?city=London&date=2024-06-21&observer_height=0", nil)
+	rr = httptest.NewRecorder()
+	handlers.HorizonHandler(rr, req)
+	response = handlers.HorizonResponse{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil || len(response.Profile) == 0 {
+		t.Fatalf("could not parse response: %v %s", err, rr.Body.String())
+	}
+	if elevation := response.Profile[0].Elevation; elevation <= eyeLevel {
+		t.Errorf("expected the terrain higher from the ground than from eye height, got %f and %f", elevation, eyeLevel)
+	}
+
+	for query, code := range map[string]string{"observer_height=-1": "invalid_height", "observer_height=NaN": "invalid_height", "distance=500": "invalid_distance", "distance=NaN": "invalid_distance"} {
+		req, _ := http.NewRequest("GET", "/api/horizon?city=London&"+query, nil)
+		rr := httptest.NewRecorder()
+		handlers.HorizonHandler(rr, req)
+		if status := rr.Code; status != http.StatusBadRequest || !strings.Contains(rr.Body.String(), `"code":"`+code+`"`) {
+			t.Errorf("expected %s for %s, got %v %s", code, query, status, rr.Body.String())
+		}
+	}
+
+	// Outside the tile there is nothing to compute the profile from
+	req, _ = http.NewRequest("GET", "/api/horizon?city=Paris&date=2024-06-21", nil)
+	rr = httptest.NewRecorder()
+	handlers.HorizonHandler(rr, req)
+	if status := rr.Code; status != http.StatusNotFound {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusNotFound)
+	}
+}
+
+func TestHorizonHandlerErrors(t *testing.T) {
+	testCases := []struct {
+		name   string
+		method string
+		query  string
+		body   string
+		status int
+		code   string
+	}{
+		{"Wrong method", "DELETE", "city=London", "", http.StatusMethodNotAllowed, "method_not_allowed"},
+		{"Invalid date", "POST", "city=London&date=soon", "[]", http.StatusBadRequest, "invalid_date"},
+		{"Malformed JSON", "POST", "city=London", `[{"azimuth": }]`, http.StatusBadRequest, "invalid_horizon"},
+		{"Elevation out of range", "POST", "city=London", `[{"azimuth": 90, "elevation": 95}]`, http.StatusBadRequest, "invalid_horizon"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, _ := http.NewRequest(tc.method, "/api/horizon?"+tc.query, strings.NewReader(tc.body))
+			rr := httptest.NewRecorder()
+			handlers.HorizonHandler(rr, req)
+
+			if status := rr.Code; status != tc.status {
+				t.Errorf("handler returned wrong status code: got %v want %v", status, tc.status)
+			}
+			if !strings.Contains(rr.Body.String(), `"code":"`+tc.code+`"`) {
+				t.Errorf("expected error code %s, got %s", tc.code, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestPrayerTimesHandler(t *testing.T) {
+	req, err := http.NewRequest("GET", "/api/prayer-times?city=Cairo&date=2024-01-01&method=egyptian", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	handlers.PrayerTimesHandler(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+
+	var response handlers.PrayerTimesResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("could not parse response: %v", err)
+	}
+	if response.TimeZone != "Africa/Cairo" || response.FajrAngle != 19.5 || response.AsrMethod != "shafii" {
+		t.Errorf("expected the Egyptian method in Cairo time, got %s", rr.Body.String())
+	}
+	if response.Fajr != "05:18" || response.Dhuhr != "11:57" || response.Maghrib != "17:05" {
+		t.Errorf("expected Fajr 05:18, Dhuhr 11:57 and Maghrib 17:05, got %s, %s and %s", response.Fajr, response.Dhuhr, response.Maghrib)
+	}
+
+	// Umm al-Qura reports Isha as an interval, and Hanafi Asr comes later
+	req, _ = http.NewRequest("GET", "/api/prayer-times?city=Riyadh&date=2024-01-01&method=ummalqura&asr=hanafi", nil)
+	rr = httptest.NewRecorder()
+	handlers.PrayerTimesHandler(rr, req)
+	response = handlers.PrayerTimesResponse{}
+	json.Unmarshal(rr.Body.Bytes(), &response)
+	if response.IshaInterval != 90 || response.IshaAngle != 0 || response.Asr <= "15:00" {
+		t.Errorf("expected Isha 90 minutes after Maghrib and a late Asr, got %s", rr.Body.String())
+	}
+
+	req, _ = http.NewRequest("GET", "/api/prayer-times?city=Riyadh&date=2024-03-20&method=ummalqura&ramadan=true", nil)
+	rr = httptest.NewRecorder()
+	handlers.PrayerTimesHandler(rr, req)
+	response = handlers.PrayerTimesResponse{}
+	json.Unmarshal(rr.Body.Bytes(), &response)
+	if response.IshaInterval != 120 || !response.Ramadan {
+		t.Errorf("expected Isha 120 minutes after Maghrib in Ramadan, got %s", rr.Body.String())
+	}
+
+	// London's short summer nights need the high latitude rule
+	req, _ = http.NewRequest("GET", "/api/prayer-times?city=London&date=2024-06-21&high_latitude=none", nil)
+	rr = httptest.NewRecorder()
+	handlers.PrayerTimesHandler(rr, req)
+	response = handlers.PrayerTimesResponse{}
+	json.Unmarshal(rr.Body.Bytes(), &response)
+	if response.Fajr != "N/A" || response.Isha != "N/A" {
+		t.Errorf("expected no Fajr or Isha without a rule, got %s", rr.Body.String())
+	}
+
+	req, _ = http.NewRequest("GET", "/api/prayer-times?city=London&date=2024-06-21", nil)
+	rr = httptest.NewRecorder()
+	handlers.PrayerTimesHandler(rr, req)
+	response = handlers.PrayerTimesResponse{}
+	json.Unmarshal(rr.Body.Bytes(), &response)
+	if response.HighLatitude != "angle_based" || len(response.Adjusted) != 2 || response.Fajr == "N/A" {
+		t.Errorf("expected angle-based Fajr and Isha, got %s", rr.Body.String())
+	}
+
+	testCases := []struct {
+		name  string
+		query string
+		code  string
+	}{
+		{"Unknown method", "city=Khartoum&method=jafari", "invalid_method"},
+		{"Unknown Asr method", "city=Khartoum&asr=early", "invalid_asr_method"},
+		{"Unknown high latitude rule", "city=Khartoum&high_latitude=nearest", "invalid_high_latitude_rule"},
+		{"Invalid date", "city=Khartoum&date=2024-13-01", "invalid_date"},
+		{"Invalid ramadan flag", "city=Khartoum&ramadan=maybe", "invalid_ramadan"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/api/prayer-times?"+tc.query, nil)
+			rr := httptest.NewRecorder()
+			handlers.PrayerTimesHandler(rr, req)
+
+			if status := rr.Code; status != http.StatusBadRequest {
+				t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusBadRequest)
+			}
+			if !strings.Contains(rr.Body.String(), `"code":"`+tc.code+`"`) {
+				t.Errorf("expected error code %s, got %s", tc.code, rr.Body.String())
+			}
+		})
+	}
+}
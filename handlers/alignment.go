@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"sun-position/utils"
+)
+
+// Accepted target altitudes and tolerances for the alignment search, in degrees
+const (
+	minAlignmentAltitude      = -5.0
+	maxAlignmentAltitude      = 90.0
+	maxAlignmentTolerance     = 45.0
+	defaultAlignmentTolerance = 0.5
+)
+
+// AlignmentsResponse holds the moments the sun lines up with a target direction over a date range
+type AlignmentsResponse struct {
+	Location   string                `json:"location"`
+	City       string                `json:"city,omitempty"`
+	TimeZone   string                `json:"timezone"`
+	Algorithm  string                `json:"algorithm"`
+	Target     utils.AlignmentTarget `json:"target"`
+	Start      string                `json:"start"`
+	End        string                `json:"end"`
+	Alignments []utils.Alignment     `json:"alignments"`
+}
+
+// AlignmentsHandler finds every moment between the start and end dates, inclusive, when the sun reaches
+// the target azimuth and altitude within the tolerances. Without an altitude, any position above the
+// horizon on the target azimuth counts.
+func AlignmentsHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	loc, err := resolveLocation(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	location, err := resolveTimeZone(query.Get("tz"), loc.TimeZone, loc.Latitude, loc.Longitude)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	target, err := parseAlignmentTarget(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// Search a year from today unless a range is given
+	startStr := query.Get("start")
+	if startStr == "" {
+		startStr = time.Now().In(location).Format("2006-01-02")
+	}
+	start, err := time.ParseInLocation("2006-01-02", startStr, location)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, newAPIError(ErrCodeInvalidDate, "Invalid start date", "start", dateFormat))
+		return
+	}
+	endStr := query.Get("end")
+	if endStr == "" {
+		endStr = start.AddDate(1, 0, -1).Format("2006-01-02")
+	}
+	end, err := time.ParseInLocation("2006-01-02", endStr, location)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, newAPIError(ErrCodeInvalidDate, "Invalid end date", "end", dateFormat))
+		return
+	}
+	// The end date is included up to its last minute
+	end = end.AddDate(0, 0, 1).Add(-time.Minute)
+	if end.Before(start) || end.Sub(start) > utils.MaxAlignmentDays*24*time.Hour {
+		writeError(w, http.StatusBadRequest, newAPIError(ErrCodeInvalidDateRange, "Invalid date range", "end", fmt.Sprintf(dateRangeFormat, utils.MaxAlignmentDays)))
+		return
+	}
+
+	algorithm, err := utils.ParseAlgorithm(query.Get("algorithm"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, newAPIError(ErrCodeInvalidAlgorithm, "Invalid algorithm", "algorithm", algorithmFormat))
+		return
+	}
+
+	observer, err := parseObserver(r, loc.Latitude, loc.Longitude)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	alignments, err := utils.FindAlignments(algorithm, observer, target, start, end)
+	switch {
+	case errors.Is(err, utils.ErrInvalidTolerance):
+		writeError(w, http.StatusBadRequest, newAPIError(ErrCodeInvalidTolerance, "Invalid tolerance", "", fmt.Sprintf(toleranceFormat, maxAlignmentTolerance)))
+		return
+	case errors.Is(err, utils.ErrInvalidDateRange):
+		writeError(w, http.StatusBadRequest, newAPIError(ErrCodeInvalidDateRange, "Invalid date range", "end", fmt.Sprintf(dateRangeFormat, utils.MaxAlignmentDays)))
+		return
+	case err != nil:
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	for i := range alignments {
+		alignments[i].Time = alignments[i].Time.In(location)
+	}
+
+	response := AlignmentsResponse{
+		Location:   fmt.Sprintf("%.4f, %.4f", loc.Latitude, loc.Longitude),
+		City:       loc.City,
+		TimeZone:   location.String(),
+		Algorithm:  string(algorithm),
+		Target:     target,
+		Start:      startStr,
+		End:        endStr,
+		Alignments: alignments,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// parseAlignmentTarget reads the azimuth, altitude and tolerance parameters
+func parseAlignmentTarget(r *http.Request) (utils.AlignmentTarget, error) {
+	query := r.URL.Query()
+	target := utils.AlignmentTarget{
+		AzimuthTolerance:  defaultAlignmentTolerance,
+		AltitudeTolerance: defaultAlignmentTolerance,
+	}
+
+	azimuth, err := strconv.ParseFloat(query.Get("azimuth"), 64)
+	if err != nil || !(azimuth >= 0 && azimuth <= 360) {
+		return target, newAPIError(ErrCodeInvalidAzimuth, "Invalid azimuth", "azimuth", azimuthFormat)
+	}
+	target.Azimuth = azimuth
+
+	if toleranceStr := query.Get("azimuth_tolerance"); toleranceStr != "" {
+		tolerance, err := strconv.ParseFloat(toleranceStr, 64)
+		if err != nil || !(tolerance > 0 && tolerance <= maxAlignmentTolerance) {
+			return target, newAPIError(ErrCodeInvalidTolerance, "Invalid azimuth tolerance", "azimuth_tolerance", fmt.Sprintf(toleranceFormat, maxAlignmentTolerance))
+		}
+		target.AzimuthTolerance = tolerance
+	}
+
+	altitudeStr := query.Get("altitude")
+	if altitudeStr == "" {
+		// Anywhere from the horizon to the zenith
+		target.Altitude = 45
+		target.AltitudeTolerance = 45
+		return target, nil
+	}
+
+	altitude, err := strconv.ParseFloat(altitudeStr, 64)
+	if err != nil || !(altitude >= minAlignmentAltitude && altitude <= maxAlignmentAltitude) {
+		return target, newAPIError(ErrCodeInvalidAltitude, "Invalid altitude", "altitude", fmt.Sprintf(altitudeFormat, minAlignmentAltitude, maxAlignmentAltitude))
+	}
+	target.Altitude = altitude
+
+	if toleranceStr := query.Get("altitude_tolerance"); toleranceStr != "" {
+		tolerance, err := strconv.ParseFloat(toleranceStr, 64)
+		if err != nil || !(tolerance > 0 && tolerance <= maxAlignmentTolerance) {
+			return target, newAPIError(ErrCodeInvalidTolerance, "Invalid altitude tolerance", "altitude_tolerance", fmt.Sprintf(toleranceFormat, maxAlignmentTolerance))
+		}
+		target.AltitudeTolerance = tolerance
+	}
+	return target, nil
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"sun-position/utils"
+)
+
+// Limits for a single batch request
+const (
+	maxBatchSize      = 10000
+	maxBatchBodyBytes = 10 << 20
+)
+
+// BatchSunPositionResult is the outcome of one observation in a batch; Error is set instead of the position when it failed
+type BatchSunPositionResult struct {
+	Index       int        `json:"index"`
+	SunAltitude *float64   `json:"sun_altitude,omitempty"`
+	SunAzimuth  *float64   `json:"sun_azimuth,omitempty"`
+	Timestamp   *time.Time `json:"timestamp,omitempty"`
+	Error       *APIError  `json:"error,omitempty"`
+}
+
+// batchObservation is one entry of a batch request. The coordinates are pointers so that a missing
+// one is reported instead of defaulting to 0.
+type batchObservation struct {
+	Latitude  *float64 `json:"latitude"`
+	Longitude *float64 `json:"longitude"`
+	Date      string   `json:"date"`
+	Time      string   `json:"time"`
+	Timestamp string   `json:"timestamp,omitempty"`
+}
+
+// BatchSunPositionHandler calculates the sun's position for a JSON array of observations.
+// Results are returned in the same order, and invalid entries get an error without failing the batch.
+func BatchSunPositionHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeError(w, http.StatusMethodNotAllowed, newAPIError(ErrCodeMethodNotAllowed, "Method not allowed", "", http.MethodPost))
+		return
+	}
+
+	algorithm, err := utils.ParseAlgorithm(r.URL.Query().Get("algorithm"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, newAPIError(ErrCodeInvalidAlgorithm, "Invalid algorithm", "algorithm", algorithmFormat))
+		return
+	}
+
+	// Entries are decoded one at a time so that a malformed entry does not fail the batch
+	var requests []json.RawMessage
+	body := http.MaxBytesReader(w, r.Body, maxBatchBodyBytes)
+	if err := json.NewDecoder(body).Decode(&requests); err != nil {
+		writeError(w, http.StatusBadRequest, newAPIError(ErrCodeInvalidBody, "Invalid JSON body, expected an array of observations", "", batchBodyFormat))
+		return
+	}
+	if len(requests) > maxBatchSize {
+		writeError(w, http.StatusRequestEntityTooLarge, newAPIError(ErrCodeBatchTooLarge, fmt.Sprintf("Too many observations, the maximum is %d", maxBatchSize), "", batchBodyFormat))
+		return
+	}
+
+	results := make([]BatchSunPositionResult, len(requests))
+	for i, req := range requests {
+		results[i] = calculateBatchEntry(i, req, algorithm)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
+
+// calculateBatchEntry decodes and validates one observation and calculates its sun position
+func calculateBatchEntry(index int, raw json.RawMessage, algorithm utils.Algorithm) BatchSunPositionResult {
+	result := BatchSunPositionResult{Index: index}
+
+	var entry batchObservation
+	if err := json.Unmarshal(raw, &entry); err != nil {
+		result.Error = newAPIError(ErrCodeInvalidBody, "Invalid observation", "", batchEntryFormat)
+		return result
+	}
+	if entry.Latitude == nil {
+		result.Error = newAPIError(ErrCodeMissingParameter, "Missing latitude", "latitude", latitudeFormat)
+		return result
+	}
+	if entry.Longitude == nil {
+		result.Error = newAPIError(ErrCodeMissingParameter, "Missing longitude", "longitude", longitudeFormat)
+		return result
+	}
+	req := SunPositionRequest{
+		Latitude:  *entry.Latitude,
+		Longitude: *entry.Longitude,
+		Date:      entry.Date,
+		Time:      entry.Time,
+		Timestamp: entry.Timestamp,
+	}
+
+	observer := utils.NewObserver(req.Latitude, req.Longitude)
+	if err := observer.Validate(); err != nil {
+		result.Error = observerError(err)
+		result.Error.Param = batchField(result.Error.Param)
+		return result
+	}
+
+	parsedTime, err := parseObservationTime(req)
+	if err != nil {
+		result.Error = err
+		return result
+	}
+
+	altitude, azimuth := utils.CalculateSunPositionForObserver(algorithm, observer, parsedTime)
+	result.SunAltitude = &altitude
+	result.SunAzimuth = &azimuth
+	result.Timestamp = &parsedTime
+	return result
+}
+
+// parseObservationTime reads an RFC 3339 timestamp, or a date and time in the location's local time zone
+func parseObservationTime(req SunPositionRequest) (time.Time, *APIError) {
+	if req.Timestamp != "" {
+		parsedTime, err := time.Parse(time.RFC3339, req.Timestamp)
+		if err != nil {
+			return time.Time{}, newAPIError(ErrCodeInvalidTimestamp, "Invalid timestamp", "timestamp", timestampFormat)
+		}
+		return parsedTime, nil
+	}
+
+	if req.Date == "" || req.Time == "" {
+		return time.Time{}, newAPIError(ErrCodeMissingTime, "Missing date and time or timestamp", "timestamp", timestampFormat)
+	}
+
+	location := utils.TimeZoneForCoordinates(req.Latitude, req.Longitude)
+	parsedTime, err := time.ParseInLocation("2006-01-02 15:04", fmt.Sprintf("%s %s", req.Date, req.Time), location)
+	if err != nil {
+		return time.Time{}, newAPIError(ErrCodeInvalidDateTime, "Invalid date or time format", "date", dateTimeFormat)
+	}
+	return parsedTime, nil
+}
+
+// batchField maps a query parameter name to the matching JSON field of a batch entry
+func batchField(param string) string {
+	switch param {
+	case "lat":
+		return "latitude"
+	case "lon":
+		return "longitude"
+	}
+	return param
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"sun-position/utils"
+)
+
+// CitiesResponse is one page of city search results
+type CitiesResponse struct {
+	Query  string       `json:"query"`
+	Mode   string       `json:"mode"`
+	Total  int          `json:"total"`
+	Limit  int          `json:"limit"`
+	Offset int          `json:"offset"`
+	Cities []utils.City `json:"cities"`
+}
+
+// CountriesResponse lists the countries that have cities available
+type CountriesResponse struct {
+	Countries []utils.CountrySummary `json:"countries"`
+}
+
+// CitiesHandler searches cities by name with prefix or fuzzy matching, ignoring case and accents
+func CitiesHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	mode := query.Get("mode")
+	if mode == "" {
+		mode = utils.SearchModePrefix
+	}
+	if mode != utils.SearchModePrefix && mode != utils.SearchModeFuzzy {
+		writeError(w, http.StatusBadRequest, newAPIError(ErrCodeInvalidSearchMode, "Invalid search mode", "mode", searchModeFormat))
+		return
+	}
+
+	limit := utils.DefaultSearchLimit
+	if limitStr := query.Get("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed < 1 || parsed > utils.MaxSearchLimit {
+			writeError(w, http.StatusBadRequest, newAPIError(ErrCodeInvalidLimit, "Invalid limit", "limit", fmt.Sprintf(limitFormat, utils.MaxSearchLimit)))
+			return
+		}
+		limit = parsed
+	}
+
+	offset := 0
+	if offsetStr := query.Get("offset"); offsetStr != "" {
+		parsed, err := strconv.Atoi(offsetStr)
+		if err != nil || parsed < 0 {
+			writeError(w, http.StatusBadRequest, newAPIError(ErrCodeInvalidOffset, "Invalid offset", "offset", offsetFormat))
+			return
+		}
+		offset = parsed
+	}
+
+	result := utils.SearchCities(utils.CitySearchOptions{
+		Query:   query.Get("q"),
+		Country: query.Get("country"),
+		Mode:    mode,
+		Limit:   limit,
+		Offset:  offset,
+	})
+
+	response := CitiesResponse{
+		Query:  query.Get("q"),
+		Mode:   mode,
+		Total:  result.Total,
+		Limit:  limit,
+		Offset: offset,
+		Cities: result.Cities,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// CountriesHandler lists countries with their number of cities, optionally filtered by the q prefix
+func CountriesHandler(w http.ResponseWriter, r *http.Request) {
+	response := CountriesResponse{Countries: utils.GetCountrySummaries(r.URL.Query().Get("q"))}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,230 @@
+package handlers
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// DefaultTrustedProxies trusts only the local reverse proxy from nginx.conf
+var DefaultTrustedProxies = []string{"127.0.0.0/8", "::1/128"}
+
+// Forwarding headers a trusted proxy can be configured to set
+const (
+	HeaderXForwardedFor = "X-Forwarded-For"
+	HeaderForwarded     = "Forwarded" // RFC 7239
+	HeaderXRealIP       = "X-Real-IP"
+)
+
+// DefaultForwardedHeader matches the header the shipped nginx.conf appends to
+const DefaultForwardedHeader = HeaderXForwardedFor
+
+var (
+	trustedProxiesMu sync.RWMutex
+	trustedProxies   = mustParseCIDRs(DefaultTrustedProxies)
+	forwardedHeader  = DefaultForwardedHeader
+)
+
+// sharedAddressSpace is the carrier-grade NAT range from RFC 6598, which net.IP.IsPrivate does not cover
+var sharedAddressSpace = mustParseCIDRs([]string{"100.64.0.0/10"})[0]
+
+// SetTrustedProxies replaces the proxies whose forwarding headers are believed.
+// Entries are CIDRs or single IP addresses.
+func SetTrustedProxies(entries []string) error {
+	networks, err := parseCIDRs(entries)
+	if err != nil {
+		return err
+	}
+
+	trustedProxiesMu.Lock()
+	defer trustedProxiesMu.Unlock()
+	trustedProxies = networks
+	return nil
+}
+
+// SetForwardedHeader selects the one forwarding header read from trusted proxies. Other forwarding
+// headers are ignored, since a proxy passes them through from the client unchanged.
+func SetForwardedHeader(name string) error {
+	for _, header := range []string{HeaderXForwardedFor, HeaderForwarded, HeaderXRealIP} {
+		if strings.EqualFold(strings.TrimSpace(name), header) {
+			trustedProxiesMu.Lock()
+			defer trustedProxiesMu.Unlock()
+			forwardedHeader = header
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported forwarded header: %s", name)
+}
+
+// configuredForwardedHeader returns the header set by SetForwardedHeader
+func configuredForwardedHeader() string {
+	trustedProxiesMu.RLock()
+	defer trustedProxiesMu.RUnlock()
+	return forwardedHeader
+}
+
+// parseCIDRs parses CIDRs, treating a bare IP address as a single-address network
+func parseCIDRs(entries []string) ([]*net.IPNet, error) {
+	var networks []*net.IPNet
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy: %s", entry)
+			}
+			bits := 128
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy: %s", entry)
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
+func mustParseCIDRs(entries []string) []*net.IPNet {
+	networks, err := parseCIDRs(entries)
+	if err != nil {
+		panic(err)
+	}
+	return networks
+}
+
+// isTrustedProxy reports whether an address belongs to a trusted proxy
+func isTrustedProxy(ip net.IP) bool {
+	trustedProxiesMu.RLock()
+	defer trustedProxiesMu.RUnlock()
+	for _, network := range trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// isPublicIP reports whether an address can meaningfully be geolocated
+func isPublicIP(ip net.IP) bool {
+	return ip != nil &&
+		!ip.IsPrivate() &&
+		!ip.IsLoopback() &&
+		!ip.IsLinkLocalUnicast() &&
+		!ip.IsLinkLocalMulticast() &&
+		!ip.IsInterfaceLocalMulticast() &&
+		!ip.IsMulticast() &&
+		!ip.IsUnspecified() &&
+		!sharedAddressSpace.Contains(ip)
+}
+
+// getClientIP extracts the client's IP address from the request. The configured forwarding header is only
+// believed when the connection comes from a trusted proxy, and the chain is walked from the right
+// so entries a client adds itself are ignored. Returns nil when the client address is unknown.
+func getClientIP(r *http.Request) net.IP {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	remote := net.ParseIP(host)
+	if remote == nil || !isTrustedProxy(remote) {
+		return remote
+	}
+
+	// Only the header the proxy sets can be believed; any other one came from the client
+	var chain []string
+	switch configuredForwardedHeader() {
+	case HeaderForwarded:
+		chain = parseForwardedFor(r.Header.Values(HeaderForwarded))
+	case HeaderXRealIP:
+		if realIP := r.Header.Get(HeaderXRealIP); realIP != "" {
+			chain = []string{strings.TrimSpace(realIP)}
+		}
+	default:
+		for _, line := range r.Header.Values(HeaderXForwardedFor) {
+			for _, entry := range strings.Split(line, ",") {
+				chain = append(chain, strings.TrimSpace(entry))
+			}
+		}
+	}
+
+	if len(chain) == 0 {
+		return remote
+	}
+
+	// Each trusted hop appended the address it received the request from
+	for i := len(chain) - 1; i >= 0; i-- {
+		ip := parseNodeIP(chain[i])
+		if ip == nil {
+			// "unknown", obfuscated identifiers or garbage: the client can't be identified
+			return nil
+		}
+		if i == 0 || !isTrustedProxy(ip) {
+			return ip
+		}
+	}
+	return nil
+}
+
+// parseForwardedFor returns the for= values from RFC 7239 Forwarded header lines, in order
+func parseForwardedFor(lines []string) []string {
+	var nodes []string
+	for _, line := range lines {
+		for _, element := range splitQuoted(line, ',') {
+			node := ""
+			for _, pair := range splitQuoted(element, ';') {
+				key, value, found := strings.Cut(strings.TrimSpace(pair), "=")
+				if found && strings.EqualFold(strings.TrimSpace(key), "for") {
+					node = strings.Trim(strings.TrimSpace(value), `"`)
+				}
+			}
+			// Elements without for= still count as a hop whose client is unknown
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes
+}
+
+// splitQuoted splits s on sep, ignoring separators inside double quotes
+func splitQuoted(s string, sep byte) []string {
+	var parts []string
+	inQuotes := false
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '"':
+			inQuotes = !inQuotes
+		case '\\':
+			if inQuotes {
+				i++
+			}
+		case sep:
+			if !inQuotes {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// parseNodeIP parses an address from a forwarding header, which may carry a port or IPv6 brackets
+func parseNodeIP(node string) net.IP {
+	node = strings.TrimSpace(node)
+	if ip := net.ParseIP(node); ip != nil {
+		return ip
+	}
+	if host, _, err := net.SplitHostPort(node); err == nil {
+		return net.ParseIP(host)
+	}
+	return net.ParseIP(strings.Trim(node, "[]"))
+}
@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"sun-position/utils"
+)
+
+// Machine-readable error codes returned by the API
+const (
+	ErrCodeCityNotFound         = "city_not_found"
+	ErrCodeInvalidLatitude      = "invalid_latitude"
+	ErrCodeInvalidLongitude     = "invalid_longitude"
+	ErrCodeInvalidDate          = "invalid_date"
+	ErrCodeInvalidDateTime      = "invalid_date_time"
+	ErrCodeInvalidTimestamp     = "invalid_timestamp"
+	ErrCodeMissingTime          = "missing_time"
+	ErrCodeMissingParameter     = "missing_parameter"
+	ErrCodeInvalidTimeZone      = "invalid_time_zone"
+	ErrCodeInvalidAlgorithm     = "invalid_algorithm"
+	ErrCodeInvalidElevation     = "invalid_elevation"
+	ErrCodeInvalidPressure      = "invalid_pressure"
+	ErrCodeInvalidTemperature   = "invalid_temperature"
+	ErrCodeInvalidStep          = "invalid_step"
+	ErrCodeInvalidLimit         = "invalid_limit"
+	ErrCodeInvalidOffset        = "invalid_offset"
+	ErrCodeInvalidSearchMode    = "invalid_mode"
+	ErrCodeInvalidYear          = "invalid_year"
+	ErrCodeInvalidFormat        = "invalid_format"
+	ErrCodeInvalidModel         = "invalid_model"
+	ErrCodeInvalidClearSky      = "invalid_clear_sky_condition"
+	ErrCodeInvalidTilt          = "invalid_tilt"
+	ErrCodeInvalidAzimuth       = "invalid_azimuth"
+	ErrCodeInvalidTransposition = "invalid_transposition"
+	ErrCodeInvalidHeight        = "invalid_height"
+	ErrCodeInvalidAltitude      = "invalid_altitude"
+	ErrCodeInvalidTolerance     = "invalid_tolerance"
+	ErrCodeInvalidDateRange     = "invalid_date_range"
+	ErrCodeInvalidHorizon       = "invalid_horizon"
+	ErrCodeInvalidDistance      = "invalid_distance"
+	ErrCodeNoElevationData      = "no_elevation_data"
+	ErrCodeInvalidPrayerMethod  = "invalid_method"
+	ErrCodeInvalidAsrMethod     = "invalid_asr_method"
+	ErrCodeInvalidHighLatitude  = "invalid_high_latitude_rule"
+	ErrCodeInvalidRamadan       = "invalid_ramadan"
+	ErrCodeInvalidBody          = "invalid_body"
+	ErrCodeBatchTooLarge        = "batch_too_large"
+	ErrCodeMethodNotAllowed     = "method_not_allowed"
+	ErrCodeNotFound             = "not_found"
+	ErrCodeInternal             = "internal_error"
+)
+
+// Accepted formats reported alongside errors
+const (
+	latitudeFormat   = "decimal degrees from -90 to 90"
+	longitudeFormat  = "decimal degrees from -180 to 180"
+	dateFormat       = "YYYY-MM-DD"
+	timeFormat       = "HH:MM"
+	timestampFormat  = "RFC 3339, e.g. 2026-01-28T12:00:00+02:00"
+	timeZoneFormat   = "IANA time zone name, e.g. Africa/Khartoum"
+	algorithmFormat  = "fast or spa"
+	dateTimeFormat   = dateFormat + " and " + timeFormat
+	observerFormat   = "%g to %g"
+	sunPathStepFmt   = "integer minutes from %d to %d"
+	limitFormat      = "integer from 1 to %d"
+	offsetFormat     = "integer of 0 or more"
+	searchModeFormat = "prefix or fuzzy"
+	yearFormat       = "integer year from %d to %d"
+	outputFormat     = "json or csv"
+	clearSkyFormat   = "haurwitz, ineichen or bird"
+	tiltFormat       = "degrees from 0 (horizontal) to 90 (vertical)"
+	azimuthFormat    = "degrees from 0 to 360, eastward from north"
+	transpositionFmt = "isotropic or haydavies"
+	heightFormat     = "meters greater than 0 up to %g"
+	altitudeFormat   = "degrees from %g to %g"
+	toleranceFormat  = "degrees greater than 0 up to %g"
+	dateRangeFormat  = "end on or after start, at most %d days"
+	horizonFormat    = "CSV lines of azimuth,elevation or a JSON array of {azimuth, elevation}, in degrees"
+	distanceFormat   = "kilometers greater than 0 up to %g"
+	prayerMethodFmt  = "mwl, isna, ummalqura, egyptian or karachi"
+	asrMethodFormat  = "shafii or hanafi"
+	highLatitudeFmt  = "angle_based, one_seventh, middle_of_night or none"
+	booleanFormat    = "true or false"
+	batchBodyFormat  = "JSON array of {latitude, longitude, date, time} or {latitude, longitude, timestamp}"
+	batchEntryFormat = "{latitude, longitude, date, time} or {latitude, longitude, timestamp} with numeric coordinates"
+)
+
+// APIError describes what went wrong with a request
+type APIError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Param   string `json:"param,omitempty"`  // the query parameter or field at fault
+	Format  string `json:"format,omitempty"` // the accepted format for that parameter
+}
+
+func (e *APIError) Error() string {
+	return e.Message
+}
+
+// ErrorResponse is the envelope for every API error
+type ErrorResponse struct {
+	Error *APIError `json:"error"`
+}
+
+// newAPIError creates an APIError
+func newAPIError(code, message, param, format string) *APIError {
+	return &APIError{Code: code, Message: message, Param: param, Format: format}
+}
+
+// writeError sends an error envelope with the given status; errors that are not an *APIError are reported as internal
+func writeError(w http.ResponseWriter, status int, err error) {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		apiErr = newAPIError(ErrCodeInternal, err.Error(), "", "")
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(ErrorResponse{Error: apiErr})
+}
+
+// APINotFoundHandler answers requests for unknown API paths with an error envelope rather than the home page
+func APINotFoundHandler(w http.ResponseWriter, r *http.Request) {
+	writeError(w, http.StatusNotFound, newAPIError(ErrCodeNotFound, "No API endpoint at "+r.URL.Path, "", ""))
+}
+
+// observerError converts an observer validation failure into an APIError for the matching query parameter
+func observerError(err error) *APIError {
+	var rangeErr *utils.ObserverRangeError
+	if !errors.As(err, &rangeErr) {
+		return newAPIError(ErrCodeInternal, err.Error(), "", "")
+	}
+
+	format := fmt.Sprintf(observerFormat, rangeErr.Min, rangeErr.Max)
+	switch rangeErr.Field {
+	case "latitude":
+		return newAPIError(ErrCodeInvalidLatitude, "Latitude out of range", "lat", latitudeFormat)
+	case "longitude":
+		return newAPIError(ErrCodeInvalidLongitude, "Longitude out of range", "lon", longitudeFormat)
+	case "elevation":
+		return newAPIError(ErrCodeInvalidElevation, "Elevation out of range", "elevation", format+" meters")
+	case "height":
+		return newAPIError(ErrCodeInvalidHeight, "Observer height out of range", "observer_height", format+" meters")
+	case "pressure":
+		return newAPIError(ErrCodeInvalidPressure, "Pressure out of range", "pressure", "greater than 0 up to 1100 millibars")
+	default:
+		return newAPIError(ErrCodeInvalidTemperature, "Temperature out of range", "temp", format+" °C")
+	}
+}
@@ -3,11 +3,8 @@ package handlers
 import (
 	"encoding/json"
 	"fmt"
-	"math"
-	"net"
 	"net/http"
 	"strconv"
-	"strings"
 	"time"
 
 	"sun-position/utils"
@@ -23,8 +20,9 @@ func HomeHandler(w http.ResponseWriter, r *http.Request) {
 type SunPositionRequest struct {
 	Latitude  float64 `json:"latitude"`
 	Longitude float64 `json:"longitude"`
-	Date      string  `json:"date"` // Format: YYYY-MM-DD
-	Time      string  `json:"time"` // Format: HH:MM
+	Date      string  `json:"date"`                // Format: YYYY-MM-DD
+	Time      string  `json:"time"`                // Format: HH:MM
+	Timestamp string  `json:"timestamp,omitempty"` // RFC 3339, used instead of date and time when set
 }
 
 // SunPositionResponse represents the response body
@@ -38,195 +36,163 @@ type SunPositionResponse struct {
 	Time        string    `json:"time"`
 	Sunrise     string    `json:"sunrise"`
 	Sunset      string    `json:"sunset"`
-}
 
+	// During polar day or night, sunrise and sunset are N/A and the next event gives the date it ends
+	DaylightState utils.DaylightState `json:"daylight_state"`
+	NextSunrise   *time.Time          `json:"next_sunrise,omitempty"` // set during polar night
+	NextSunset    *time.Time          `json:"next_sunset,omitempty"`  // set during polar day
+
+	// Directions of sunrise and sunset in degrees eastward from north, and their extremes at the solstices
+	SunriseAzimuth   *float64               `json:"sunrise_azimuth,omitempty"`
+	SunsetAzimuth    *float64               `json:"sunset_azimuth,omitempty"`
+	SolsticeBearings utils.SolsticeBearings `json:"solstice_bearings"`
+
+	Algorithm   string    `json:"algorithm"`
+	TimeZone    string    `json:"timezone"`   // IANA time zone name
+	UTCOffset   string    `json:"utc_offset"` // Format: +HH:MM
+
+	SolarNoon        string `json:"solar_noon"`
+	DayLength        string `json:"day_length"` // Format: HH:MM
+	CivilDawn        string `json:"civil_dawn"`
+	CivilDusk        string `json:"civil_dusk"`
+	NauticalDawn     string `json:"nautical_dawn"`
+	NauticalDusk     string `json:"nautical_dusk"`
+	AstronomicalDawn string `json:"astronomical_dawn"`
+	AstronomicalDusk string `json:"astronomical_dusk"`
+}
 
-// getClientIP extracts the client's IP address from the request, considering proxies
-func getClientIP(r *http.Request) string {
-	// Check X-Forwarded-For header (first entry if multiple IPs)
-	forwarded := r.Header.Get("X-Forwarded-For")
-	if forwarded != "" {
-		ip := strings.Split(forwarded, ",")[0]
-		return strings.TrimSpace(ip)
+// formatEventTime formats a sun event as HH:MM, or N/A when it does not occur on that date
+func formatEventTime(t time.Time) string {
+	if t.IsZero() {
+		return "N/A"
 	}
+	return t.Format("15:04")
+}
 
-	// Check X-Real-IP header
-	realIP := r.Header.Get("X-Real-IP")
-	if realIP != "" {
-		return realIP
+// nextEventTime returns a pointer to a sun event for optional response fields, or nil when none was found
+func nextEventTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
 	}
+	return &t
+}
 
-	// Use RemoteAddr as fallback
-	ip, _, err := net.SplitHostPort(r.RemoteAddr)
-	if err != nil {
-		return r.RemoteAddr
-	}
-	return ip
+// formatDuration formats a duration as HH:MM
+func formatDuration(d time.Duration) string {
+	minutes := int(d.Round(time.Minute).Minutes())
+	return fmt.Sprintf("%02d:%02d", minutes/60, minutes%60)
 }
 
-// SunPositionHandler calculates and returns the sun's position
-func SunPositionHandler(w http.ResponseWriter, r *http.Request) {
-	var req SunPositionRequest
-
-	// Parse query parameters
-	cityName := r.URL.Query().Get("city")
-	latStr := r.URL.Query().Get("lat")
-	lonStr := r.URL.Query().Get("lon")
-
-	var lat, lon float64
-	var err error
-
-	// If city name is provided, use it to get coordinates
-	if cityName != "" {
-		cityFound := false
-		for _, city := range utils.CommonCities {
-			// Check if the city name matches exactly or partially
-			if strings.EqualFold(city.Name, cityName) {
-				lat = city.Latitude
-				lon = city.Longitude
-				cityFound = true
-				break
-			}
-		}
-		if !cityFound {
-			http.Error(w, "City not found", http.StatusBadRequest)
-			return
-		}
-	} else {
-		// If no city is provided, try to get location from IP address
-		if latStr == "" && lonStr == "" {
-			// Get client IP address
-			clientIP := getClientIP(r)
-
-			// Attempt to get location from IP
-			location, err := utils.GetLocationFromIP(clientIP)
-			if err == nil && location != nil && location.Country != "" {
-				// Get the capital city for the detected country
-				capitalCity := utils.GetCapitalCityForCountry(location.Country)
-
-				// Look for the capital city in our CommonCities list
-				if capitalCity != "" {
-					for _, city := range utils.CommonCities {
-						if strings.EqualFold(city.Name, capitalCity) {
-							lat = city.Latitude
-							lon = city.Longitude
-							cityName = capitalCity // Update cityName to the detected capital
-							break
-						}
-					}
-				}
-
-				// If capital city is not in our list or wasn't found, use coordinates from IP if available
-				if lat == 0 && lon == 0 && location.Lat != "" && location.Lon != "" {
-					parsedLat, err1 := strconv.ParseFloat(location.Lat, 64)
-					parsedLon, err2 := strconv.ParseFloat(location.Lon, 64)
-					if err1 == nil && err2 == nil {
-						lat = parsedLat
-						lon = parsedLon
-						cityName = capitalCity // Use capital city name if available, otherwise it remains empty
-					}
-				}
-			}
-
-			// If we still don't have coordinates, default to Khartoum
-			if lat == 0 && lon == 0 {
-				lat = 15.5007
-				lon = 32.5599
-				if cityName == "" {
-					cityName = "Khartoum"
-				}
-			}
-		} else {
-			// Parse latitude and longitude from query params
-			lat, err = strconv.ParseFloat(latStr, 64)
-			if err != nil {
-				http.Error(w, "Invalid latitude", http.StatusBadRequest)
-				return
-			}
-
-			lon, err = strconv.ParseFloat(lonStr, 64)
-			if err != nil {
-				http.Error(w, "Invalid longitude", http.StatusBadRequest)
-				return
-			}
+// parseObserver builds an observer from the elevation (m), observer_height (m above the surrounding terrain),
+// pressure (mbar) and temp (°C) query parameters. Pressure defaults to the mean pressure at the given elevation,
+// and the horizon dip is taken from the elevation unless observer_height is given.
+func parseObserver(r *http.Request, lat, lon float64) (utils.Observer, error) {
+	observer := utils.NewObserver(lat, lon)
+	query := r.URL.Query()
+
+	if elevationStr := query.Get("elevation"); elevationStr != "" {
+		elevation, err := strconv.ParseFloat(elevationStr, 64)
+		if err != nil {
+			return observer, newAPIError(ErrCodeInvalidElevation, "Invalid elevation", "elevation", "meters above sea level")
 		}
+		observer.Elevation = elevation
+		observer.Pressure = utils.PressureAtElevation(elevation)
 	}
 
-	// Use current date/time if not provided
-	dateStr := r.URL.Query().Get("date")
-	timeStr := r.URL.Query().Get("time")
+	if heightStr := query.Get("observer_height"); heightStr != "" {
+		height, err := strconv.ParseFloat(heightStr, 64)
+		if err != nil {
+			return observer, newAPIError(ErrCodeInvalidHeight, "Invalid observer height", "observer_height", "meters above the surrounding terrain")
+		}
+		observer.Height = &height
+	}
 
-	if dateStr == "" || timeStr == "" {
-		now := time.Now()
-		dateStr = now.Format("2006-01-02")
-		timeStr = now.Format("15:04")
+	if pressureStr := query.Get("pressure"); pressureStr != "" {
+		pressure, err := strconv.ParseFloat(pressureStr, 64)
+		if err != nil {
+			return observer, newAPIError(ErrCodeInvalidPressure, "Invalid pressure", "pressure", "millibars")
+		}
+		observer.Pressure = pressure
 	}
 
-	req.Latitude = lat
-	req.Longitude = lon
-	req.Date = dateStr
-	req.Time = timeStr
+	if tempStr := query.Get("temp"); tempStr != "" {
+		temperature, err := strconv.ParseFloat(tempStr, 64)
+		if err != nil {
+			return observer, newAPIError(ErrCodeInvalidTemperature, "Invalid temperature", "temp", "degrees Celsius")
+		}
+		observer.Temperature = temperature
+	}
 
-	// Parse the date and time
-	// The user enters local time for the location, so we need to interpret it correctly
-	// Calculate the time zone offset based on longitude (each 15 degrees = 1 hour)
-	// Round to the nearest time zone (multiples of 15 degrees)
-	timeZoneOffsetHours := math.Round(req.Longitude / 15.0)
-	timeZoneOffsetSeconds := int(timeZoneOffsetHours * 60 * 60) // Convert hours to seconds
-	location := time.FixedZone("Local", timeZoneOffsetSeconds)
+	if err := observer.Validate(); err != nil {
+		return observer, observerError(err)
+	}
+	return observer, nil
+}
 
-	parsedTime, err := time.ParseInLocation("2006-01-02 15:04", fmt.Sprintf("%s %s", req.Date, req.Time), location)
+// SunPositionHandler calculates and returns the sun's position
+func SunPositionHandler(w http.ResponseWriter, r *http.Request) {
+	// Resolve the location, time zone, observer and the local civil time, including daylight saving time
+	moment, err := resolveMoment(r)
 	if err != nil {
-		http.Error(w, "Invalid date or time format", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, err)
 		return
 	}
+	lat, lon := moment.Location.Latitude, moment.Location.Longitude
+	observer, parsedTime, location := moment.Observer, moment.Time, moment.TimeZone
 
-	// Debug: Print the parsed time
-	fmt.Printf("DEBUG: Input time %s %s for location (%.4f, %.4f) -> parsed as %v (location: %s)\n",
-		req.Date, req.Time, req.Latitude, req.Longitude, parsedTime, location.String())
+	// Select the solar position algorithm (defaults to the fast approximation)
+	algorithm, err := utils.ParseAlgorithm(r.URL.Query().Get("algorithm"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, newAPIError(ErrCodeInvalidAlgorithm, "Invalid algorithm", "algorithm", algorithmFormat))
+		return
+	}
 
 	// Calculate sun position
-	altitude, azimuth := utils.CalculateSunPosition(req.Latitude, req.Longitude, parsedTime)
-
-	// Determine the city name to include in the response
-	var responseCityName string
-	if cityName != "" {
-		responseCityName = cityName
-	} else if req.Latitude == 15.5007 && req.Longitude == 32.5599 {
-		// If using default coordinates, set city name to Khartoum
-		responseCityName = "Khartoum"
-	} else {
-		// Try to find the city name from the CommonCities list
-		for _, city := range utils.CommonCities {
-			if math.Abs(city.Latitude-req.Latitude) < 0.01 && math.Abs(city.Longitude-req.Longitude) < 0.01 {
-				responseCityName = city.Name
-				break
-			}
-		}
-	}
+	altitude, azimuth := utils.CalculateSunPositionForObserver(algorithm, observer, parsedTime)
 
 	response := SunPositionResponse{
 		SunAltitude: altitude,
 		SunAzimuth:  azimuth,
 		Timestamp:   parsedTime,
-		Location:    fmt.Sprintf("%.4f, %.4f", req.Latitude, req.Longitude),
-		City:        responseCityName, // Include city name in response
-		Date:        req.Date,
-		Time:        req.Time,
+		Location:    fmt.Sprintf("%.4f, %.4f", lat, lon),
+		City:        moment.Location.City, // Include city name in response
+		Date:        moment.Date,
+		Time:        moment.Clock,
+		Algorithm:   string(algorithm),
+		TimeZone:    location.String(),
+		UTCOffset:   parsedTime.Format("-07:00"),
 	}
 
 	// Calculate sunrise and sunset for the given date and location
-	sunriseTime, sunsetTime := utils.CalculateSunriseSunset(req.Latitude, req.Longitude, parsedTime)
-	if !sunriseTime.IsZero() {
-		response.Sunrise = sunriseTime.Format("15:04")
-	} else {
-		response.Sunrise = "N/A"
+	sunriseTime, sunsetTime := utils.CalculateSunriseSunsetForObserver(observer, parsedTime)
+	response.Sunrise = formatEventTime(sunriseTime)
+	response.Sunset = formatEventTime(sunsetTime)
+
+	// Tell continuous daylight from continuous night, and when it ends
+	response.DaylightState = utils.CalculateDaylightState(lat, lon, parsedTime, observer.SunriseAltitude())
+	switch response.DaylightState {
+	case utils.PolarNight:
+		response.NextSunrise = nextEventTime(utils.FindNextSunrise(lat, lon, parsedTime, observer.SunriseAltitude()))
+	case utils.PolarDay:
+		response.NextSunset = nextEventTime(utils.FindNextSunset(lat, lon, parsedTime, observer.SunriseAltitude()))
 	}
-	if !sunsetTime.IsZero() {
-		response.Sunset = sunsetTime.Format("15:04")
-	} else {
-		response.Sunset = "N/A"
+
+	// Where the sun rises and sets today, and at the solstices
+	if rise, set, ok := utils.CalculateRiseSetAzimuths(lat, lon, parsedTime, observer.SunriseAltitude()); ok {
+		response.SunriseAzimuth, response.SunsetAzimuth = &rise, &set
 	}
+	response.SolsticeBearings = utils.CalculateSolsticeBearings(observer, parsedTime.Year(), location)
+
+	// Solar noon, day length and the three twilight phases
+	response.SolarNoon = formatEventTime(utils.CalculateSolarNoon(lon, parsedTime))
+	response.DayLength = formatDuration(utils.CalculateDayLength(lat, lon, parsedTime, observer.SunriseAltitude()))
+	civil, nautical, astronomical := utils.CalculateTwilight(lat, lon, parsedTime)
+	response.CivilDawn = formatEventTime(civil.Dawn)
+	response.CivilDusk = formatEventTime(civil.Dusk)
+	response.NauticalDawn = formatEventTime(nautical.Dawn)
+	response.NauticalDusk = formatEventTime(nautical.Dusk)
+	response.AstronomicalDawn = formatEventTime(astronomical.Dawn)
+	response.AstronomicalDusk = formatEventTime(astronomical.Dusk)
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+
+	"sun-position/utils"
+)
+
+// Limits for horizon profiles
+const (
+	maxHorizonBodyBytes   = 1 << 20
+	maxHorizonDistanceKm  = 100.0
+	defaultObserverHeight = 1.7 // eye height above the ground in meters, for profiles computed from terrain
+)
+
+// Where a horizon profile came from
+const (
+	horizonSourceUpload  = "upload"
+	horizonSourceTerrain = "terrain"
+)
+
+// SunPeriod is a stretch of direct sun in local time
+type SunPeriod struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+// HorizonResponse compares the day behind a horizon profile with the day over a flat horizon
+type HorizonResponse struct {
+	Location       string               `json:"location"`
+	City           string               `json:"city,omitempty"`
+	Date           string               `json:"date"`
+	TimeZone       string               `json:"timezone"`
+	Algorithm      string               `json:"algorithm"`
+	Source         string               `json:"source"`      // upload or terrain
+	FirstLight     string               `json:"first_light"` // the sun first clears the skyline
+	LastLight      string               `json:"last_light"`  // the sun last drops behind the skyline
+	DirectSun      string               `json:"direct_sun"`  // HH:MM of sun above the skyline
+	DirectSunHours float64              `json:"direct_sun_hours"`
+	Periods        []SunPeriod          `json:"periods"`
+	Sunrise        string               `json:"sunrise"` // over a flat horizon, for comparison
+	Sunset         string               `json:"sunset"`
+	DayLength      string               `json:"day_length"`
+	Profile        []utils.HorizonPoint `json:"profile"`
+}
+
+// HorizonHandler returns first light, last light and the hours of direct sun for a site with an obstructed horizon.
+// POST the profile as CSV (text/csv) or JSON; a GET computes it from the configured elevation data,
+// for an observer observer_height meters above the ground (1.7 by default) looking up to distance kilometers away.
+func HorizonHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodGet+", "+http.MethodPost)
+		writeError(w, http.StatusMethodNotAllowed, newAPIError(ErrCodeMethodNotAllowed, "Method not allowed", "", http.MethodGet+", "+http.MethodPost))
+		return
+	}
+	query := r.URL.Query()
+
+	loc, err := resolveLocation(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	location, err := resolveTimeZone(query.Get("tz"), loc.TimeZone, loc.Latitude, loc.Longitude)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	dateStr := query.Get("date")
+	if dateStr == "" {
+		dateStr = time.Now().In(location).Format("2006-01-02")
+	}
+	date, err := time.ParseInLocation("2006-01-02", dateStr, location)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, newAPIError(ErrCodeInvalidDate, "Invalid date", "date", dateFormat))
+		return
+	}
+
+	algorithm, err := utils.ParseAlgorithm(query.Get("algorithm"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, newAPIError(ErrCodeInvalidAlgorithm, "Invalid algorithm", "algorithm", algorithmFormat))
+		return
+	}
+
+	observer, err := parseObserver(r, loc.Latitude, loc.Longitude)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	source := horizonSourceUpload
+	var profile *utils.HorizonProfile
+	if r.Method == http.MethodPost {
+		profile, err = parseHorizonBody(w, r)
+	} else {
+		source = horizonSourceTerrain
+		profile, err = terrainHorizon(r, observer)
+	}
+	if err != nil {
+		status := http.StatusBadRequest
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.Code == ErrCodeNoElevationData {
+			status = http.StatusNotFound
+		}
+		writeError(w, status, err)
+		return
+	}
+
+	daylight := utils.CalculateHorizonDaylight(algorithm, observer, profile, date)
+	sunrise, sunset := utils.CalculateSunriseSunsetForObserver(observer, date)
+	sunrise, sunset = sunrise.In(location), sunset.In(location)
+
+	periods := make([]SunPeriod, len(daylight.Periods))
+	for i, period := range daylight.Periods {
+		periods[i] = SunPeriod{Start: formatEventTime(period.Start), End: formatEventTime(period.End)}
+	}
+
+	response := HorizonResponse{
+		Location:       fmt.Sprintf("%.4f, %.4f", loc.Latitude, loc.Longitude),
+		City:           loc.City,
+		Date:           dateStr,
+		TimeZone:       location.String(),
+		Algorithm:      string(algorithm),
+		Source:         source,
+		FirstLight:     formatEventTime(daylight.FirstLight),
+		LastLight:      formatEventTime(daylight.LastLight),
+		DirectSun:      formatDuration(daylight.DirectSun),
+		DirectSunHours: daylight.DirectSun.Hours(),
+		Periods:        periods,
+		Sunrise:        formatEventTime(sunrise),
+		Sunset:         formatEventTime(sunset),
+		Profile:        profile.Points(),
+	}
+	if !sunrise.IsZero() && !sunset.IsZero() {
+		response.DayLength = formatDuration(sunset.Sub(sunrise))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// parseHorizonBody reads an uploaded profile, as CSV when the content type says so and as JSON otherwise
+func parseHorizonBody(w http.ResponseWriter, r *http.Request) (*utils.HorizonProfile, error) {
+	body := http.MaxBytesReader(w, r.Body, maxHorizonBodyBytes)
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+	var profile *utils.HorizonProfile
+	var err error
+	switch mediaType {
+	case "text/csv", "text/plain":
+		profile, err = utils.ParseHorizonCSV(body)
+	default:
+		profile, err = utils.ParseHorizonJSON(body)
+	}
+	if err != nil {
+		return nil, newAPIError(ErrCodeInvalidHorizon, "Invalid horizon profile: "+err.Error(), "", horizonFormat)
+	}
+	return profile, nil
+}
+
+// terrainHorizon computes the profile around an observer from the loaded elevation data,
+// seen from the observer's height above the ground or from eye height when it is not given
+func terrainHorizon(r *http.Request, observer utils.Observer) (*utils.HorizonProfile, error) {
+	query := r.URL.Query()
+	terrain := utils.DefaultTerrain()
+	if terrain == nil {
+		return nil, newAPIError(ErrCodeNoElevationData, "No elevation data is configured; POST a horizon profile instead", "", horizonFormat)
+	}
+
+	height := defaultObserverHeight
+	if observer.Height != nil {
+		height = *observer.Height
+	}
+
+	distance := utils.DefaultHorizonDistanceKm
+	if distanceStr := query.Get("distance"); distanceStr != "" {
+		var err error
+		distance, err = strconv.ParseFloat(distanceStr, 64)
+		if err != nil || !(distance > 0 && distance <= maxHorizonDistanceKm) {
+			return nil, newAPIError(ErrCodeInvalidDistance, "Invalid distance", "distance", fmt.Sprintf(distanceFormat, maxHorizonDistanceKm))
+		}
+	}
+
+	profile, err := terrain.ComputeHorizonProfile(observer.Latitude, observer.Longitude, height, distance)
+	if errors.Is(err, utils.ErrNoElevationData) {
+		return nil, newAPIError(ErrCodeNoElevationData, "No elevation data covers this location", "", "")
+	}
+	return profile, err
+}
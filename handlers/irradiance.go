@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"sun-position/utils"
+)
+
+// IrradianceResponse holds the clear-sky irradiance estimate and the sun position it was derived from
+type IrradianceResponse struct {
+	Model            utils.ClearSkyModel      `json:"model"`
+	Algorithm        string                   `json:"algorithm"`
+	SunAltitude      float64                  `json:"sun_altitude"`
+	SunAzimuth       float64                  `json:"sun_azimuth"`
+	Extraterrestrial float64                  `json:"extraterrestrial"` // W/m² normal to the sun at the top of the atmosphere
+	GHI              float64                  `json:"ghi"`
+	DNI              float64                  `json:"dni"`
+	DHI              float64                  `json:"dhi"`
+	Conditions       utils.ClearSkyConditions `json:"conditions"`
+	Timestamp        time.Time                `json:"timestamp"`
+	Location         string                   `json:"location"`
+	City             string                   `json:"city,omitempty"`
+	TimeZone         string                   `json:"timezone"`
+}
+
+// IrradianceHandler estimates global horizontal, direct normal and diffuse horizontal irradiance
+// under a clear sky. The location and time are resolved like SunPositionHandler.
+func IrradianceHandler(w http.ResponseWriter, r *http.Request) {
+	moment, err := resolveMoment(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	algorithm, err := utils.ParseAlgorithm(r.URL.Query().Get("algorithm"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, newAPIError(ErrCodeInvalidAlgorithm, "Invalid algorithm", "algorithm", algorithmFormat))
+		return
+	}
+
+	model, err := utils.ParseClearSkyModel(r.URL.Query().Get("model"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, newAPIError(ErrCodeInvalidModel, "Invalid clear-sky model", "model", clearSkyFormat))
+		return
+	}
+
+	conditions, err := parseClearSkyConditions(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	altitude, azimuth := utils.CalculateSunPositionForObserver(algorithm, moment.Observer, moment.Time)
+	extraterrestrial := utils.ExtraterrestrialIrradiance(moment.Time)
+	irradiance := utils.ClearSkyIrradiance(model, 90-altitude, extraterrestrial, moment.Observer, conditions)
+
+	response := IrradianceResponse{
+		Model:            model,
+		Algorithm:        string(algorithm),
+		SunAltitude:      altitude,
+		SunAzimuth:       azimuth,
+		Extraterrestrial: extraterrestrial,
+		GHI:              irradiance.GHI,
+		DNI:              irradiance.DNI,
+		DHI:              irradiance.DHI,
+		Conditions:       conditions,
+		Timestamp:        moment.Time,
+		Location:         fmt.Sprintf("%.4f, %.4f", moment.Location.Latitude, moment.Location.Longitude),
+		City:             moment.Location.City,
+		TimeZone:         moment.TimeZone.String(),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// parseClearSkyConditions reads the optional atmosphere parameters, named like the JSON fields
+// of utils.ClearSkyConditions, on top of the defaults
+func parseClearSkyConditions(r *http.Request) (utils.ClearSkyConditions, error) {
+	conditions := utils.DefaultClearSkyConditions()
+	fields := []struct {
+		param string
+		value *float64
+	}{
+		{"linke_turbidity", &conditions.LinkeTurbidity},
+		{"ozone", &conditions.Ozone},
+		{"precipitable_water", &conditions.PrecipitableWater},
+		{"aod380", &conditions.AOD380},
+		{"aod500", &conditions.AOD500},
+		{"albedo", &conditions.Albedo},
+	}
+
+	for _, field := range fields {
+		if valueStr := r.URL.Query().Get(field.param); valueStr != "" {
+			value, err := strconv.ParseFloat(valueStr, 64)
+			if err != nil {
+				return conditions, newAPIError(ErrCodeInvalidClearSky, "Invalid "+field.param, field.param, "number")
+			}
+			*field.value = value
+		}
+	}
+
+	var rangeErr *utils.ClearSkyRangeError
+	if err := conditions.Validate(); errors.As(err, &rangeErr) {
+		return conditions, newAPIError(ErrCodeInvalidClearSky, rangeErr.Field+" out of range", rangeErr.Field,
+			fmt.Sprintf(observerFormat, rangeErr.Min, rangeErr.Max))
+	}
+	return conditions, nil
+}
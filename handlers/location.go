@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"sun-position/utils"
+)
+
+// requestLocation is the place a request refers to, resolved from its query parameters
+type requestLocation struct {
+	Latitude  float64
+	Longitude float64
+	City      string // empty when the coordinates were given directly
+	TimeZone  string // IANA zone of the matched city, if any
+}
+
+// resolveLocation determines the location from the city or lat/lon query parameters,
+// falling back to the client's IP address and finally to Khartoum
+func resolveLocation(r *http.Request) (requestLocation, error) {
+	// Parse query parameters
+	cityName := r.URL.Query().Get("city")
+	latStr := r.URL.Query().Get("lat")
+	lonStr := r.URL.Query().Get("lon")
+
+	var lat, lon float64
+	var err error
+	var cityTimeZone string
+
+	// If city name is provided, use it to get coordinates
+	if cityName != "" {
+		// Names match ignoring case and accents, so "Sao Paulo" finds "São Paulo"
+		city, cityFound := utils.FindCity(cityName)
+		if !cityFound {
+			return requestLocation{}, newAPIError(ErrCodeCityNotFound, "City not found", "city", "")
+		}
+		lat = city.Latitude
+		lon = city.Longitude
+		cityTimeZone = city.TimeZone
+		cityName = city.Name
+	} else {
+		// If no city is provided, try to get location from IP address
+		if latStr == "" && lonStr == "" {
+			// Get client IP address
+			clientIP := getClientIP(r)
+
+			// Attempt to get location from IP; private, loopback and link-local addresses are never looked up
+			var location *utils.IPLocation
+			var lookupErr error
+			if isPublicIP(clientIP) {
+				location, lookupErr = utils.GetLocationFromIPContext(r.Context(), clientIP.String())
+			}
+			if lookupErr == nil && location != nil && (location.CountryCode != "" || location.Country != "") {
+				// Identify the country by its ISO code, falling back to the name for providers without one
+				country := location.CountryCode
+				if country == "" {
+					country = location.Country
+				}
+				capitalCity := utils.GetCapitalCityForCountry(country)
+
+				// Look for the capital city in that country in the gazetteer
+				if city, ok := utils.FindCapitalCity(country); ok {
+					lat = city.Latitude
+					lon = city.Longitude
+					cityTimeZone = city.TimeZone
+					cityName = city.Name // Update cityName to the detected capital
+				}
+
+				// If capital city is not in our list or wasn't found, use coordinates from IP if available
+				if lat == 0 && lon == 0 && location.Lat != "" && location.Lon != "" {
+					parsedLat, err1 := strconv.ParseFloat(location.Lat, 64)
+					parsedLon, err2 := strconv.ParseFloat(location.Lon, 64)
+					if err1 == nil && err2 == nil {
+						lat = parsedLat
+						lon = parsedLon
+						cityName = capitalCity // Use capital city name if available, otherwise it remains empty
+					}
+				}
+			}
+
+			// If we still don't have coordinates, default to Khartoum
+			if lat == 0 && lon == 0 {
+				lat = 15.5007
+				lon = 32.5599
+				if cityName == "" {
+					cityName = "Khartoum"
+				}
+			}
+		} else {
+			// Parse latitude and longitude from query params
+			lat, lon, err = parseCoordinates(latStr, lonStr)
+			if err != nil {
+				return requestLocation{}, err
+			}
+		}
+	}
+
+	// Name coordinates after a known city when one is close by; its time zone is not used,
+	// since a zone boundary may lie between the city and the coordinates
+	if cityName == "" {
+		if city, ok := utils.FindNearbyCity(lat, lon); ok {
+			cityName = city.Name
+		}
+	}
+
+	return requestLocation{Latitude: lat, Longitude: lon, City: cityName, TimeZone: cityTimeZone}, nil
+}
+
+// parseCoordinates parses and range checks the lat and lon query parameters
+func parseCoordinates(latStr, lonStr string) (float64, float64, error) {
+	lat, err := strconv.ParseFloat(latStr, 64)
+	if err != nil || !(lat >= -90 && lat <= 90) {
+		return 0, 0, newAPIError(ErrCodeInvalidLatitude, "Invalid latitude", "lat", latitudeFormat)
+	}
+
+	lon, err := strconv.ParseFloat(lonStr, 64)
+	if err != nil || !(lon >= -180 && lon <= 180) {
+		return 0, 0, newAPIError(ErrCodeInvalidLongitude, "Invalid longitude", "lon", longitudeFormat)
+	}
+	return lat, lon, nil
+}
+
+// resolveTimeZone returns the IANA location for a request, preferring an explicit tz name,
+// then the matched city's zone, and finally the zone containing the coordinates
+func resolveTimeZone(tzName, cityTimeZone string, lat, lon float64) (*time.Location, error) {
+	if tzName != "" {
+		location, err := time.LoadLocation(tzName)
+		if err != nil {
+			return nil, newAPIError(ErrCodeInvalidTimeZone, "Invalid time zone", "tz", timeZoneFormat)
+		}
+		return location, nil
+	}
+	if cityTimeZone != "" {
+		if location, err := time.LoadLocation(cityTimeZone); err == nil {
+			return location, nil
+		}
+	}
+	return utils.TimeZoneForCoordinates(lat, lon), nil
+}
+
+// requestMoment is the observer, time zone and local time a point-in-time request refers to
+type requestMoment struct {
+	Location requestLocation
+	Observer utils.Observer
+	TimeZone *time.Location
+	Time     time.Time
+	Date     string // YYYY-MM-DD as requested, or today
+	Clock    string // HH:MM as requested, or now
+}
+
+// resolveMoment resolves the location, time zone, observer and the date and time parameters,
+// defaulting to the current local time when either the date or the time is missing
+func resolveMoment(r *http.Request) (requestMoment, error) {
+	var moment requestMoment
+	var err error
+
+	moment.Location, err = resolveLocation(r)
+	if err != nil {
+		return moment, err
+	}
+	lat, lon := moment.Location.Latitude, moment.Location.Longitude
+
+	moment.TimeZone, err = resolveTimeZone(r.URL.Query().Get("tz"), moment.Location.TimeZone, lat, lon)
+	if err != nil {
+		return moment, err
+	}
+
+	moment.Date = r.URL.Query().Get("date")
+	moment.Clock = r.URL.Query().Get("time")
+	if moment.Date == "" || moment.Clock == "" {
+		now := time.Now().In(moment.TimeZone)
+		moment.Date = now.Format("2006-01-02")
+		moment.Clock = now.Format("15:04")
+	}
+
+	moment.Observer, err = parseObserver(r, lat, lon)
+	if err != nil {
+		return moment, err
+	}
+
+	moment.Time, err = time.ParseInLocation("2006-01-02 15:04", fmt.Sprintf("%s %s", moment.Date, moment.Clock), moment.TimeZone)
+	if err != nil {
+		return moment, newAPIError(ErrCodeInvalidDateTime, "Invalid date or time format", "date", dateTimeFormat)
+	}
+	return moment, nil
+}
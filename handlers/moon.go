@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"sun-position/utils"
+)
+
+// MoonPositionResponse holds the moon's position, phase, moonrise and moonset
+type MoonPositionResponse struct {
+	MoonAltitude float64         `json:"moon_altitude"`
+	MoonAzimuth  float64         `json:"moon_azimuth"`
+	Distance     float64         `json:"distance"`     // km from the center of the Earth
+	Illumination float64         `json:"illumination"` // illuminated fraction, 0 to 1
+	Phase        utils.MoonPhase `json:"phase"`
+	Age          float64         `json:"age"` // days since the last new moon
+	Moonrise     string          `json:"moonrise"`
+	Moonset      string          `json:"moonset"`
+	Timestamp    time.Time       `json:"timestamp"`
+	Location     string          `json:"location"`
+	City         string          `json:"city,omitempty"`
+	Date         string          `json:"date"`
+	Time         string          `json:"time"`
+	TimeZone     string          `json:"timezone"`
+	UTCOffset    string          `json:"utc_offset"`
+}
+
+// MoonPositionHandler returns the moon's altitude, azimuth, distance, illumination and phase,
+// and the day's moonrise and moonset. The location is resolved like SunPositionHandler.
+func MoonPositionHandler(w http.ResponseWriter, r *http.Request) {
+	// Resolve the location, time zone and observer like SunPositionHandler
+	moment, err := resolveMoment(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	observer, parsedTime := moment.Observer, moment.Time
+
+	moon := utils.CalculateMoonPosition(observer, parsedTime)
+	moonrise, moonset := utils.CalculateMoonriseMoonset(observer, parsedTime)
+
+	response := MoonPositionResponse{
+		MoonAltitude: moon.Altitude,
+		MoonAzimuth:  moon.Azimuth,
+		Distance:     moon.Distance,
+		Illumination: moon.Illumination,
+		Phase:        moon.Phase,
+		Age:          moon.Age,
+		Moonrise:     formatEventTime(moonrise),
+		Moonset:      formatEventTime(moonset),
+		Timestamp:    parsedTime,
+		Location:     fmt.Sprintf("%.4f, %.4f", moment.Location.Latitude, moment.Location.Longitude),
+		City:         moment.Location.City,
+		Date:         moment.Date,
+		Time:         moment.Clock,
+		TimeZone:     moment.TimeZone.String(),
+		UTCOffset:    parsedTime.Format("-07:00"),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
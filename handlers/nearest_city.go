@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"sun-position/utils"
+)
+
+// MaxNearestCities caps the limit parameter of the nearest city endpoint
+const MaxNearestCities = 50
+
+// NearestCityResponse lists the cities closest to a point, nearest first
+type NearestCityResponse struct {
+	Location string              `json:"location"`
+	Cities   []utils.NearestCity `json:"cities"`
+}
+
+// NearestCityHandler reverse geocodes coordinates to the closest known cities,
+// with the great-circle distance and bearing from the coordinates to each city
+func NearestCityHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	lat, lon, err := parseCoordinates(query.Get("lat"), query.Get("lon"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	limit := 1
+	if limitStr := query.Get("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed < 1 || parsed > MaxNearestCities {
+			writeError(w, http.StatusBadRequest, newAPIError(ErrCodeInvalidLimit, "Invalid limit", "limit", fmt.Sprintf(limitFormat, MaxNearestCities)))
+			return
+		}
+		limit = parsed
+	}
+
+	response := NearestCityResponse{
+		Location: fmt.Sprintf("%.4f, %.4f", lat, lon),
+		Cities:   utils.DefaultGazetteer().NearestCities(lat, lon, limit),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
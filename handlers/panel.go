@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"sun-position/utils"
+)
+
+// PanelOptimum is the panel orientation with the largest clear-sky annual yield
+type PanelOptimum struct {
+	Tilt         float64 `json:"tilt"`
+	Azimuth      float64 `json:"azimuth"`
+	AnnualEnergy float64 `json:"annual_energy"` // kWh/m²
+	DailyEnergy  float64 `json:"daily_energy"`  // kWh/m² on the requested date
+}
+
+// PanelEnergyResponse holds a panel's clear-sky energy for a day and a year, and the best orientation
+type PanelEnergyResponse struct {
+	Location      string                   `json:"location"`
+	City          string                   `json:"city,omitempty"`
+	Date          string                   `json:"date"`
+	TimeZone      string                   `json:"timezone"`
+	Model         utils.ClearSkyModel      `json:"model"`
+	Transposition utils.TranspositionModel `json:"transposition"`
+	Algorithm     string                   `json:"algorithm"`
+	Panel         utils.Panel              `json:"panel"`
+	DailyEnergy   float64                  `json:"daily_energy"`  // kWh/m²
+	AnnualEnergy  float64                  `json:"annual_energy"` // kWh/m² over the date's year
+	Optimal       PanelOptimum             `json:"optimal"`
+	Conditions    utils.ClearSkyConditions `json:"conditions"`
+}
+
+// PanelEnergyHandler estimates the clear-sky energy reaching a tilted panel on a date and over that
+// date's year, and finds the tilt and azimuth that maximise the annual yield. Without tilt and azimuth
+// the panel is tilted at the latitude and faces the equator.
+func PanelEnergyHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	loc, err := resolveLocation(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	location, err := resolveTimeZone(query.Get("tz"), loc.TimeZone, loc.Latitude, loc.Longitude)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// Use the current local date if not provided
+	dateStr := query.Get("date")
+	if dateStr == "" {
+		dateStr = time.Now().In(location).Format("2006-01-02")
+	}
+	date, err := time.ParseInLocation("2006-01-02", dateStr, location)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, newAPIError(ErrCodeInvalidDate, "Invalid date format", "date", dateFormat))
+		return
+	}
+
+	panel, err := parsePanel(r, loc.Latitude)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	algorithm, err := utils.ParseAlgorithm(query.Get("algorithm"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, newAPIError(ErrCodeInvalidAlgorithm, "Invalid algorithm", "algorithm", algorithmFormat))
+		return
+	}
+
+	model, err := utils.ParseClearSkyModel(query.Get("model"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, newAPIError(ErrCodeInvalidModel, "Invalid clear-sky model", "model", clearSkyFormat))
+		return
+	}
+
+	transposition, err := utils.ParseTranspositionModel(query.Get("transposition"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, newAPIError(ErrCodeInvalidTransposition, "Invalid transposition model", "transposition", transpositionFmt))
+		return
+	}
+
+	observer, err := parseObserver(r, loc.Latitude, loc.Longitude)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	conditions, err := parseClearSkyConditions(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	simulation := utils.PanelSimulation{
+		Model:         model,
+		Transposition: transposition,
+		Algorithm:     algorithm,
+		Observer:      observer,
+		Conditions:    conditions,
+	}
+	optimal, optimalEnergy := simulation.OptimalOrientation(date.Year(), location)
+
+	response := PanelEnergyResponse{
+		Location:      fmt.Sprintf("%.4f, %.4f", loc.Latitude, loc.Longitude),
+		City:          loc.City,
+		Date:          dateStr,
+		TimeZone:      location.String(),
+		Model:         model,
+		Transposition: transposition,
+		Algorithm:     string(algorithm),
+		Panel:         panel,
+		DailyEnergy:   simulation.DailyEnergy(panel, date),
+		AnnualEnergy:  simulation.AnnualEnergy(panel, date.Year(), location),
+		Optimal: PanelOptimum{
+			Tilt:         optimal.Tilt,
+			Azimuth:      optimal.Azimuth,
+			AnnualEnergy: optimalEnergy,
+			DailyEnergy:  simulation.DailyEnergy(optimal, date),
+		},
+		Conditions: conditions,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// parsePanel reads the tilt and azimuth parameters, defaulting to a panel tilted at the latitude
+// and facing the equator
+func parsePanel(r *http.Request, latitude float64) (utils.Panel, error) {
+	panel := utils.Panel{Tilt: math.Round(math.Abs(latitude)), Azimuth: 180}
+	if latitude < 0 {
+		panel.Azimuth = 0
+	}
+
+	if tiltStr := r.URL.Query().Get("tilt"); tiltStr != "" {
+		tilt, err := strconv.ParseFloat(tiltStr, 64)
+		if err != nil || !(tilt >= 0 && tilt <= 90) {
+			return panel, newAPIError(ErrCodeInvalidTilt, "Invalid tilt", "tilt", tiltFormat)
+		}
+		panel.Tilt = tilt
+	}
+
+	if azimuthStr := r.URL.Query().Get("azimuth"); azimuthStr != "" {
+		azimuth, err := strconv.ParseFloat(azimuthStr, 64)
+		if err != nil || !(azimuth >= 0 && azimuth <= 360) {
+			return panel, newAPIError(ErrCodeInvalidAzimuth, "Invalid azimuth", "azimuth", azimuthFormat)
+		}
+		panel.Azimuth = azimuth
+	}
+	return panel, nil
+}
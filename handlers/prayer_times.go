@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"sun-position/utils"
+)
+
+// PrayerTimesResponse holds the daily prayer times in local time; N/A when the sun doesn't reach the angle
+type PrayerTimesResponse struct {
+	Location     string   `json:"location"`
+	City         string   `json:"city,omitempty"`
+	Date         string   `json:"date"`
+	TimeZone     string   `json:"timezone"`
+	Method       string   `json:"method"`
+	FajrAngle    float64  `json:"fajr_angle"`
+	IshaAngle    float64  `json:"isha_angle,omitempty"`    // omitted when Isha is an interval after Maghrib
+	IshaInterval int      `json:"isha_interval,omitempty"` // minutes after Maghrib
+	AsrMethod    string   `json:"asr_method"`
+	HighLatitude string   `json:"high_latitude_rule"`
+	Ramadan      bool     `json:"ramadan"`
+	Fajr         string   `json:"fajr"`
+	Sunrise      string   `json:"sunrise"`
+	Dhuhr        string   `json:"dhuhr"`
+	Asr          string   `json:"asr"`
+	Maghrib      string   `json:"maghrib"`
+	Isha         string   `json:"isha"`
+	Adjusted     []string `json:"adjusted,omitempty"` // prayers moved by the high latitude rule
+}
+
+// PrayerTimesHandler returns Fajr, Sunrise, Dhuhr, Asr, Maghrib and Isha for a location and date,
+// using the method, asr and high_latitude query parameters to pick the conventions.
+// ramadan=true selects the longer Ramadan Isha interval of methods that have one, such as Umm al-Qura.
+func PrayerTimesHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	loc, err := resolveLocation(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	location, err := resolveTimeZone(query.Get("tz"), loc.TimeZone, loc.Latitude, loc.Longitude)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	dateStr := query.Get("date")
+	if dateStr == "" {
+		dateStr = time.Now().In(location).Format("2006-01-02")
+	}
+	date, err := time.ParseInLocation("2006-01-02", dateStr, location)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, newAPIError(ErrCodeInvalidDate, "Invalid date", "date", dateFormat))
+		return
+	}
+
+	var options utils.PrayerOptions
+	if options.Method, err = utils.ParsePrayerMethod(query.Get("method")); err != nil {
+		writeError(w, http.StatusBadRequest, newAPIError(ErrCodeInvalidPrayerMethod, "Invalid prayer method", "method", prayerMethodFmt))
+		return
+	}
+	if options.Asr, err = utils.ParseAsrMethod(query.Get("asr")); err != nil {
+		writeError(w, http.StatusBadRequest, newAPIError(ErrCodeInvalidAsrMethod, "Invalid Asr method", "asr", asrMethodFormat))
+		return
+	}
+	if options.HighLatitude, err = utils.ParseHighLatitudeRule(query.Get("high_latitude")); err != nil {
+		writeError(w, http.StatusBadRequest, newAPIError(ErrCodeInvalidHighLatitude, "Invalid high latitude rule", "high_latitude", highLatitudeFmt))
+		return
+	}
+
+	if ramadanStr := query.Get("ramadan"); ramadanStr != "" {
+		if options.Ramadan, err = strconv.ParseBool(ramadanStr); err != nil {
+			writeError(w, http.StatusBadRequest, newAPIError(ErrCodeInvalidRamadan, "Invalid ramadan flag", "ramadan", booleanFormat))
+			return
+		}
+	}
+
+	observer, err := parseObserver(r, loc.Latitude, loc.Longitude)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	times := utils.CalculatePrayerTimes(observer, date, options)
+	convention := options.Method.Convention()
+
+	response := PrayerTimesResponse{
+		Location:     fmt.Sprintf("%.4f, %.4f", loc.Latitude, loc.Longitude),
+		City:         loc.City,
+		Date:         dateStr,
+		TimeZone:     location.String(),
+		Method:       string(options.Method),
+		FajrAngle:    convention.FajrAngle,
+		IshaAngle:    convention.IshaAngle,
+		IshaInterval: int(convention.IshaAfterMaghrib(options.Ramadan).Minutes()),
+		AsrMethod:    string(options.Asr),
+		HighLatitude: string(options.HighLatitude),
+		Ramadan:      options.Ramadan,
+		Fajr:         formatEventTime(times.Fajr.In(location)),
+		Sunrise:      formatEventTime(times.Sunrise.In(location)),
+		Dhuhr:        formatEventTime(times.Dhuhr.In(location)),
+		Asr:          formatEventTime(times.Asr.In(location)),
+		Maghrib:      formatEventTime(times.Maghrib.In(location)),
+		Isha:         formatEventTime(times.Isha.In(location)),
+	}
+	if times.FajrAdjusted {
+		response.Adjusted = append(response.Adjusted, "fajr")
+	}
+	if times.IshaAdjusted {
+		response.Adjusted = append(response.Adjusted, "isha")
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
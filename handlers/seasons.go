@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"sun-position/utils"
+)
+
+// SeasonsResponse holds the equinoxes and solstices of one year
+type SeasonsResponse struct {
+	Year             int       `json:"year"`
+	TimeZone         string    `json:"timezone"` // zone the instants are expressed in, UTC by default
+	MarchEquinox     time.Time `json:"march_equinox"`
+	JuneSolstice     time.Time `json:"june_solstice"`
+	SeptemberEquinox time.Time `json:"september_equinox"`
+	DecemberSolstice time.Time `json:"december_solstice"`
+}
+
+// SeasonsHandler returns the instants of the March and September equinoxes and the June and December
+// solstices for a year. The instants are in UTC unless a tz parameter asks for another zone.
+func SeasonsHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	location := time.UTC
+	if tzName := query.Get("tz"); tzName != "" {
+		var err error
+		location, err = time.LoadLocation(tzName)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, newAPIError(ErrCodeInvalidTimeZone, "Invalid time zone", "tz", timeZoneFormat))
+			return
+		}
+	}
+
+	// Use the current year if not provided
+	year := time.Now().UTC().Year()
+	if yearStr := query.Get("year"); yearStr != "" {
+		parsed, err := strconv.Atoi(yearStr)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, newAPIError(ErrCodeInvalidYear, "Invalid year", "year", fmt.Sprintf(yearFormat, utils.MinSeasonYear, utils.MaxSeasonYear)))
+			return
+		}
+		year = parsed
+	}
+
+	seasons, err := utils.CalculateSeasons(year)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, newAPIError(ErrCodeInvalidYear, "Invalid year", "year", fmt.Sprintf(yearFormat, utils.MinSeasonYear, utils.MaxSeasonYear)))
+		return
+	}
+
+	response := SeasonsResponse{
+		Year:             year,
+		TimeZone:         location.String(),
+		MarchEquinox:     seasons.MarchEquinox.In(location),
+		JuneSolstice:     seasons.JuneSolstice.In(location),
+		SeptemberEquinox: seasons.SeptemberEquinox.In(location),
+		DecemberSolstice: seasons.DecemberSolstice.In(location),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
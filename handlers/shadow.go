@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"sun-position/utils"
+)
+
+// maxObjectHeight is the tallest object accepted by the shadow calculator, in meters
+const maxObjectHeight = 1000.0
+
+// ShadowResponse holds the shadow of an object at one moment and through the whole day
+type ShadowResponse struct {
+	Location    string              `json:"location"`
+	City        string              `json:"city,omitempty"`
+	Date        string              `json:"date"`
+	Time        string              `json:"time"`
+	TimeZone    string              `json:"timezone"`
+	Algorithm   string              `json:"algorithm"`
+	Height      float64             `json:"height"` // meters
+	SunAltitude float64             `json:"sun_altitude"`
+	SunAzimuth  float64             `json:"sun_azimuth"`
+	Shadow      *utils.Shadow       `json:"shadow"` // null while the sun is down
+	Step        int                 `json:"step"`   // minutes between path points
+	Path        []utils.ShadowPoint `json:"path"`   // daylight moments of the day, for animation
+}
+
+// ShadowHandler returns the length, direction and tip coordinates of the shadow cast by a vertical
+// object of the given height, at the requested time and through the day
+func ShadowHandler(w http.ResponseWriter, r *http.Request) {
+	moment, err := resolveMoment(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	height, err := strconv.ParseFloat(r.URL.Query().Get("height"), 64)
+	if err != nil || !(height > 0 && height <= maxObjectHeight) {
+		writeError(w, http.StatusBadRequest, newAPIError(ErrCodeInvalidHeight, "Invalid object height", "height", fmt.Sprintf(heightFormat, maxObjectHeight)))
+		return
+	}
+
+	step := defaultSunPathStep
+	if stepStr := r.URL.Query().Get("step"); stepStr != "" {
+		step, err = strconv.Atoi(stepStr)
+		if err != nil || step < minSunPathStep || step > maxSunPathStep {
+			writeError(w, http.StatusBadRequest, newAPIError(ErrCodeInvalidStep, "Invalid step", "step", fmt.Sprintf(sunPathStepFmt, minSunPathStep, maxSunPathStep)))
+			return
+		}
+	}
+
+	algorithm, err := utils.ParseAlgorithm(r.URL.Query().Get("algorithm"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, newAPIError(ErrCodeInvalidAlgorithm, "Invalid algorithm", "algorithm", algorithmFormat))
+		return
+	}
+
+	lat, lon := moment.Location.Latitude, moment.Location.Longitude
+	altitude, azimuth := utils.CalculateSunPositionForObserver(algorithm, moment.Observer, moment.Time)
+
+	response := ShadowResponse{
+		Location:    fmt.Sprintf("%.4f, %.4f", lat, lon),
+		City:        moment.Location.City,
+		Date:        moment.Date,
+		Time:        moment.Clock,
+		TimeZone:    moment.TimeZone.String(),
+		Algorithm:   string(algorithm),
+		Height:      height,
+		SunAltitude: altitude,
+		SunAzimuth:  azimuth,
+		Step:        step,
+		Path:        utils.CalculateShadowPath(algorithm, moment.Observer, height, moment.Time, time.Duration(step)*time.Minute),
+	}
+	if shadow, ok := utils.CalculateShadow(height, lat, lon, altitude, azimuth); ok {
+		response.Shadow = &shadow
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"sun-position/utils"
+)
+
+// Years accepted by the solar calendar, where the sunrise and equation of time approximations hold
+const (
+	minCalendarYear = 1900
+	maxCalendarYear = 2100
+)
+
+// SolarCalendarDay is one row of the solar calendar; times are HH:MM local time or N/A
+type SolarCalendarDay struct {
+	Date        string  `json:"date"`
+	Sunrise     string  `json:"sunrise"`
+	Sunset      string  `json:"sunset"`
+	SolarNoon   string  `json:"solar_noon"`
+	MaxAltitude float64 `json:"max_altitude"` // degrees at solar noon
+	DayLength   string  `json:"day_length"`   // Format: HH:MM
+
+	DaylightState utils.DaylightState `json:"daylight_state"`
+}
+
+// SolarCalendarSummary lists the notable days of the year
+type SolarCalendarSummary struct {
+	LongestDay          string `json:"longest_day"`
+	LongestDayLength    string `json:"longest_day_length"`
+	ShortestDay         string `json:"shortest_day"`
+	ShortestDayLength   string `json:"shortest_day_length"`
+	EarliestSunriseDate string `json:"earliest_sunrise_date"`
+	EarliestSunrise     string `json:"earliest_sunrise"`
+	LatestSunsetDate    string `json:"latest_sunset_date"`
+	LatestSunset        string `json:"latest_sunset"`
+}
+
+// SolarCalendarResponse holds the daily sun events for a whole year at one location
+type SolarCalendarResponse struct {
+	Location  string               `json:"location"`
+	City      string               `json:"city,omitempty"`
+	Year      int                  `json:"year"`
+	TimeZone  string               `json:"timezone"`
+	Algorithm string               `json:"algorithm"`
+	Summary   SolarCalendarSummary `json:"summary"`
+	Days      []SolarCalendarDay   `json:"days"`
+}
+
+// SolarCalendarHandler returns sunrise, sunset, solar noon, transit altitude and day length for every day
+// of a year as JSON, or as CSV with format=csv
+func SolarCalendarHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	format := query.Get("format")
+	if format == "" {
+		format = "json"
+	}
+	if format != "json" && format != "csv" {
+		writeError(w, http.StatusBadRequest, newAPIError(ErrCodeInvalidFormat, "Invalid format", "format", outputFormat))
+		return
+	}
+
+	loc, err := resolveLocation(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	location, err := resolveTimeZone(query.Get("tz"), loc.TimeZone, loc.Latitude, loc.Longitude)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// Use the current local year if not provided
+	year := time.Now().In(location).Year()
+	if yearStr := query.Get("year"); yearStr != "" {
+		year, err = strconv.Atoi(yearStr)
+		if err != nil || year < minCalendarYear || year > maxCalendarYear {
+			writeError(w, http.StatusBadRequest, newAPIError(ErrCodeInvalidYear, "Invalid year", "year", fmt.Sprintf(yearFormat, minCalendarYear, maxCalendarYear)))
+			return
+		}
+	}
+
+	algorithm, err := utils.ParseAlgorithm(query.Get("algorithm"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, newAPIError(ErrCodeInvalidAlgorithm, "Invalid algorithm", "algorithm", algorithmFormat))
+		return
+	}
+
+	observer, err := parseObserver(r, loc.Latitude, loc.Longitude)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	days := utils.CalculateSolarCalendar(algorithm, observer, year, location)
+	rows := make([]SolarCalendarDay, 0, len(days))
+	for _, day := range days {
+		rows = append(rows, SolarCalendarDay{
+			Date:        day.Date.Format("2006-01-02"),
+			Sunrise:     formatEventTime(day.Sunrise),
+			Sunset:      formatEventTime(day.Sunset),
+			SolarNoon:   formatEventTime(day.SolarNoon),
+			MaxAltitude: day.MaxAltitude,
+			DayLength:   formatDuration(day.DayLength),
+
+			DaylightState: day.State,
+		})
+	}
+
+	if format == "csv" {
+		writeSolarCalendarCSV(w, year, rows)
+		return
+	}
+
+	summary := utils.SummarizeSolarCalendar(days)
+	response := SolarCalendarResponse{
+		Location:  fmt.Sprintf("%.4f, %.4f", loc.Latitude, loc.Longitude),
+		City:      loc.City,
+		Year:      year,
+		TimeZone:  location.String(),
+		Algorithm: string(algorithm),
+		Summary: SolarCalendarSummary{
+			LongestDay:          summary.LongestDay.Date.Format("2006-01-02"),
+			LongestDayLength:    formatDuration(summary.LongestDay.DayLength),
+			ShortestDay:         summary.ShortestDay.Date.Format("2006-01-02"),
+			ShortestDayLength:   formatDuration(summary.ShortestDay.DayLength),
+			EarliestSunriseDate: formatEventDate(summary.EarliestSunrise.Sunrise, summary.EarliestSunrise.Date),
+			EarliestSunrise:     formatEventTime(summary.EarliestSunrise.Sunrise),
+			LatestSunsetDate:    formatEventDate(summary.LatestSunset.Sunset, summary.LatestSunset.Date),
+			LatestSunset:        formatEventTime(summary.LatestSunset.Sunset),
+		},
+		Days: rows,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// formatEventDate formats the date of a sun event as YYYY-MM-DD, or N/A when the event never occurs
+func formatEventDate(event, date time.Time) string {
+	if event.IsZero() {
+		return "N/A"
+	}
+	return date.Format("2006-01-02")
+}
+
+// writeSolarCalendarCSV writes the calendar rows as a CSV download with a header row
+func writeSolarCalendarCSV(w http.ResponseWriter, year int, rows []SolarCalendarDay) {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="solar-calendar-%d.csv"`, year))
+
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"date", "sunrise", "sunset", "solar_noon", "max_altitude", "day_length", "daylight_state"})
+	for _, row := range rows {
+		writer.Write([]string{
+			row.Date,
+			row.Sunrise,
+			row.Sunset,
+			row.SolarNoon,
+			strconv.FormatFloat(row.MaxAltitude, 'f', 2, 64),
+			row.DayLength,
+			string(row.DaylightState),
+		})
+	}
+	writer.Flush()
+}
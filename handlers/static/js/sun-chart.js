@@ -172,37 +172,32 @@ function drawSunPathChart(ctx, sunPositions, selectedTime = null) {
 }
 
 
-// Function to calculate sun positions for the entire day
-async function calculateDailySunPath(lat, lon, date, cityName = null, selectedTime = null) {
-    const positions = [];
-
-    let baseUrl;
+// Function to calculate sun positions for the entire day with the selected algorithm (fast or spa).
+// Throws with the API's error message when the request fails, so the caller can show it.
+async function calculateDailySunPath(lat, lon, date, cityName = null, selectedTime = null, algorithm = 'fast') {
+    let apiUrl;
     if (cityName) {
-        baseUrl = `/sun-pos/api/sun-position?city=${encodeURIComponent(cityName)}&date=${date}`;
+        apiUrl = `/sun-pos/api/sun-path?city=${encodeURIComponent(cityName)}&date=${date}&step=15&algorithm=${algorithm}`;
     } else {
-        baseUrl = `/sun-pos/api/sun-position?lat=${lat}&lon=${lon}&date=${date}`;
+        apiUrl = `/sun-pos/api/sun-path?lat=${lat}&lon=${lon}&date=${date}&step=15&algorithm=${algorithm}`;
     }
 
-    for (let hour = 0; hour < 24; hour++) {
-        for (let minute = 0; minute < 60; minute += 15) { // Every 15 minutes
-            const timeStr = `${String(hour).padStart(2, '0')}:${String(minute).padStart(2, '0')}`;
-            try {
-                const response = await fetch(`${baseUrl}&time=${timeStr}`);
-                const data = await response.json();
-
-                positions.push({
-                    hour: hour + minute/60,
-                    altitude: data.sun_altitude,
-                    azimuth: data.sun_azimuth,
-                    time: timeStr
-                });
-            } catch (error) {
-                console.error(`Error calculating sun position for ${timeStr}:`, error);
+    // The whole day's series comes back in a single response
+    const response = await fetch(apiUrl);
+    if (!response.ok) {
+        let message = `HTTP error! Status: ${response.status}`;
+        try {
+            const body = await response.json();
+            if (body.error && body.error.message) {
+                message = body.error.format ? `${body.error.message} (expected ${body.error.format})` : body.error.message;
             }
+        } catch (e) {
+            // Not a JSON error body
         }
+        throw new Error(message);
     }
-
-    return positions;
+    const data = await response.json();
+    return data.points || [];
 }
 
 // Helper function to map a value from one range to another
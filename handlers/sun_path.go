@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"sun-position/utils"
+)
+
+// Allowed sampling interval for the sun path, in minutes
+const (
+	defaultSunPathStep = 15
+	minSunPathStep     = 1
+	maxSunPathStep     = 180
+)
+
+// SunPathResponse represents the sun's altitude and azimuth through a whole day
+type SunPathResponse struct {
+	Location  string               `json:"location"`
+	City      string               `json:"city,omitempty"`
+	Date      string               `json:"date"`
+	TimeZone  string               `json:"timezone"`
+	Algorithm string               `json:"algorithm"`
+	Step      int                  `json:"step"` // minutes between points
+	Points    []utils.SunPathPoint `json:"points"`
+}
+
+// SunPathHandler returns the sun's position for a whole day at a fixed step in one response
+func SunPathHandler(w http.ResponseWriter, r *http.Request) {
+	loc, err := resolveLocation(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	location, err := resolveTimeZone(r.URL.Query().Get("tz"), loc.TimeZone, loc.Latitude, loc.Longitude)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// Use the current local date if not provided
+	dateStr := r.URL.Query().Get("date")
+	if dateStr == "" {
+		dateStr = time.Now().In(location).Format("2006-01-02")
+	}
+	date, err := time.ParseInLocation("2006-01-02", dateStr, location)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, newAPIError(ErrCodeInvalidDate, "Invalid date format", "date", dateFormat))
+		return
+	}
+
+	step := defaultSunPathStep
+	if stepStr := r.URL.Query().Get("step"); stepStr != "" {
+		step, err = strconv.Atoi(stepStr)
+		if err != nil || step < minSunPathStep || step > maxSunPathStep {
+			writeError(w, http.StatusBadRequest, newAPIError(ErrCodeInvalidStep, "Invalid step", "step", fmt.Sprintf(sunPathStepFmt, minSunPathStep, maxSunPathStep)))
+			return
+		}
+	}
+
+	algorithm, err := utils.ParseAlgorithm(r.URL.Query().Get("algorithm"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, newAPIError(ErrCodeInvalidAlgorithm, "Invalid algorithm", "algorithm", algorithmFormat))
+		return
+	}
+
+	observer, err := parseObserver(r, loc.Latitude, loc.Longitude)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	response := SunPathResponse{
+		Location:  fmt.Sprintf("%.4f, %.4f", loc.Latitude, loc.Longitude),
+		City:      loc.City,
+		Date:      dateStr,
+		TimeZone:  location.String(),
+		Algorithm: string(algorithm),
+		Step:      step,
+		Points:    utils.CalculateSunPath(algorithm, observer, date, time.Duration(step)*time.Minute),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
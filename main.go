@@ -5,8 +5,12 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"sun-position/handlers"
+	"sun-position/utils"
 )
 
 func main() {
@@ -15,12 +19,86 @@ func main() {
 		port = "10040"
 	}
 
+	// Forwarding headers are only believed from these proxies, e.g. "127.0.0.1,10.0.0.0/8"
+	if proxies := os.Getenv("TRUSTED_PROXIES"); proxies != "" {
+		if err := handlers.SetTrustedProxies(strings.Split(proxies, ",")); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	// The forwarding header the proxy sets: X-Forwarded-For (default), Forwarded or X-Real-IP
+	if header := os.Getenv("FORWARDED_HEADER"); header != "" {
+		if err := handlers.SetForwardedHeader(header); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	// Select the IP geolocation provider; a database path on its own selects the offline mmdb provider
+	ipProvider := os.Getenv("IP_GEOLOCATION_PROVIDER")
+	ipDatabase := os.Getenv("IP_GEOLOCATION_DB")
+	if ipProvider == "" && ipDatabase != "" {
+		ipProvider = utils.IPProviderMMDB
+	}
+	provider, err := utils.NewIPLocationProvider(ipProvider, ipDatabase)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Cache lookups in memory; failed lookups are remembered for a shorter time
+	cacheSize := envInt("IP_CACHE_SIZE", utils.DefaultIPCacheSize)
+	cacheTTL := envDuration("IP_CACHE_TTL", utils.DefaultIPCacheTTL)
+	negativeTTL := envDuration("IP_CACHE_NEGATIVE_TTL", utils.DefaultIPCacheNegativeTTL)
+	utils.SetIPLocationProvider(utils.NewCachingProvider(provider, cacheSize, cacheTTL, negativeTTL))
+	utils.SetIPLookupTimeout(envDuration("IP_LOOKUP_TIMEOUT", utils.DefaultIPLookupTimeout))
+
+	// Optionally replace the embedded time zone boundaries with a full-resolution GeoJSON file
+	if boundaries := os.Getenv("TIMEZONE_BOUNDARIES"); boundaries != "" {
+		if err := utils.LoadTimeZoneBoundariesFile(boundaries); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	// GEONAMES_CITIES replaces the embedded city list with a GeoNames dump such as cities15000.txt
+	if cities := os.Getenv("GEONAMES_CITIES"); cities != "" {
+		gazetteer, err := utils.LoadGeoNamesFile(cities, os.Getenv("GEONAMES_COUNTRY_INFO"))
+		if err != nil {
+			log.Fatal(err)
+		}
+		utils.SetGazetteer(gazetteer)
+		log.Printf("Loaded %d cities from %s", gazetteer.Len(), cities)
+	}
+
+	// DEM_PATH names an SRTM .hgt or GeoTIFF elevation model, or a directory of them, for horizon profiles
+	if demPath := os.Getenv("DEM_PATH"); demPath != "" {
+		terrain, err := utils.LoadTerrain(demPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		utils.SetTerrain(terrain)
+		log.Printf("Loaded %d elevation models from %s", terrain.Len(), demPath)
+	}
+
 	// Register routes with /sun-pos prefix - order matters!
 	// Static files first
 	http.Handle("/sun-pos/static/", http.StripPrefix("/sun-pos/static/", handlers.StaticFileServer()))
 
 	// Then API routes
 	http.HandleFunc("/sun-pos/api/sun-position", handlers.SunPositionHandler)
+	http.HandleFunc("/sun-pos/api/sun-path", handlers.SunPathHandler)
+	http.HandleFunc("/sun-pos/api/solar-calendar", handlers.SolarCalendarHandler)
+	http.HandleFunc("/sun-pos/api/seasons", handlers.SeasonsHandler)
+	http.HandleFunc("/sun-pos/api/moon-position", handlers.MoonPositionHandler)
+	http.HandleFunc("/sun-pos/api/irradiance", handlers.IrradianceHandler)
+	http.HandleFunc("/sun-pos/api/panel-energy", handlers.PanelEnergyHandler)
+	http.HandleFunc("/sun-pos/api/shadow", handlers.ShadowHandler)
+	http.HandleFunc("/sun-pos/api/alignments", handlers.AlignmentsHandler)
+	http.HandleFunc("/sun-pos/api/horizon", handlers.HorizonHandler)
+	http.HandleFunc("/sun-pos/api/prayer-times", handlers.PrayerTimesHandler)
+	http.HandleFunc("/sun-pos/api/sun-position/batch", handlers.BatchSunPositionHandler)
+	http.HandleFunc("/sun-pos/api/cities", handlers.CitiesHandler)
+	http.HandleFunc("/sun-pos/api/countries", handlers.CountriesHandler)
+	http.HandleFunc("/sun-pos/api/nearest-city", handlers.NearestCityHandler)
+	http.HandleFunc("/sun-pos/api/", handlers.APINotFoundHandler)
 
 	// Finally, catch-all for the home page (should be last)
 	http.HandleFunc("/sun-pos/", handlers.HomeHandler)
@@ -28,3 +106,29 @@ func main() {
 	fmt.Printf("Server starting on http://localhost:%s/sun-pos\n", port)
 	log.Fatal(http.ListenAndServe(":"+port, nil))
 }
+
+// envInt reads an integer environment variable, using fallback when it is unset
+func envInt(name string, fallback int) int {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		log.Fatalf("invalid %s: %v", name, err)
+	}
+	return parsed
+}
+
+// envDuration reads a duration such as 30s or 12h from the environment, using fallback when it is unset
+func envDuration(name string, fallback time.Duration) time.Duration {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		log.Fatalf("invalid %s: %v", name, err)
+	}
+	return parsed
+}
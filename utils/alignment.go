@@ -0,0 +1,114 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// MaxAlignmentDays is the longest date range FindAlignments searches
+const MaxAlignmentDays = 366
+
+// Errors returned by FindAlignments for a target or date range it cannot search
+var (
+	ErrInvalidTolerance = errors.New("tolerances must be positive")
+	ErrInvalidDateRange = errors.New("invalid date range")
+)
+
+// alignmentScanStep is how often the sun's position is sampled when looking for alignments
+const alignmentScanStep = 5 * time.Minute
+
+// AlignmentTarget is a direction on the sky and how close the sun must come to it
+type AlignmentTarget struct {
+	Azimuth           float64 `json:"azimuth"`            // degrees eastward from north
+	Altitude          float64 `json:"altitude"`           // degrees above the horizon
+	AzimuthTolerance  float64 `json:"azimuth_tolerance"`  // degrees either side of the azimuth
+	AltitudeTolerance float64 `json:"altitude_tolerance"` // degrees either side of the altitude
+}
+
+// Alignment is a moment when the sun comes closest to a target, within its tolerances
+type Alignment struct {
+	Time          time.Time `json:"time"`
+	Azimuth       float64   `json:"azimuth"`
+	Altitude      float64   `json:"altitude"`
+	AzimuthError  float64   `json:"azimuth_error"`  // sun minus target, -180 to 180 degrees
+	AltitudeError float64   `json:"altitude_error"` // sun minus target, degrees
+}
+
+// FindAlignments finds every moment from start to end when the sun reaches the target azimuth and altitude
+// within the tolerances, such as the sunsets that line up with a street. Each pass of the sun near the
+// target gives one alignment, at the moment it comes closest relative to the tolerances.
+func FindAlignments(algorithm Algorithm, observer Observer, target AlignmentTarget, start, end time.Time) ([]Alignment, error) {
+	if target.AzimuthTolerance <= 0 || target.AltitudeTolerance <= 0 {
+		return nil, ErrInvalidTolerance
+	}
+	if end.Before(start) {
+		return nil, fmt.Errorf("%w: end is before start", ErrInvalidDateRange)
+	}
+	if end.Sub(start) > MaxAlignmentDays*24*time.Hour {
+		return nil, fmt.Errorf("%w: longer than %d days", ErrInvalidDateRange, MaxAlignmentDays)
+	}
+
+	// Normalized distance from the target: 1 is the edge of the tolerance along either axis
+	miss := func(algorithm Algorithm, t time.Time) float64 {
+		altitude, azimuth := CalculateSunPositionForObserver(algorithm, observer, t)
+		dAz := angleDifference(azimuth, target.Azimuth) / target.AzimuthTolerance
+		dAlt := (altitude - target.Altitude) / target.AltitudeTolerance
+		return dAz*dAz + dAlt*dAlt
+	}
+
+	// Scan with the fast model for local minima, then refine each with the requested algorithm
+	alignments := []Alignment{}
+	previous, current := math.Inf(1), miss(AlgorithmFast, start)
+	for t := start; !t.After(end); t = t.Add(alignmentScanStep) {
+		next := miss(AlgorithmFast, t.Add(alignmentScanStep))
+		if current <= previous && current < next {
+			best := minimizeAlignment(func(t time.Time) float64 { return miss(algorithm, t) }, t.Add(-alignmentScanStep), t.Add(alignmentScanStep))
+			if best.Before(start) || best.After(end) {
+				previous, current = current, next
+				continue
+			}
+
+			altitude, azimuth := CalculateSunPositionForObserver(algorithm, observer, best)
+			alignment := Alignment{
+				Time:          best,
+				Azimuth:       azimuth,
+				Altitude:      altitude,
+				AzimuthError:  angleDifference(azimuth, target.Azimuth),
+				AltitudeError: altitude - target.Altitude,
+			}
+			if math.Abs(alignment.AzimuthError) <= target.AzimuthTolerance && math.Abs(alignment.AltitudeError) <= target.AltitudeTolerance {
+				alignments = append(alignments, alignment)
+			}
+		}
+		previous, current = current, next
+	}
+	return alignments, nil
+}
+
+// minimizeAlignment narrows the minimum of f between a and b down to a second by golden-section search
+func minimizeAlignment(f func(time.Time) float64, a, b time.Time) time.Time {
+	const invPhi = 0.6180339887498949
+	span := float64(b.Sub(a))
+	c := a.Add(time.Duration(span * (1 - invPhi)))
+	d := a.Add(time.Duration(span * invPhi))
+	fc, fd := f(c), f(d)
+	for b.Sub(a) > time.Second {
+		if fc < fd {
+			b, d, fd = d, c, fc
+			c = a.Add(time.Duration(float64(b.Sub(a)) * (1 - invPhi)))
+			fc = f(c)
+		} else {
+			a, c, fc = c, d, fd
+			d = a.Add(time.Duration(float64(b.Sub(a)) * invPhi))
+			fd = f(d)
+		}
+	}
+	return a.Add(b.Sub(a) / 2).Round(time.Second)
+}
+
+// angleDifference returns a - b wrapped to the range -180 to 180 degrees
+func angleDifference(a, b float64) float64 {
+	return math.Mod(math.Mod(a-b, 360)+540, 360) - 180
+}
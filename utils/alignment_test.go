@@ -0,0 +1,103 @@
+package utils
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestFindAlignmentsManhattanhenge(t *testing.T) {
+	// Sunset along the Manhattan street grid, with the whole disk just above the horizon
+	newYork, _ := time.LoadLocation("America/New_York")
+	observer := NewObserver(40.7128, -74.0060)
+	target := AlignmentTarget{Azimuth: 299.6, Altitude: 0.27, AzimuthTolerance: 0.05, AltitudeTolerance: 0.05}
+
+	alignments, err := FindAlignments(AlgorithmSPA, observer, target,
+		time.Date(2024, time.January, 1, 0, 0, 0, 0, newYork), time.Date(2024, time.December, 31, 0, 0, 0, 0, newYork))
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+
+	expected := []string{"2024-05-29", "2024-07-12"}
+	if len(alignments) != len(expected) {
+		t.Fatalf("Expected %d alignments, but got %+v", len(expected), alignments)
+	}
+	for i, alignment := range alignments {
+		local := alignment.Time.In(newYork)
+		if date := local.Format("2006-01-02"); date != expected[i] {
+			t.Errorf("Expected an alignment on %s, but got %s", expected[i], date)
+		}
+		if local.Hour() != 20 {
+			t.Errorf("Expected an alignment at sunset, but got %v", local)
+		}
+		if math.Abs(alignment.AzimuthError) > target.AzimuthTolerance || math.Abs(alignment.AltitudeError) > target.AltitudeTolerance {
+			t.Errorf("Expected the alignment within tolerance, but got %+v", alignment)
+		}
+	}
+}
+
+func TestFindAlignmentsEveryDay(t *testing.T) {
+	// The sun crosses the meridian due south once a day; any altitude above the horizon qualifies
+	observer := NewObserver(51.5074, -0.1278)
+	target := AlignmentTarget{Azimuth: 180, Altitude: 45, AzimuthTolerance: 0.5, AltitudeTolerance: 45}
+	start := time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC)
+
+	alignments, err := FindAlignments(AlgorithmFast, observer, target, start, start.AddDate(0, 0, 7))
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+	if len(alignments) != 7 {
+		t.Fatalf("Expected one alignment a day for 7 days, but got %d", len(alignments))
+	}
+	for _, alignment := range alignments {
+		if math.Abs(alignment.AzimuthError) > 0.01 {
+			t.Errorf("Expected the sun due south, but got azimuth %f at %v", alignment.Azimuth, alignment.Time)
+		}
+		if noon := CalculateSolarNoon(observer.Longitude, alignment.Time); math.Abs(alignment.Time.Sub(noon).Minutes()) > 2 {
+			t.Errorf("Expected the alignment at solar noon %v, but got %v", noon, alignment.Time)
+		}
+	}
+}
+
+func TestFindAlignmentsErrors(t *testing.T) {
+	observer := NewObserver(51.5074, -0.1278)
+	start := time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC)
+	valid := AlignmentTarget{Azimuth: 90, AzimuthTolerance: 1, AltitudeTolerance: 1}
+
+	testCases := []struct {
+		name   string
+		target AlignmentTarget
+		end    time.Time
+		want   error
+	}{
+		{"Zero tolerance", AlignmentTarget{Azimuth: 90, AltitudeTolerance: 1}, start.AddDate(0, 0, 1), ErrInvalidTolerance},
+		{"End before start", valid, start.AddDate(0, 0, -1), ErrInvalidDateRange},
+		{"Range too long", valid, start.AddDate(2, 0, 0), ErrInvalidDateRange},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := FindAlignments(AlgorithmFast, observer, tc.target, start, tc.end); !errors.Is(err, tc.want) {
+				t.Errorf("Expected %v, but got %v", tc.want, err)
+			}
+		})
+	}
+}
+
+func TestAngleDifference(t *testing.T) {
+	testCases := []struct {
+		a, b, expected float64
+	}{
+		{10, 350, 20},
+		{350, 10, -20},
+		{180, 0, -180},
+		{299.6, 299.5, 0.1},
+	}
+
+	for _, tc := range testCases {
+		if got := angleDifference(tc.a, tc.b); math.Abs(got-tc.expected) > 1e-9 {
+			t.Errorf("Expected %f - %f = %f, but got %f", tc.a, tc.b, tc.expected, got)
+		}
+	}
+}
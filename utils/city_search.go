@@ -0,0 +1,243 @@
+package utils
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// City search modes
+const (
+	SearchModePrefix = "prefix" // names or words in names starting with the query
+	SearchModeFuzzy  = "fuzzy"  // prefix matches plus names within a small edit distance
+)
+
+// Pagination limits for city search
+const (
+	DefaultSearchLimit = 10
+	MaxSearchLimit     = 100
+)
+
+// CitySearchOptions controls SearchCities
+type CitySearchOptions struct {
+	Query   string
+	Country string // optional ISO code, name or alias
+	Mode    string
+	Limit   int
+	Offset  int
+}
+
+// CitySearchResult is one page of matching cities and the total number of matches
+type CitySearchResult struct {
+	Cities []City
+	Total  int
+}
+
+// CountrySummary is a country with the number of cities available for it
+type CountrySummary struct {
+	Name   string `json:"name"`
+	Code   string `json:"code,omitempty"` // ISO 3166-1 alpha-2
+	Cities int    `json:"cities"`
+}
+
+// accentFolds is a hand-written fold table from lower-case Latin letters with diacritics, and ligatures,
+// to plain ASCII; no Unicode normalization is applied, so precomposed letters must be listed here
+var accentFolds = map[rune]string{
+	'à': "a", 'á': "a", 'â': "a", 'ã': "a", 'ä': "a", 'å': "a", 'ā': "a", 'ă': "a", 'ą': "a",
+	'ç': "c", 'ć': "c", 'č': "c", 'ĉ': "c", 'ċ': "c",
+	'ď': "d", 'đ': "d", 'ð': "d",
+	'è': "e", 'é': "e", 'ê': "e", 'ë': "e", 'ē': "e", 'ĕ': "e", 'ė': "e", 'ę': "e", 'ě': "e",
+	'ğ': "g", 'ĝ': "g", 'ġ': "g", 'ģ': "g",
+	'ĥ': "h", 'ħ': "h",
+	'ì': "i", 'í': "i", 'î': "i", 'ï': "i", 'ĩ': "i", 'ī': "i", 'ĭ': "i", 'į': "i", 'ı': "i",
+	'ĵ': "j",
+	'ķ': "k",
+	'ĺ': "l", 'ļ': "l", 'ľ': "l", 'ŀ': "l", 'ł': "l",
+	'ñ': "n", 'ń': "n", 'ņ': "n", 'ň': "n",
+	'ò': "o", 'ó': "o", 'ô': "o", 'õ': "o", 'ö': "o", 'ø': "o", 'ō': "o", 'ŏ': "o", 'ő': "o",
+	'ŕ': "r", 'ŗ': "r", 'ř': "r",
+	'ś': "s", 'ŝ': "s", 'ş': "s", 'š': "s", 'ș': "s",
+	'ţ': "t", 'ť': "t", 'ŧ': "t", 'ț': "t",
+	'ù': "u", 'ú': "u", 'û': "u", 'ü': "u", 'ũ': "u", 'ū': "u", 'ŭ': "u", 'ů': "u", 'ű': "u", 'ų': "u",
+	'ŵ': "w",
+	'ý': "y", 'ÿ': "y", 'ŷ': "y",
+	'ź': "z", 'ż': "z", 'ž': "z",
+	'ß': "ss", 'æ': "ae", 'œ': "oe", 'þ': "th",
+}
+
+// FoldName normalizes a place name for comparison: lower case, accents removed,
+// and punctuation collapsed to single spaces, so "São Paulo" and "sao-paulo" compare equal
+func FoldName(name string) string {
+	var b strings.Builder
+	pendingSpace := false
+	for _, r := range strings.ToLower(name) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			// Combining accents from decomposed input
+			continue
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if pendingSpace && b.Len() > 0 {
+				b.WriteByte(' ')
+			}
+			pendingSpace = false
+			if folded, ok := accentFolds[r]; ok {
+				b.WriteString(folded)
+			} else {
+				b.WriteRune(r)
+			}
+		default:
+			pendingSpace = true
+		}
+	}
+	return b.String()
+}
+
+// FindCity returns the city with the given name from the active gazetteer, ignoring case and accents
+func FindCity(name string) (City, bool) {
+	return DefaultGazetteer().FindCity(name)
+}
+
+// SearchCities searches the active gazetteer
+func SearchCities(options CitySearchOptions) CitySearchResult {
+	return DefaultGazetteer().Search(options)
+}
+
+// Search finds cities whose names match the query, best matches first.
+// Exact matches rank above name prefixes, then word prefixes, then fuzzy matches;
+// ties go to the more populous city and then alphabetically.
+func (g *Gazetteer) Search(options CitySearchOptions) CitySearchResult {
+	query := FoldName(options.Query)
+	countryCode := CountryCode(options.Country)
+
+	type match struct {
+		index int
+		score int
+	}
+	var matches []match
+	for i, city := range g.cities {
+		if options.Country != "" && !g.inCountry(city, options.Country, countryCode) {
+			continue
+		}
+		if score, ok := matchCityName(g.folded[i], query, options.Mode == SearchModeFuzzy); ok {
+			matches = append(matches, match{index: i, score: score})
+		}
+	}
+
+	sort.SliceStable(matches, func(a, b int) bool {
+		if matches[a].score != matches[b].score {
+			return matches[a].score < matches[b].score
+		}
+		if populationA, populationB := g.cities[matches[a].index].Population, g.cities[matches[b].index].Population; populationA != populationB {
+			return populationA > populationB
+		}
+		return g.folded[matches[a].index] < g.folded[matches[b].index]
+	})
+
+	limit := options.Limit
+	if limit <= 0 {
+		limit = DefaultSearchLimit
+	}
+	if limit > MaxSearchLimit {
+		limit = MaxSearchLimit
+	}
+
+	result := CitySearchResult{Total: len(matches), Cities: []City{}}
+	for i := options.Offset; i >= 0 && i < len(matches) && len(result.Cities) < limit; i++ {
+		result.Cities = append(result.Cities, g.cities[matches[i].index])
+	}
+	return result
+}
+
+// matchCityName scores how well a folded name matches a folded query; lower is better
+func matchCityName(name, query string, fuzzy bool) (int, bool) {
+	switch {
+	case query == "":
+		return 3, true
+	case name == query:
+		return 0, true
+	case strings.HasPrefix(name, query):
+		return 1, true
+	case strings.Contains(" "+name, " "+query):
+		// A later word starts with the query, e.g. "paulo" in "sao paulo"
+		return 2, true
+	}
+	if !fuzzy {
+		return 0, false
+	}
+
+	// Compare against the whole name and, for autocomplete, against a prefix of the same length
+	maxDistance := maxEditDistance(query)
+	distance := levenshtein(name, query)
+	if runes := []rune(name); len(runes) > len([]rune(query)) {
+		distance = min(distance, levenshtein(string(runes[:len([]rune(query))]), query))
+	}
+	if distance <= maxDistance {
+		return 3 + distance, true
+	}
+	return 0, false
+}
+
+// maxEditDistance allows more typos in longer queries
+func maxEditDistance(query string) int {
+	switch n := len([]rune(query)); {
+	case n <= 3:
+		return 0
+	case n <= 5:
+		return 1
+	case n <= 9:
+		return 2
+	default:
+		return 3
+	}
+}
+
+// levenshtein returns the number of single character edits needed to turn a into b
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(rb)]
+}
+
+// GetCountrySummaries lists the countries in the active gazetteer
+func GetCountrySummaries(query string) []CountrySummary {
+	return DefaultGazetteer().CountrySummaries(query)
+}
+
+// CountrySummaries returns the countries with their city counts, sorted by name.
+// A non-empty query keeps only countries whose name or a word in it starts with the query.
+func (g *Gazetteer) CountrySummaries(query string) []CountrySummary {
+	folded := FoldName(query)
+	counts := make(map[string]int)
+	codes := make(map[string]string)
+	for _, city := range g.cities {
+		counts[city.Country]++
+		codes[city.Country] = city.CountryCode
+	}
+
+	summaries := []CountrySummary{}
+	for _, country := range g.Countries() {
+		if folded != "" && !strings.Contains(" "+FoldName(country), " "+folded) {
+			continue
+		}
+		summaries = append(summaries, CountrySummary{Name: country, Code: codes[country], Cities: counts[country]})
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		return FoldName(summaries[i].Name) < FoldName(summaries[j].Name)
+	})
+	return summaries
+}
@@ -0,0 +1,137 @@
+package utils
+
+import (
+	"testing"
+)
+
+func TestFoldName(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{"São Paulo", "sao paulo"},
+		{"Reykjavík", "reykjavik"},
+		{"Zürich", "zurich"},
+		{"Kraków", "krakow"},
+		{"Łódź", "lodz"},
+		{"Brasília", "brasilia"},
+		{"Sa\u0303o Paulo", "sao paulo"}, // decomposed tilde
+		{"  N'Djamena ", "n djamena"},
+		{"Port-au-Prince", "port au prince"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			result := FoldName(tc.input)
+			if result != tc.expected {
+				t.Errorf("Expected folded name %q for %q, but got %q", tc.expected, tc.input, result)
+			}
+		})
+	}
+}
+
+func TestFindCity(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{"Sao Paulo", "São Paulo"},
+		{"KHARTOUM", "Khartoum"},
+		{"rio-de-janeiro", "Rio de Janeiro"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			city, ok := FindCity(tc.input)
+			if !ok || city.Name != tc.expected {
+				t.Errorf("Expected to find %s for %q, but got %q (found=%t)", tc.expected, tc.input, city.Name, ok)
+			}
+		})
+	}
+
+	if _, ok := FindCity("Atlantis"); ok {
+		t.Errorf("Expected Atlantis not to be found")
+	}
+}
+
+func TestSearchCities(t *testing.T) {
+	testCases := []struct {
+		name     string
+		options  CitySearchOptions
+		expected []string
+	}{
+		{"Prefix", CitySearchOptions{Query: "sa"}, []string{"Santiago", "São Paulo"}},
+		{"Accent-insensitive prefix", CitySearchOptions{Query: "São"}, []string{"São Paulo"}},
+		{"Word prefix", CitySearchOptions{Query: "paulo"}, []string{"São Paulo"}},
+		{"Exact match first", CitySearchOptions{Query: "Rio"}, []string{"Rio de Janeiro"}},
+		{"Country filter", CitySearchOptions{Query: "", Country: "usa"}, []string{"Chicago", "Los Angeles", "Miami", "New York"}},
+		{"Fuzzy typo", CitySearchOptions{Query: "Londn", Mode: SearchModeFuzzy}, []string{"London"}},
+		{"Fuzzy spelling", CitySearchOptions{Query: "Tokio", Mode: SearchModeFuzzy}, []string{"Tokyo"}},
+		{"No fuzzy in prefix mode", CitySearchOptions{Query: "Londn"}, []string{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := SearchCities(tc.options)
+			if len(result.Cities) != len(tc.expected) {
+				t.Fatalf("Expected %d cities, but got %d: %v", len(tc.expected), len(result.Cities), result.Cities)
+			}
+			for i, name := range tc.expected {
+				if result.Cities[i].Name != name {
+					t.Errorf("Expected city %d to be %s, but got %s", i, name, result.Cities[i].Name)
+				}
+			}
+		})
+	}
+}
+
+func TestSearchCitiesPagination(t *testing.T) {
+	all := SearchCities(CitySearchOptions{Limit: MaxSearchLimit})
+	if all.Total != len(CommonCities) || len(all.Cities) != len(CommonCities) {
+		t.Fatalf("Expected all %d cities, but got %d of %d", len(CommonCities), len(all.Cities), all.Total)
+	}
+
+	page := SearchCities(CitySearchOptions{Limit: 5, Offset: 10})
+	if page.Total != len(CommonCities) || len(page.Cities) != 5 {
+		t.Fatalf("Expected a page of 5 out of %d, but got %d out of %d", len(CommonCities), len(page.Cities), page.Total)
+	}
+	for i, city := range page.Cities {
+		if city.Name != all.Cities[10+i].Name {
+			t.Errorf("Expected %s at position %d, but got %s", all.Cities[10+i].Name, 10+i, city.Name)
+		}
+	}
+
+	if past := SearchCities(CitySearchOptions{Offset: 1000}); len(past.Cities) != 0 {
+		t.Errorf("Expected no cities past the end, but got %d", len(past.Cities))
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	testCases := []struct {
+		a, b     string
+		expected int
+	}{
+		{"", "abc", 3},
+		{"kitten", "sitting", 3},
+		{"london", "london", 0},
+		{"são", "sao", 1},
+	}
+
+	for _, tc := range testCases {
+		if result := levenshtein(tc.a, tc.b); result != tc.expected {
+			t.Errorf("Expected distance %d between %q and %q, but got %d", tc.expected, tc.a, tc.b, result)
+		}
+	}
+}
+
+func TestGetCountrySummaries(t *testing.T) {
+	summaries := GetCountrySummaries("")
+	if len(summaries) != len(GetCountries()) {
+		t.Errorf("Expected %d countries, but got %d", len(GetCountries()), len(summaries))
+	}
+
+	filtered := GetCountrySummaries("bra")
+	if len(filtered) != 1 || filtered[0].Name != "Brazil" || filtered[0].Cities != 2 {
+		t.Errorf("Expected Brazil with 2 cities, but got %v", filtered)
+	}
+}
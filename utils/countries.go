@@ -0,0 +1,346 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Country is a country or territory with its ISO 3166-1 codes
+type Country struct {
+	Alpha2  string `json:"alpha2"`
+	Alpha3  string `json:"alpha3"`
+	Name    string `json:"name"`
+	Capital string `json:"capital"`
+}
+
+// Countries lists the countries known to the capital lookup, by short English name.
+// Kosovo uses the user-assigned code XK, as GeoNames and the GeoIP databases do.
+var Countries = []Country{
+	{Alpha2: "AF", Alpha3: "AFG", Name: "Afghanistan", Capital: "Kabul"},
+	{Alpha2: "AL", Alpha3: "ALB", Name: "Albania", Capital: "Tirana"},
+	{Alpha2: "DZ", Alpha3: "DZA", Name: "Algeria", Capital: "Algiers"},
+	{Alpha2: "AS", Alpha3: "ASM", Name: "American Samoa", Capital: "Pago Pago"},
+	{Alpha2: "AD", Alpha3: "AND", Name: "Andorra", Capital: "Andorra la Vella"},
+	{Alpha2: "AO", Alpha3: "AGO", Name: "Angola", Capital: "Luanda"},
+	{Alpha2: "AG", Alpha3: "ATG", Name: "Antigua and Barbuda", Capital: "St. John's"},
+	{Alpha2: "AR", Alpha3: "ARG", Name: "Argentina", Capital: "Buenos Aires"},
+	{Alpha2: "AM", Alpha3: "ARM", Name: "Armenia", Capital: "Yerevan"},
+	{Alpha2: "AU", Alpha3: "AUS", Name: "Australia", Capital: "Canberra"},
+	{Alpha2: "AT", Alpha3: "AUT", Name: "Austria", Capital: "Vienna"},
+	{Alpha2: "AZ", Alpha3: "AZE", Name: "Azerbaijan", Capital: "Baku"},
+	{Alpha2: "BS", Alpha3: "BHS", Name: "Bahamas", Capital: "Nassau"},
+	{Alpha2: "BH", Alpha3: "BHR", Name: "Bahrain", Capital: "Manama"},
+	{Alpha2: "BD", Alpha3: "BGD", Name: "Bangladesh", Capital: "Dhaka"},
+	{Alpha2: "BB", Alpha3: "BRB", Name: "Barbados", Capital: "Bridgetown"},
+	{Alpha2: "BY", Alpha3: "BLR", Name: "Belarus", Capital: "Minsk"},
+	{Alpha2: "BE", Alpha3: "BEL", Name: "Belgium", Capital: "Brussels"},
+	{Alpha2: "BZ", Alpha3: "BLZ", Name: "Belize", Capital: "Belmopan"},
+	{Alpha2: "BJ", Alpha3: "BEN", Name: "Benin", Capital: "Porto-Novo"},
+	{Alpha2: "BT", Alpha3: "BTN", Name: "Bhutan", Capital: "Thimphu"},
+	{Alpha2: "BO", Alpha3: "BOL", Name: "Bolivia", Capital: "La Paz"},
+	{Alpha2: "BA", Alpha3: "BIH", Name: "Bosnia and Herzegovina", Capital: "Sarajevo"},
+	{Alpha2: "BW", Alpha3: "BWA", Name: "Botswana", Capital: "Gaborone"},
+	{Alpha2: "BR", Alpha3: "BRA", Name: "Brazil", Capital: "Brasília"},
+	{Alpha2: "BN", Alpha3: "BRN", Name: "Brunei", Capital: "Bandar Seri Begawan"},
+	{Alpha2: "BG", Alpha3: "BGR", Name: "Bulgaria", Capital: "Sofia"},
+	{Alpha2: "BF", Alpha3: "BFA", Name: "Burkina Faso", Capital: "Ouagadougou"},
+	{Alpha2: "BI", Alpha3: "BDI", Name: "Burundi", Capital: "Gitega"},
+	{Alpha2: "CV", Alpha3: "CPV", Name: "Cabo Verde", Capital: "Praia"},
+	{Alpha2: "KH", Alpha3: "KHM", Name: "Cambodia", Capital: "Phnom Penh"},
+	{Alpha2: "CM", Alpha3: "CMR", Name: "Cameroon", Capital: "Yaoundé"},
+	{Alpha2: "CA", Alpha3: "CAN", Name: "Canada", Capital: "Ottawa"},
+	{Alpha2: "CF", Alpha3: "CAF", Name: "Central African Republic", Capital: "Bangui"},
+	{Alpha2: "TD", Alpha3: "TCD", Name: "Chad", Capital: "N'Djamena"},
+	{Alpha2: "CL", Alpha3: "CHL", Name: "Chile", Capital: "Santiago"},
+	{Alpha2: "CN", Alpha3: "CHN", Name: "China", Capital: "Beijing"},
+	{Alpha2: "CO", Alpha3: "COL", Name: "Colombia", Capital: "Bogotá"},
+	{Alpha2: "KM", Alpha3: "COM", Name: "Comoros", Capital: "Moroni"},
+	{Alpha2: "CD", Alpha3: "COD", Name: "Democratic Republic of the Congo", Capital: "Kinshasa"},
+	{Alpha2: "CG", Alpha3: "COG", Name: "Republic of the Congo", Capital: "Brazzaville"},
+	{Alpha2: "CR", Alpha3: "CRI", Name: "Costa Rica", Capital: "San José"},
+	{Alpha2: "HR", Alpha3: "HRV", Name: "Croatia", Capital: "Zagreb"},
+	{Alpha2: "CU", Alpha3: "CUB", Name: "Cuba", Capital: "Havana"},
+	{Alpha2: "CY", Alpha3: "CYP", Name: "Cyprus", Capital: "Nicosia"},
+	{Alpha2: "CZ", Alpha3: "CZE", Name: "Czech Republic", Capital: "Prague"},
+	{Alpha2: "DK", Alpha3: "DNK", Name: "Denmark", Capital: "Copenhagen"},
+	{Alpha2: "DJ", Alpha3: "DJI", Name: "Djibouti", Capital: "Djibouti"},
+	{Alpha2: "DM", Alpha3: "DMA", Name: "Dominica", Capital: "Roseau"},
+	{Alpha2: "DO", Alpha3: "DOM", Name: "Dominican Republic", Capital: "Santo Domingo"},
+	{Alpha2: "EC", Alpha3: "ECU", Name: "Ecuador", Capital: "Quito"},
+	{Alpha2: "EG", Alpha3: "EGY", Name: "Egypt", Capital: "Cairo"},
+	{Alpha2: "SV", Alpha3: "SLV", Name: "El Salvador", Capital: "San Salvador"},
+	{Alpha2: "GQ", Alpha3: "GNQ", Name: "Equatorial Guinea", Capital: "Malabo"},
+	{Alpha2: "ER", Alpha3: "ERI", Name: "Eritrea", Capital: "Asmara"},
+	{Alpha2: "EE", Alpha3: "EST", Name: "Estonia", Capital: "Tallinn"},
+	{Alpha2: "SZ", Alpha3: "SWZ", Name: "Eswatini", Capital: "Mbabane"},
+	{Alpha2: "ET", Alpha3: "ETH", Name: "Ethiopia", Capital: "Addis Ababa"},
+	{Alpha2: "FJ", Alpha3: "FJI", Name: "Fiji", Capital: "Suva"},
+	{Alpha2: "FI", Alpha3: "FIN", Name: "Finland", Capital: "Helsinki"},
+	{Alpha2: "FR", Alpha3: "FRA", Name: "France", Capital: "Paris"},
+	{Alpha2: "GA", Alpha3: "GAB", Name: "Gabon", Capital: "Libreville"},
+	{Alpha2: "GM", Alpha3: "GMB", Name: "Gambia", Capital: "Banjul"},
+	{Alpha2: "GE", Alpha3: "GEO", Name: "Georgia", Capital: "Tbilisi"},
+	{Alpha2: "DE", Alpha3: "DEU", Name: "Germany", Capital: "Berlin"},
+	{Alpha2: "GH", Alpha3: "GHA", Name: "Ghana", Capital: "Accra"},
+	{Alpha2: "GR", Alpha3: "GRC", Name: "Greece", Capital: "Athens"},
+	{Alpha2: "GD", Alpha3: "GRD", Name: "Grenada", Capital: "St. George's"},
+	{Alpha2: "GT", Alpha3: "GTM", Name: "Guatemala", Capital: "Guatemala City"},
+	{Alpha2: "GN", Alpha3: "GIN", Name: "Guinea", Capital: "Conakry"},
+	{Alpha2: "GW", Alpha3: "GNB", Name: "Guinea-Bissau", Capital: "Bissau"},
+	{Alpha2: "GY", Alpha3: "GUY", Name: "Guyana", Capital: "Georgetown"},
+	{Alpha2: "HT", Alpha3: "HTI", Name: "Haiti", Capital: "Port-au-Prince"},
+	{Alpha2: "HN", Alpha3: "HND", Name: "Honduras", Capital: "Tegucigalpa"},
+	{Alpha2: "HK", Alpha3: "HKG", Name: "Hong Kong", Capital: "Hong Kong"},
+	{Alpha2: "HU", Alpha3: "HUN", Name: "Hungary", Capital: "Budapest"},
+	{Alpha2: "IS", Alpha3: "ISL", Name: "Iceland", Capital: "Reykjavik"},
+	{Alpha2: "IN", Alpha3: "IND", Name: "India", Capital: "New Delhi"},
+	{Alpha2: "ID", Alpha3: "IDN", Name: "Indonesia", Capital: "Jakarta"},
+	{Alpha2: "IR", Alpha3: "IRN", Name: "Iran", Capital: "Tehran"},
+	{Alpha2: "IQ", Alpha3: "IRQ", Name: "Iraq", Capital: "Baghdad"},
+	{Alpha2: "IE", Alpha3: "IRL", Name: "Ireland", Capital: "Dublin"},
+	{Alpha2: "IL", Alpha3: "ISR", Name: "Israel", Capital: "Jerusalem"},
+	{Alpha2: "IT", Alpha3: "ITA", Name: "Italy", Capital: "Rome"},
+	{Alpha2: "CI", Alpha3: "CIV", Name: "Ivory Coast", Capital: "Yamoussoukro"},
+	{Alpha2: "JM", Alpha3: "JAM", Name: "Jamaica", Capital: "Kingston"},
+	{Alpha2: "JP", Alpha3: "JPN", Name: "Japan", Capital: "Tokyo"},
+	{Alpha2: "JO", Alpha3: "JOR", Name: "Jordan", Capital: "Amman"},
+	{Alpha2: "KZ", Alpha3: "KAZ", Name: "Kazakhstan", Capital: "Astana"},
+	{Alpha2: "KE", Alpha3: "KEN", Name: "Kenya", Capital: "Nairobi"},
+	{Alpha2: "KI", Alpha3: "KIR", Name: "Kiribati", Capital: "Tarawa"},
+	{Alpha2: "KP", Alpha3: "PRK", Name: "North Korea", Capital: "Pyongyang"},
+	{Alpha2: "KR", Alpha3: "KOR", Name: "South Korea", Capital: "Seoul"},
+	{Alpha2: "XK", Alpha3: "XKX", Name: "Kosovo", Capital: "Pristina"},
+	{Alpha2: "KW", Alpha3: "KWT", Name: "Kuwait", Capital: "Kuwait City"},
+	{Alpha2: "KG", Alpha3: "KGZ", Name: "Kyrgyzstan", Capital: "Bishkek"},
+	{Alpha2: "LA", Alpha3: "LAO", Name: "Laos", Capital: "Vientiane"},
+	{Alpha2: "LV", Alpha3: "LVA", Name: "Latvia", Capital: "Riga"},
+	{Alpha2: "LB", Alpha3: "LBN", Name: "Lebanon", Capital: "Beirut"},
+	{Alpha2: "LS", Alpha3: "LSO", Name: "Lesotho", Capital: "Maseru"},
+	{Alpha2: "LR", Alpha3: "LBR", Name: "Liberia", Capital: "Monrovia"},
+	{Alpha2: "LY", Alpha3: "LBY", Name: "Libya", Capital: "Tripoli"},
+	{Alpha2: "LI", Alpha3: "LIE", Name: "Liechtenstein", Capital: "Vaduz"},
+	{Alpha2: "LT", Alpha3: "LTU", Name: "Lithuania", Capital: "Vilnius"},
+	{Alpha2: "LU", Alpha3: "LUX", Name: "Luxembourg", Capital: "Luxembourg City"},
+	{Alpha2: "MO", Alpha3: "MAC", Name: "Macau", Capital: "Macau"},
+	{Alpha2: "MG", Alpha3: "MDG", Name: "Madagascar", Capital: "Antananarivo"},
+	{Alpha2: "MW", Alpha3: "MWI", Name: "Malawi", Capital: "Lilongwe"},
+	{Alpha2: "MY", Alpha3: "MYS", Name: "Malaysia", Capital: "Kuala Lumpur"},
+	{Alpha2: "MV", Alpha3: "MDV", Name: "Maldives", Capital: "Malé"},
+	{Alpha2: "ML", Alpha3: "MLI", Name: "Mali", Capital: "Bamako"},
+	{Alpha2: "MT", Alpha3: "MLT", Name: "Malta", Capital: "Valletta"},
+	{Alpha2: "MH", Alpha3: "MHL", Name: "Marshall Islands", Capital: "Majuro"},
+	{Alpha2: "MR", Alpha3: "MRT", Name: "Mauritania", Capital: "Nouakchott"},
+	{Alpha2: "MU", Alpha3: "MUS", Name: "Mauritius", Capital: "Port Louis"},
+	{Alpha2: "MX", Alpha3: "MEX", Name: "Mexico", Capital: "Mexico City"},
+	{Alpha2: "FM", Alpha3: "FSM", Name: "Micronesia", Capital: "Palikir"},
+	{Alpha2: "MD", Alpha3: "MDA", Name: "Moldova", Capital: "Chișinău"},
+	{Alpha2: "MC", Alpha3: "MCO", Name: "Monaco", Capital: "Monaco"},
+	{Alpha2: "MN", Alpha3: "MNG", Name: "Mongolia", Capital: "Ulaanbaatar"},
+	{Alpha2: "ME", Alpha3: "MNE", Name: "Montenegro", Capital: "Podgorica"},
+	{Alpha2: "MA", Alpha3: "MAR", Name: "Morocco", Capital: "Rabat"},
+	{Alpha2: "MZ", Alpha3: "MOZ", Name: "Mozambique", Capital: "Maputo"},
+	{Alpha2: "MM", Alpha3: "MMR", Name: "Myanmar", Capital: "Naypyidaw"},
+	{Alpha2: "NA", Alpha3: "NAM", Name: "Namibia", Capital: "Windhoek"},
+	{Alpha2: "NR", Alpha3: "NRU", Name: "Nauru", Capital: "Yaren"},
+	{Alpha2: "NP", Alpha3: "NPL", Name: "Nepal", Capital: "Kathmandu"},
+	{Alpha2: "NL", Alpha3: "NLD", Name: "Netherlands", Capital: "Amsterdam"},
+	{Alpha2: "NZ", Alpha3: "NZL", Name: "New Zealand", Capital: "Wellington"},
+	{Alpha2: "NI", Alpha3: "NIC", Name: "Nicaragua", Capital: "Managua"},
+	{Alpha2: "NE", Alpha3: "NER", Name: "Niger", Capital: "Niamey"},
+	{Alpha2: "NG", Alpha3: "NGA", Name: "Nigeria", Capital: "Abuja"},
+	{Alpha2: "MK", Alpha3: "MKD", Name: "North Macedonia", Capital: "Skopje"},
+	{Alpha2: "NO", Alpha3: "NOR", Name: "Norway", Capital: "Oslo"},
+	{Alpha2: "OM", Alpha3: "OMN", Name: "Oman", Capital: "Muscat"},
+	{Alpha2: "PK", Alpha3: "PAK", Name: "Pakistan", Capital: "Islamabad"},
+	{Alpha2: "PW", Alpha3: "PLW", Name: "Palau", Capital: "Ngerulmud"},
+	{Alpha2: "PS", Alpha3: "PSE", Name: "Palestine", Capital: "Ramallah"},
+	{Alpha2: "PA", Alpha3: "PAN", Name: "Panama", Capital: "Panama City"},
+	{Alpha2: "PG", Alpha3: "PNG", Name: "Papua New Guinea", Capital: "Port Moresby"},
+	{Alpha2: "PY", Alpha3: "PRY", Name: "Paraguay", Capital: "Asunción"},
+	{Alpha2: "PE", Alpha3: "PER", Name: "Peru", Capital: "Lima"},
+	{Alpha2: "PH", Alpha3: "PHL", Name: "Philippines", Capital: "Manila"},
+	{Alpha2: "PL", Alpha3: "POL", Name: "Poland", Capital: "Warsaw"},
+	{Alpha2: "PT", Alpha3: "PRT", Name: "Portugal", Capital: "Lisbon"},
+	{Alpha2: "QA", Alpha3: "QAT", Name: "Qatar", Capital: "Doha"},
+	{Alpha2: "RO", Alpha3: "ROU", Name: "Romania", Capital: "Bucharest"},
+	{Alpha2: "RU", Alpha3: "RUS", Name: "Russia", Capital: "Moscow"},
+	{Alpha2: "RW", Alpha3: "RWA", Name: "Rwanda", Capital: "Kigali"},
+	{Alpha2: "KN", Alpha3: "KNA", Name: "Saint Kitts and Nevis", Capital: "Basseterre"},
+	{Alpha2: "LC", Alpha3: "LCA", Name: "Saint Lucia", Capital: "Castries"},
+	{Alpha2: "VC", Alpha3: "VCT", Name: "Saint Vincent and the Grenadines", Capital: "Kingstown"},
+	{Alpha2: "WS", Alpha3: "WSM", Name: "Samoa", Capital: "Apia"},
+	{Alpha2: "SM", Alpha3: "SMR", Name: "San Marino", Capital: "San Marino"},
+	{Alpha2: "ST", Alpha3: "STP", Name: "Sao Tome and Principe", Capital: "São Tomé"},
+	{Alpha2: "SA", Alpha3: "SAU", Name: "Saudi Arabia", Capital: "Riyadh"},
+	{Alpha2: "SN", Alpha3: "SEN", Name: "Senegal", Capital: "Dakar"},
+	{Alpha2: "RS", Alpha3: "SRB", Name: "Serbia", Capital: "Belgrade"},
+	{Alpha2: "SC", Alpha3: "SYC", Name: "Seychelles", Capital: "Victoria"},
+	{Alpha2: "SL", Alpha3: "SLE", Name: "Sierra Leone", Capital: "Freetown"},
+	{Alpha2: "SG", Alpha3: "SGP", Name: "Singapore", Capital: "Singapore"},
+	{Alpha2: "SK", Alpha3: "SVK", Name: "Slovakia", Capital: "Bratislava"},
+	{Alpha2: "SI", Alpha3: "SVN", Name: "Slovenia", Capital: "Ljubljana"},
+	{Alpha2: "SB", Alpha3: "SLB", Name: "Solomon Islands", Capital: "Honiara"},
+	{Alpha2: "SO", Alpha3: "SOM", Name: "Somalia", Capital: "Mogadishu"},
+	{Alpha2: "ZA", Alpha3: "ZAF", Name: "South Africa", Capital: "Pretoria"},
+	{Alpha2: "SS", Alpha3: "SSD", Name: "South Sudan", Capital: "Juba"},
+	{Alpha2: "ES", Alpha3: "ESP", Name: "Spain", Capital: "Madrid"},
+	{Alpha2: "LK", Alpha3: "LKA", Name: "Sri Lanka", Capital: "Colombo"},
+	{Alpha2: "SD", Alpha3: "SDN", Name: "Sudan", Capital: "Khartoum"},
+	{Alpha2: "SR", Alpha3: "SUR", Name: "Suriname", Capital: "Paramaribo"},
+	{Alpha2: "SE", Alpha3: "SWE", Name: "Sweden", Capital: "Stockholm"},
+	{Alpha2: "CH", Alpha3: "CHE", Name: "Switzerland", Capital: "Bern"},
+	{Alpha2: "SY", Alpha3: "SYR", Name: "Syria", Capital: "Damascus"},
+	{Alpha2: "TW", Alpha3: "TWN", Name: "Taiwan", Capital: "Taipei"},
+	{Alpha2: "TJ", Alpha3: "TJK", Name: "Tajikistan", Capital: "Dushanbe"},
+	{Alpha2: "TZ", Alpha3: "TZA", Name: "Tanzania", Capital: "Dodoma"},
+	{Alpha2: "TH", Alpha3: "THA", Name: "Thailand", Capital: "Bangkok"},
+	{Alpha2: "TL", Alpha3: "TLS", Name: "Timor-Leste", Capital: "Dili"},
+	{Alpha2: "TG", Alpha3: "TGO", Name: "Togo", Capital: "Lomé"},
+	{Alpha2: "TO", Alpha3: "TON", Name: "Tonga", Capital: "Nuku'alofa"},
+	{Alpha2: "TT", Alpha3: "TTO", Name: "Trinidad and Tobago", Capital: "Port of Spain"},
+	{Alpha2: "TN", Alpha3: "TUN", Name: "Tunisia", Capital: "Tunis"},
+	{Alpha2: "TR", Alpha3: "TUR", Name: "Turkey", Capital: "Ankara"},
+	{Alpha2: "TM", Alpha3: "TKM", Name: "Turkmenistan", Capital: "Ashgabat"},
+	{Alpha2: "TV", Alpha3: "TUV", Name: "Tuvalu", Capital: "Funafuti"},
+	{Alpha2: "UG", Alpha3: "UGA", Name: "Uganda", Capital: "Kampala"},
+	{Alpha2: "UA", Alpha3: "UKR", Name: "Ukraine", Capital: "Kyiv"},
+	{Alpha2: "AE", Alpha3: "ARE", Name: "United Arab Emirates", Capital: "Abu Dhabi"},
+	{Alpha2: "GB", Alpha3: "GBR", Name: "United Kingdom", Capital: "London"},
+	{Alpha2: "US", Alpha3: "USA", Name: "United States", Capital: "Washington, D.C."},
+	{Alpha2: "UY", Alpha3: "URY", Name: "Uruguay", Capital: "Montevideo"},
+	{Alpha2: "UZ", Alpha3: "UZB", Name: "Uzbekistan", Capital: "Tashkent"},
+	{Alpha2: "VU", Alpha3: "VUT", Name: "Vanuatu", Capital: "Port Vila"},
+	{Alpha2: "VA", Alpha3: "VAT", Name: "Vatican City", Capital: "Vatican City"},
+	{Alpha2: "VE", Alpha3: "VEN", Name: "Venezuela", Capital: "Caracas"},
+	{Alpha2: "VN", Alpha3: "VNM", Name: "Vietnam", Capital: "Hanoi"},
+	{Alpha2: "YE", Alpha3: "YEM", Name: "Yemen", Capital: "Sana'a"},
+	{Alpha2: "ZM", Alpha3: "ZMB", Name: "Zambia", Capital: "Lusaka"},
+	{Alpha2: "ZW", Alpha3: "ZWE", Name: "Zimbabwe", Capital: "Harare"},
+}
+
+// countryAliases maps other common names to ISO alpha-2 codes
+var countryAliases = map[string]string{
+	"USA":                      "US",
+	"United States of America": "US",
+	"America":                  "US",
+	"UK":                       "GB",
+	"Great Britain":            "GB",
+	"Britain":                  "GB",
+	"England":                  "GB",
+	"Scotland":                 "GB",
+	"Wales":                    "GB",
+	"Northern Ireland":         "GB",
+	"United Kingdom of Great Britain and Northern Ireland": "GB",
+	"UAE":                                    "AE",
+	"Emirates":                               "AE",
+	"Korea, South":                           "KR",
+	"Republic of Korea":                      "KR",
+	"Korea, Republic of":                     "KR",
+	"Korea, North":                           "KP",
+	"Democratic People's Republic of Korea":  "KP",
+	"Korea, Democratic People's Republic of": "KP",
+	"DPRK":                                   "KP",
+	"Russian Federation":                     "RU",
+	"Czechia":                                "CZ",
+	"Cape Verde":                             "CV",
+	"Swaziland":                              "SZ",
+	"Burma":                                  "MM",
+	"Macedonia":                              "MK",
+	"Holland":                                "NL",
+	"The Netherlands":                        "NL",
+	"Côte d'Ivoire":                          "CI",
+	"Congo, Democratic Republic of the":      "CD",
+	"DR Congo":                               "CD",
+	"DRC":                                    "CD",
+	"Congo-Kinshasa":                         "CD",
+	"Congo":                                  "CG",
+	"Congo, Republic of the":                 "CG",
+	"Republic of Congo":                      "CG",
+	"Congo-Brazzaville":                      "CG",
+	"Türkiye":                                "TR",
+	"Holy See":                               "VA",
+	"Vatican":                                "VA",
+	"Lao People's Democratic Republic":       "LA",
+	"Lao PDR":                                "LA",
+	"Syrian Arab Republic":                   "SY",
+	"Iran, Islamic Republic of":              "IR",
+	"Islamic Republic of Iran":               "IR",
+	"Viet Nam":                               "VN",
+	"Tanzania, United Republic of":           "TZ",
+	"United Republic of Tanzania":            "TZ",
+	"Bolivia, Plurinational State of":        "BO",
+	"Venezuela, Bolivarian Republic of":      "VE",
+	"Moldova, Republic of":                   "MD",
+	"Republic of Moldova":                    "MD",
+	"Taiwan, Province of China":              "TW",
+	"Hong Kong SAR":                          "HK",
+	"Macao":                                  "MO",
+	"Macao SAR":                              "MO",
+	"State of Palestine":                     "PS",
+	"Palestine, State of":                    "PS",
+	"Palestinian Territory":                  "PS",
+	"Micronesia, Federated States of":        "FM",
+	"Federated States of Micronesia":         "FM",
+	"East Timor":                             "TL",
+	"Brunei Darussalam":                      "BN",
+	"The Bahamas":                            "BS",
+	"The Gambia":                             "GM",
+	"Bosnia":                                 "BA",
+	"St. Kitts and Nevis":                    "KN",
+	"St. Lucia":                              "LC",
+	"St. Vincent and the Grenadines":         "VC",
+}
+
+// CountryCapitalMap maps ISO alpha-2 codes to capital cities
+var CountryCapitalMap = buildCountryCapitalMap()
+
+// countryIndex maps folded names, aliases and lower case alpha-2 and alpha-3 codes to Countries
+var countryIndex = buildCountryIndex()
+
+func buildCountryCapitalMap() map[string]string {
+	capitals := make(map[string]string, len(Countries))
+	for _, country := range Countries {
+		capitals[country.Alpha2] = country.Capital
+	}
+	return capitals
+}
+
+func buildCountryIndex() map[string]int {
+	index := make(map[string]int)
+	byAlpha2 := make(map[string]int, len(Countries))
+	for i, country := range Countries {
+		byAlpha2[country.Alpha2] = i
+		index[FoldName(country.Name)] = i
+	}
+	for alias, code := range countryAliases {
+		i, ok := byAlpha2[code]
+		if !ok {
+			// The aliases are part of the build, so this only happens if one is mistyped
+			panic(fmt.Sprintf("country alias %q has unknown code %s", alias, code))
+		}
+		index[FoldName(alias)] = i
+	}
+	// Codes go last so no name or alias can shadow one
+	for i, country := range Countries {
+		index[strings.ToLower(country.Alpha2)] = i
+		index[strings.ToLower(country.Alpha3)] = i
+	}
+	return index
+}
+
+// LookupCountry finds a country by ISO alpha-2 or alpha-3 code, name or common alias.
+// Matching ignores case, accents and punctuation but is otherwise exact, so "Niger" never matches Nigeria.
+func LookupCountry(country string) (Country, bool) {
+	if i, ok := countryIndex[FoldName(country)]; ok {
+		return Countries[i], true
+	}
+	return Country{}, false
+}
+
+// CountryCode returns the ISO alpha-2 code for a country code, name or alias, or "" if it is unknown
+func CountryCode(country string) string {
+	if c, ok := LookupCountry(country); ok {
+		return c.Alpha2
+	}
+	return ""
+}
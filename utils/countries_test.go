@@ -0,0 +1,82 @@
+package utils
+
+import (
+	"testing"
+)
+
+func TestLookupCountry(t *testing.T) {
+	testCases := []struct {
+		input string
+		code  string
+	}{
+		{"SD", "SD"},
+		{"sdn", "SD"},
+		{"Sudan", "SD"},
+		{"USA", "US"},
+		{"United States of America", "US"},
+		{"UK", "GB"},
+		{"Korea, South", "KR"},
+		{"Cote d'Ivoire", "CI"},
+		{"Türkiye", "TR"},
+		{"Niger", "NE"},
+		{"NER", "NE"},
+		{"Nigeria", "NG"},
+		{"NG", "NG"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			country, ok := LookupCountry(tc.input)
+			if !ok || country.Alpha2 != tc.code {
+				t.Errorf("Expected %s for %q, but got %q (found %v)", tc.code, tc.input, country.Alpha2, ok)
+			}
+		})
+	}
+
+	if _, ok := LookupCountry("Nige"); ok {
+		t.Errorf("Expected no match for a partial name")
+	}
+}
+
+func TestCountriesHaveUniqueCodes(t *testing.T) {
+	alpha2 := make(map[string]bool)
+	alpha3 := make(map[string]bool)
+	for _, country := range Countries {
+		if len(country.Alpha2) != 2 || len(country.Alpha3) != 3 {
+			t.Errorf("Country %s has malformed codes %s/%s", country.Name, country.Alpha2, country.Alpha3)
+		}
+		if alpha2[country.Alpha2] || alpha3[country.Alpha3] {
+			t.Errorf("Country %s reuses code %s/%s", country.Name, country.Alpha2, country.Alpha3)
+		}
+		alpha2[country.Alpha2] = true
+		alpha3[country.Alpha3] = true
+	}
+	for alias, code := range countryAliases {
+		if !alpha2[code] {
+			t.Errorf("Alias %q points at unknown code %s", alias, code)
+		}
+	}
+}
+
+func TestCommonCitiesCountryCodes(t *testing.T) {
+	for _, city := range CommonCities {
+		if code := CountryCode(city.Country); code != city.CountryCode {
+			t.Errorf("Expected %s for %s, %s, but the country resolves to %q", city.CountryCode, city.Name, city.Country, code)
+		}
+	}
+}
+
+func TestFindCapitalCity(t *testing.T) {
+	city, ok := FindCapitalCity("JM")
+	if !ok || city.Name != "Kingston" || city.CountryCode != "JM" {
+		t.Errorf("Expected Kingston, Jamaica, but got %+v", city)
+	}
+	for _, code := range []string{"HK", "MO", "PS"} {
+		if city, ok := FindCapitalCity(code); !ok || city.CountryCode != code {
+			t.Errorf("Expected the capital of %s, but got %+v", code, city)
+		}
+	}
+	if _, ok := FindCapitalCity("US"); ok {
+		t.Errorf("Expected Washington to be missing from the embedded cities")
+	}
+}
@@ -0,0 +1,287 @@
+package utils
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ErrNoElevationData is returned when no loaded elevation model covers a location
+var ErrNoElevationData = errors.New("no elevation data for this location")
+
+// hgtVoid marks a missing sample in SRTM .hgt files
+const hgtVoid = -32768
+
+// hgtNamePattern matches SRTM tile names such as N45E006, which give the south-west corner
+var hgtNamePattern = regexp.MustCompile(`(?i)^([NS])(\d{2})([EW])(\d{3})`)
+
+// ElevationModel is a raster of terrain heights in meters on a regular latitude/longitude grid
+type ElevationModel struct {
+	north   float64 // latitude of the center of the first row
+	west    float64 // longitude of the center of the first column
+	latStep float64 // degrees between rows, going south
+	lonStep float64 // degrees between columns, going east
+	rows    int
+	cols    int
+	heights []float32 // row-major from the north-west corner, NaN where there is no data
+}
+
+// newElevationModel checks the grid dimensions before building a model
+func newElevationModel(north, west, latStep, lonStep float64, rows, cols int, heights []float32) (*ElevationModel, error) {
+	if rows < 2 || cols < 2 || len(heights) != rows*cols {
+		return nil, fmt.Errorf("invalid elevation grid: %dx%d with %d samples", cols, rows, len(heights))
+	}
+	if !(latStep > 0) || !(lonStep > 0) {
+		return nil, fmt.Errorf("invalid elevation grid spacing: %g, %g", latStep, lonStep)
+	}
+	return &ElevationModel{north: north, west: west, latStep: latStep, lonStep: lonStep, rows: rows, cols: cols, heights: heights}, nil
+}
+
+// Bounds returns the latitudes and longitudes of the outermost sample centers
+func (m *ElevationModel) Bounds() (south, west, north, east float64) {
+	return m.north - float64(m.rows-1)*m.latStep, m.west, m.north, m.west + float64(m.cols-1)*m.lonStep
+}
+
+// ElevationAt interpolates the terrain height at a location, reporting false outside the grid or next to a void
+func (m *ElevationModel) ElevationAt(lat, lon float64) (float64, bool) {
+	row := (m.north - lat) / m.latStep
+	col := (lon - m.west) / m.lonStep
+	if row < 0 || col < 0 || row > float64(m.rows-1) || col > float64(m.cols-1) {
+		return 0, false
+	}
+
+	// Interpolate between the four surrounding samples; the last row and column use the cell before them
+	r0 := min(int(row), m.rows-2)
+	c0 := min(int(col), m.cols-2)
+	fr := row - float64(r0)
+	fc := col - float64(c0)
+
+	samples := [4]struct {
+		index  int
+		weight float64
+	}{
+		{r0*m.cols + c0, (1 - fr) * (1 - fc)},
+		{r0*m.cols + c0 + 1, (1 - fr) * fc},
+		{(r0+1)*m.cols + c0, fr * (1 - fc)},
+		{(r0+1)*m.cols + c0 + 1, fr * fc},
+	}
+	height := 0.0
+	for _, sample := range samples {
+		if sample.weight == 0 {
+			// Exactly on a row or column, so a void on the far side doesn't matter
+			continue
+		}
+		value := float64(m.heights[sample.index])
+		if math.IsNaN(value) {
+			return 0, false
+		}
+		height += value * sample.weight
+	}
+	return height, true
+}
+
+// cellSizeKm is the north-south spacing of the grid
+func (m *ElevationModel) cellSizeKm() float64 {
+	return m.latStep * math.Pi / 180 * EarthRadiusKm
+}
+
+// ParseHGT reads an SRTM .hgt tile: big-endian 16 bit heights on a square 1 or 3 arc second grid.
+// The tile's position comes from its name, such as N45E006.hgt.
+func ParseHGT(name string, data []byte) (*ElevationModel, error) {
+	match := hgtNamePattern.FindStringSubmatch(filepath.Base(name))
+	if match == nil {
+		return nil, fmt.Errorf("cannot tell the location of %s: expected a name like N45E006.hgt", name)
+	}
+	south, _ := strconv.ParseFloat(match[2], 64)
+	west, _ := strconv.ParseFloat(match[4], 64)
+	if strings.EqualFold(match[1], "S") {
+		south = -south
+	}
+	if strings.EqualFold(match[3], "W") {
+		west = -west
+	}
+
+	size := int(math.Round(math.Sqrt(float64(len(data) / 2))))
+	if size < 2 || size*size*2 != len(data) {
+		return nil, fmt.Errorf("%s is not a square grid of 16 bit samples", name)
+	}
+
+	heights := make([]float32, size*size)
+	for i := range heights {
+		value := int16(binary.BigEndian.Uint16(data[i*2:]))
+		if value == hgtVoid {
+			heights[i] = float32(math.NaN())
+		} else {
+			heights[i] = float32(value)
+		}
+	}
+	step := 1 / float64(size-1)
+	return newElevationModel(south+1, west, step, step, size, size, heights)
+}
+
+// Terrain is a set of elevation models, such as neighbouring SRTM tiles
+type Terrain struct {
+	models []*ElevationModel
+}
+
+// NewTerrain combines elevation models; where they overlap the first one wins
+func NewTerrain(models ...*ElevationModel) *Terrain {
+	return &Terrain{models: models}
+}
+
+// Len returns the number of elevation models
+func (t *Terrain) Len() int {
+	return len(t.models)
+}
+
+// ElevationAt returns the terrain height at a location from the first model that covers it
+func (t *Terrain) ElevationAt(lat, lon float64) (float64, bool) {
+	for _, model := range t.models {
+		if height, ok := model.ElevationAt(lat, lon); ok {
+			return height, true
+		}
+	}
+	return 0, false
+}
+
+// modelAt returns the first model with data at a location
+func (t *Terrain) modelAt(lat, lon float64) *ElevationModel {
+	for _, model := range t.models {
+		if _, ok := model.ElevationAt(lat, lon); ok {
+			return model
+		}
+	}
+	return nil
+}
+
+var (
+	terrainMu     sync.RWMutex
+	activeTerrain *Terrain
+)
+
+// DefaultTerrain returns the terrain used to compute horizon profiles, or nil when none is loaded
+func DefaultTerrain() *Terrain {
+	terrainMu.RLock()
+	defer terrainMu.RUnlock()
+	return activeTerrain
+}
+
+// SetTerrain replaces the terrain used to compute horizon profiles
+func SetTerrain(t *Terrain) {
+	terrainMu.Lock()
+	defer terrainMu.Unlock()
+	activeTerrain = t
+}
+
+// LoadTerrain reads an elevation model file, or every .hgt and GeoTIFF file in a directory
+func LoadTerrain(path string) (*Terrain, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open elevation data: %w", err)
+	}
+
+	paths := []string{path}
+	if info.IsDir() {
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read elevation data: %w", err)
+		}
+		paths = nil
+		for _, entry := range entries {
+			switch strings.ToLower(filepath.Ext(entry.Name())) {
+			case ".hgt", ".tif", ".tiff":
+				paths = append(paths, filepath.Join(path, entry.Name()))
+			}
+		}
+	}
+
+	terrain := &Terrain{}
+	for _, file := range paths {
+		model, err := LoadElevationModel(file)
+		if err != nil {
+			return nil, err
+		}
+		terrain.models = append(terrain.models, model)
+	}
+	if terrain.Len() == 0 {
+		return nil, fmt.Errorf("no .hgt or GeoTIFF files found in %s", path)
+	}
+	return terrain, nil
+}
+
+// LoadElevationModel reads an SRTM .hgt file or a GeoTIFF, chosen by the file extension
+func LoadElevationModel(path string) (*ElevationModel, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read elevation data: %w", err)
+	}
+
+	var model *ElevationModel
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".hgt":
+		model, err = ParseHGT(path, data)
+	case ".tif", ".tiff":
+		model, err = ParseGeoTIFF(data)
+	default:
+		return nil, fmt.Errorf("unsupported elevation data format: %s", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	return model, nil
+}
+
+// Defaults for horizon profiles computed from terrain
+const (
+	DefaultHorizonDistanceKm = 20.0 // how far out to look for obstructions
+	horizonAzimuthStep       = 1.0  // degrees between rays
+	minHorizonSampleKm       = 0.01 // smallest distance between samples along a ray
+	terrainRefraction        = 0.13 // coefficient of terrestrial refraction
+)
+
+// ComputeHorizonProfile finds the skyline around an observer standing heightAboveGround meters above the terrain.
+// It follows a ray every degree of azimuth out to maxDistanceKm, allowing for the curvature of the Earth and
+// terrestrial refraction, and keeps the steepest angle to the ground along each ray.
+func (t *Terrain) ComputeHorizonProfile(lat, lon, heightAboveGround, maxDistanceKm float64) (*HorizonProfile, error) {
+	ground, ok := t.ElevationAt(lat, lon)
+	if !ok {
+		return nil, ErrNoElevationData
+	}
+	if maxDistanceKm <= 0 {
+		maxDistanceKm = DefaultHorizonDistanceKm
+	}
+	eye := ground + heightAboveGround
+
+	// Sample at the resolution of the grid under the observer
+	stepKm := math.Max(t.modelAt(lat, lon).cellSizeKm(), minHorizonSampleKm)
+	effectiveRadius := EarthRadiusKm * 1000 / (1 - terrainRefraction)
+
+	points := make([]HorizonPoint, 0, int(360/horizonAzimuthStep))
+	for azimuth := 0.0; azimuth < 360; azimuth += horizonAzimuthStep {
+		maxAngle := math.Inf(-1)
+		for distanceKm := stepKm; distanceKm <= maxDistanceKm; distanceKm += stepKm {
+			pointLat, pointLon := DestinationPoint(lat, lon, azimuth, distanceKm)
+			height, ok := t.ElevationAt(pointLat, pointLon)
+			if !ok {
+				continue
+			}
+			distance := distanceKm * 1000
+			drop := distance * distance / (2 * effectiveRadius)
+			angle := math.Atan2(height-drop-eye, distance) * 180 / math.Pi
+			maxAngle = math.Max(maxAngle, angle)
+		}
+		if math.IsInf(maxAngle, -1) {
+			// No terrain in this direction, such as beyond the edge of the data
+			maxAngle = 0
+		}
+		points = append(points, HorizonPoint{Azimuth: azimuth, Elevation: maxAngle})
+	}
+	return NewHorizonProfile(points)
+}
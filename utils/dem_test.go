@@ -0,0 +1,290 @@
+package utils
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeTestHGT creates an 11x11 .hgt tile rising 10 m per sample eastward, with a void in the middle
+func writeTestHGT(t *testing.T, dir, name string) string {
+	data := make([]byte, 11*11*2)
+	for row := 0; row < 11; row++ {
+		for col := 0; col < 11; col++ {
+			value := int16(col * 10)
+			if row == 5 && col == 5 {
+				value = hgtVoid
+			}
+			binary.BigEndian.PutUint16(data[(row*11+col)*2:], uint16(value))
+		}
+	}
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// testTIFFEntry is one IFD entry for writeTestGeoTIFF: short, long or double values, or ASCII text
+type testTIFFEntry struct {
+	tag     uint16
+	shorts  []uint16
+	longs   []uint32
+	doubles []float64
+	text    string
+}
+
+// buildTestGeoTIFF lays out a little-endian TIFF with the image data first and the directory after it
+func buildTestGeoTIFF(image []byte, entries []testTIFFEntry) []byte {
+	order := binary.LittleEndian
+	out := []byte("II*\x00\x00\x00\x00\x00")
+	out = append(out, image...)
+
+	// Values too large for an entry go after the directory
+	directory := len(out)
+	order.PutUint32(out[4:], uint32(directory))
+	extra := directory + 2 + len(entries)*12 + 4
+
+	var ifd, values []byte
+	ifd = order.AppendUint16(ifd, uint16(len(entries)))
+	for _, entry := range entries {
+		var fieldType uint16
+		var payload []byte
+		count := 0
+		switch {
+		case entry.shorts != nil:
+			fieldType, count = 3, len(entry.shorts)
+			for _, v := range entry.shorts {
+				payload = order.AppendUint16(payload, v)
+			}
+		case entry.longs != nil:
+			fieldType, count = 4, len(entry.longs)
+			for _, v := range entry.longs {
+				payload = order.AppendUint32(payload, v)
+			}
+		case entry.doubles != nil:
+			fieldType, count = 12, len(entry.doubles)
+			for _, v := range entry.doubles {
+				payload = order.AppendUint64(payload, math.Float64bits(v))
+			}
+		default:
+			fieldType = 2
+			payload = append([]byte(entry.text), 0)
+			count = len(payload)
+		}
+
+		ifd = order.AppendUint16(ifd, entry.tag)
+		ifd = order.AppendUint16(ifd, fieldType)
+		ifd = order.AppendUint32(ifd, uint32(count))
+		if len(payload) <= 4 {
+			ifd = append(ifd, append(payload, make([]byte, 4-len(payload))...)...)
+		} else {
+			ifd = order.AppendUint32(ifd, uint32(extra+len(values)))
+			values = append(values, payload...)
+		}
+	}
+	ifd = order.AppendUint32(ifd, 0)
+
+	out = append(out, ifd...)
+	return append(out, values...)
+}
+
+func TestParseHGT(t *testing.T) {
+	path := writeTestHGT(t, t.TempDir(), "S10W020.hgt")
+	model, err := LoadElevationModel(path)
+	if err != nil {
+		t.Fatalf("Expected the tile to load, but got %v", err)
+	}
+
+	if south, west, north, east := model.Bounds(); south != -10 || west != -20 || north != -9 || east != -19 {
+		t.Errorf("Expected bounds -10, -20, -9, -19, but got %g, %g, %g, %g", south, west, north, east)
+	}
+
+	testCases := []struct {
+		lat, lon float64
+		height   float64
+		ok       bool
+	}{
+		{-10, -20, 0, true},
+		{-9, -19, 100, true},
+		{-9.95, -19.75, 25, true}, // between samples
+		{-9.5, -19.5, 0, false},   // the void
+		{-8.5, -19.5, 0, false},   // outside the tile
+	}
+	for _, tc := range testCases {
+		height, ok := model.ElevationAt(tc.lat, tc.lon)
+		if ok != tc.ok || math.Abs(height-tc.height) > 1e-6 {
+			t.Errorf("Expected %g m (%v) at %g, %g, but got %g m (%v)", tc.height, tc.ok, tc.lat, tc.lon, height, ok)
+		}
+	}
+
+	if _, err := ParseHGT("N45E006.hgt", make([]byte, 10)); err == nil {
+		t.Errorf("Expected an error for a truncated tile, but got none")
+	}
+	if _, err := ParseHGT("terrain.hgt", make([]byte, 8)); err == nil {
+		t.Errorf("Expected an error for a tile without a location in its name, but got none")
+	}
+}
+
+func TestParseGeoTIFF(t *testing.T) {
+	// 3x2 int16 image in two strips; the tie point is the north-west corner of the first pixel
+	image := make([]byte, 0, 12)
+	for _, v := range []int16{100, 200, 300, 400, -9999, 600} {
+		image = binary.LittleEndian.AppendUint16(image, uint16(v))
+	}
+	entries := []testTIFFEntry{
+		{tag: tiffImageWidth, shorts: []uint16{3}},
+		{tag: tiffImageLength, shorts: []uint16{2}},
+		{tag: tiffBitsPerSample, shorts: []uint16{16}},
+		{tag: tiffCompression, shorts: []uint16{1}},
+		{tag: tiffStripOffsets, longs: []uint32{8, 14}},
+		{tag: tiffSamplesPerPixel, shorts: []uint16{1}},
+		{tag: tiffRowsPerStrip, shorts: []uint16{1}},
+		{tag: tiffStripByteCounts, longs: []uint32{6, 6}},
+		{tag: tiffSampleFormat, shorts: []uint16{tiffSampleInt}},
+		{tag: geoModelPixelScale, doubles: []float64{0.5, 0.5, 0}},
+		{tag: geoModelTiepoint, doubles: []float64{0, 0, 0, 10, 50, 0}},
+		{tag: geoKeyDirectory, shorts: []uint16{1, 1, 0, 2, geoKeyModelType, 0, 1, geoModelTypeGeodetic, geoKeyRasterType, 0, 1, geoRasterPixelIsArea}},
+		{tag: gdalNoData, text: "-9999"},
+	}
+
+	model, err := ParseGeoTIFF(buildTestGeoTIFF(image, entries))
+	if err != nil {
+		t.Fatalf("Expected the GeoTIFF to parse, but got %v", err)
+	}
+	// Pixel centers are half a pixel in from the corner
+	if south, west, north, east := model.Bounds(); south != 49.25 || west != 10.25 || north != 49.75 || east != 11.25 {
+		t.Errorf("Expected bounds 49.25, 10.25, 49.75, 11.25, but got %g, %g, %g, %g", south, west, north, east)
+	}
+	if height, ok := model.ElevationAt(49.75, 10.5); !ok || height != 150 {
+		t.Errorf("Expected 150 m, but got %g m (%v)", height, ok)
+	}
+	if _, ok := model.ElevationAt(49.25, 10.75); ok {
+		t.Errorf("Expected no data next to the nodata pixel")
+	}
+
+	// Projected rasters and compressed images are rejected
+	projected := append([]testTIFFEntry(nil), entries...)
+	projected[11] = testTIFFEntry{tag: geoKeyDirectory, shorts: []uint16{1, 1, 0, 1, geoKeyModelType, 0, 1, 1}}
+	compressed := append([]testTIFFEntry(nil), entries...)
+	compressed[3] = testTIFFEntry{tag: tiffCompression, shorts: []uint16{5}}
+	for name, invalid := range map[string][]byte{
+		"projected":  buildTestGeoTIFF(image, projected),
+		"compressed": buildTestGeoTIFF(image, compressed),
+		"not a tiff": []byte("GIF89a\x00\x00"),
+	} {
+		if _, err := ParseGeoTIFF(invalid); err == nil {
+			t.Errorf("Expected an error for %s, but got none", name)
+		}
+	}
+}
+
+func TestParseGeoTIFFTiles(t *testing.T) {
+	// 3x3 float32 image in four 2x2 tiles, with the tie point on the first pixel center
+	tiles := [][]float32{
+		{1, 2, 4, 5},
+		{3, 0, 6, 0},
+		{7, 8, 0, 0},
+		{9, 0, 0, 0},
+	}
+	var image []byte
+	var offsets []uint32
+	for _, tile := range tiles {
+		offsets = append(offsets, uint32(8+len(image)))
+		for _, v := range tile {
+			image = binary.LittleEndian.AppendUint32(image, math.Float32bits(v))
+		}
+	}
+	model, err := ParseGeoTIFF(buildTestGeoTIFF(image, []testTIFFEntry{
+		{tag: tiffImageWidth, shorts: []uint16{3}},
+		{tag: tiffImageLength, shorts: []uint16{3}},
+		{tag: tiffBitsPerSample, shorts: []uint16{32}},
+		{tag: tiffTileWidth, shorts: []uint16{2}},
+		{tag: tiffTileLength, shorts: []uint16{2}},
+		{tag: tiffTileOffsets, longs: offsets},
+		{tag: tiffTileByteCounts, longs: []uint32{16, 16, 16, 16}},
+		{tag: tiffSampleFormat, shorts: []uint16{tiffSampleFloat}},
+		{tag: geoModelPixelScale, doubles: []float64{1, 1, 0}},
+		{tag: geoModelTiepoint, doubles: []float64{0, 0, 0, 0, 2, 0}},
+		{tag: geoKeyDirectory, shorts: []uint16{1, 1, 0, 1, geoKeyRasterType, 0, 1, 2}},
+	}))
+	if err != nil {
+		t.Fatalf("Expected the GeoTIFF to parse, but got %v", err)
+	}
+	for i, want := range []float64{1, 2, 3, 4, 5, 6, 7, 8, 9} {
+		lat, lon := float64(2-i/3), float64(i%3)
+		if height, ok := model.ElevationAt(lat, lon); !ok || height != want {
+			t.Errorf("Expected %g m at %g, %g, but got %g m (%v)", want, lat, lon, height, ok)
+		}
+	}
+}
+
+func TestLoadTerrain(t *testing.T) {
+	dir := t.TempDir()
+	writeTestHGT(t, dir, "N45E006.hgt")
+	writeTestHGT(t, dir, "n45e007.HGT")
+	if err := os.WriteFile(filepath.Join(dir, "README.txt"), []byte("not elevation data"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	terrain, err := LoadTerrain(dir)
+	if err != nil {
+		t.Fatalf("Expected the directory to load, but got %v", err)
+	}
+	if terrain.Len() != 2 {
+		t.Errorf("Expected 2 tiles, but got %d", terrain.Len())
+	}
+	if height, ok := terrain.ElevationAt(45.05, 7.5); !ok || height != 50 {
+		t.Errorf("Expected 50 m from the second tile, but got %g m (%v)", height, ok)
+	}
+
+	if _, err := LoadTerrain(t.TempDir()); err == nil {
+		t.Errorf("Expected an error for a directory without elevation data, but got none")
+	}
+	if _, err := LoadTerrain(filepath.Join(dir, "README.txt")); err == nil {
+		t.Errorf("Expected an error for an unsupported file, but got none")
+	}
+}
+
+func TestComputeHorizonProfile(t *testing.T) {
+	// Flat ground at sea level with a 1000 m cliff from longitude 6.25 eastward
+	const size = 401
+	heights := make([]float32, size*size)
+	for row := 0; row < size; row++ {
+		for col := 0; col < size; col++ {
+			if 6.0+float64(col)*0.001 >= 6.25 {
+				heights[row*size+col] = 1000
+			}
+		}
+	}
+	model, err := newElevationModel(45.4, 6.0, 0.001, 0.001, size, size, heights)
+	if err != nil {
+		t.Fatal(err)
+	}
+	terrain := NewTerrain(model)
+
+	profile, err := terrain.ComputeHorizonProfile(45.2, 6.2, 2, 0)
+	if err != nil {
+		t.Fatalf("Expected a profile, but got %v", err)
+	}
+	if len(profile.Points()) != 360 {
+		t.Errorf("Expected a point every degree, but got %d", len(profile.Points()))
+	}
+
+	distance := GreatCircleDistance(45.2, 6.2, 45.2, 6.25) * 1000
+	cliff := math.Atan(1000/distance) * 180 / math.Pi
+	if east := profile.ElevationAt(90); math.Abs(east-cliff) > 1.5 {
+		t.Errorf("Expected the cliff %.1f° above the eastern horizon, but got %.1f°", cliff, east)
+	}
+	// Looking west over flat ground the horizon dips just below level
+	if west := profile.ElevationAt(270); west >= 0 || west < -1 {
+		t.Errorf("Expected the western horizon just below 0°, but got %.2f°", west)
+	}
+
+	if _, err := terrain.ComputeHorizonProfile(47, 6.2, 2, 0); !errors.Is(err, ErrNoElevationData) {
+		t.Errorf("Expected ErrNoElevationData outside the terrain, but got %v", err)
+	}
+}
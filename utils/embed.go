@@ -0,0 +1,13 @@
+package utils
+
+import (
+	_ "embed"
+)
+
+// timeZoneBoundariesGeoJSON holds simplified time zone boundaries in the
+// timezone-boundary-builder GeoJSON format (a feature per zone with a tzid property).
+// The data is the 2025b release with oceans (ODbL), reduced with Douglas-Peucker at
+// 0.01° and without the Etc/* ocean zones, which NauticalTimeZone covers
+//
+//go:embed data/timezones.geojson
+var timeZoneBoundariesGeoJSON []byte
@@ -0,0 +1,259 @@
+package utils
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Gazetteer is an in-memory set of cities indexed by accent-insensitive name
+type Gazetteer struct {
+	cities []City
+	folded []string         // folded name of each city
+	byName map[string][]int // folded name -> city indexes, most populous first
+
+	treeOnce sync.Once
+	tree     *kdNode // spatial index for reverse geocoding, built on first use
+}
+
+var (
+	gazetteerMu     sync.RWMutex
+	activeGazetteer = NewGazetteer(CommonCities)
+)
+
+// NewGazetteer indexes a copy of a list of cities, filling in missing country codes from the country names
+func NewGazetteer(cities []City) *Gazetteer {
+	// Copy so that filling in codes never writes to the caller's slice, such as CommonCities
+	cities = append([]City(nil), cities...)
+	g := &Gazetteer{
+		cities: cities,
+		folded: make([]string, len(cities)),
+		byName: make(map[string][]int, len(cities)),
+	}
+	for i, city := range cities {
+		if city.CountryCode == "" {
+			cities[i].CountryCode = CountryCode(city.Country)
+		}
+		g.folded[i] = FoldName(city.Name)
+		g.byName[g.folded[i]] = append(g.byName[g.folded[i]], i)
+	}
+	for _, indexes := range g.byName {
+		sort.SliceStable(indexes, func(a, b int) bool {
+			return cities[indexes[a]].Population > cities[indexes[b]].Population
+		})
+	}
+	return g
+}
+
+// DefaultGazetteer returns the gazetteer used by the package-level lookups
+func DefaultGazetteer() *Gazetteer {
+	gazetteerMu.RLock()
+	defer gazetteerMu.RUnlock()
+	return activeGazetteer
+}
+
+// SetGazetteer replaces the gazetteer used by the package-level lookups
+func SetGazetteer(g *Gazetteer) {
+	gazetteerMu.Lock()
+	defer gazetteerMu.Unlock()
+	activeGazetteer = g
+}
+
+// Cities returns every city in the gazetteer
+func (g *Gazetteer) Cities() []City {
+	return g.cities
+}
+
+// Len returns the number of cities in the gazetteer
+func (g *Gazetteer) Len() int {
+	return len(g.cities)
+}
+
+// FindCity returns the city with the given name, ignoring case and accents.
+// When several cities share the name the most populous one wins.
+func (g *Gazetteer) FindCity(name string) (City, bool) {
+	if indexes := g.byName[FoldName(name)]; len(indexes) > 0 {
+		return g.cities[indexes[0]], true
+	}
+	return City{}, false
+}
+
+// FindCityInCountry returns the most populous city with the given name in a country,
+// identified by its ISO alpha-2 code
+func (g *Gazetteer) FindCityInCountry(name, countryCode string) (City, bool) {
+	for _, i := range g.byName[FoldName(name)] {
+		if g.cities[i].CountryCode == countryCode {
+			return g.cities[i], true
+		}
+	}
+	return City{}, false
+}
+
+// Countries returns the unique country names in the order they first appear
+func (g *Gazetteer) Countries() []string {
+	seen := make(map[string]bool)
+	var countries []string
+	for _, city := range g.cities {
+		if !seen[city.Country] {
+			seen[city.Country] = true
+			countries = append(countries, city.Country)
+		}
+	}
+	return countries
+}
+
+// CitiesByCountry returns the cities in the given country, named by ISO code, name or alias
+func (g *Gazetteer) CitiesByCountry(country string) []City {
+	var cities []City
+	for _, city := range g.cities {
+		if g.inCountry(city, country, CountryCode(country)) {
+			cities = append(cities, city)
+		}
+	}
+	return cities
+}
+
+// inCountry reports whether a city is in a country, comparing ISO codes when the country is known
+// and falling back to the country name otherwise
+func (g *Gazetteer) inCountry(city City, country, countryCode string) bool {
+	if countryCode != "" {
+		return city.CountryCode == countryCode
+	}
+	return FoldName(city.Country) == FoldName(country)
+}
+
+// LoadGeoNamesFile reads a GeoNames cities dump (cities500, cities5000, cities15000 or allCountries format).
+// countryInfoPath optionally names a GeoNames countryInfo.txt used to turn country codes into names.
+func LoadGeoNamesFile(path, countryInfoPath string) (*Gazetteer, error) {
+	var countryNames map[string]string
+	if countryInfoPath != "" {
+		file, err := os.Open(countryInfoPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open country info: %w", err)
+		}
+		defer file.Close()
+		countryNames, err = ParseGeoNamesCountryInfo(file)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open cities file: %w", err)
+	}
+	defer file.Close()
+
+	cities, err := ParseGeoNamesCities(file, countryNames)
+	if err != nil {
+		return nil, err
+	}
+	if len(cities) == 0 {
+		return nil, fmt.Errorf("no cities found in %s", path)
+	}
+	return NewGazetteer(cities), nil
+}
+
+// ParseGeoNamesCities parses the tab-separated GeoNames geoname table. Country names come from
+// countryNames when given, then from the embedded cities and the ISO country table, and otherwise the code is used.
+func ParseGeoNamesCities(r io.Reader, countryNames map[string]string) ([]City, error) {
+	knownNames := make(map[string]string)
+	for _, city := range CommonCities {
+		knownNames[city.CountryCode] = city.Country
+	}
+
+	var cities []City
+	scanner := bufio.NewScanner(r)
+	// Lines with long alternate name lists exceed the default 64 KB limit
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := scanner.Text()
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		if len(fields) < 18 {
+			return nil, fmt.Errorf("line %d: expected at least 18 columns, got %d", lineNumber, len(fields))
+		}
+
+		geoNameID, err := strconv.Atoi(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid geonameid: %w", lineNumber, err)
+		}
+		latitude, err := strconv.ParseFloat(fields[4], 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid latitude: %w", lineNumber, err)
+		}
+		longitude, err := strconv.ParseFloat(fields[5], 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid longitude: %w", lineNumber, err)
+		}
+		population, _ := strconv.ParseInt(fields[14], 10, 64)
+
+		// Prefer the surveyed elevation, then the digital elevation model (-9999 means no data)
+		elevation, err := strconv.ParseFloat(fields[15], 64)
+		if err != nil {
+			if dem, demErr := strconv.ParseFloat(fields[16], 64); demErr == nil && dem != -9999 {
+				elevation = dem
+			}
+		}
+
+		countryCode := fields[8]
+		country := countryNames[countryCode]
+		if country == "" {
+			country = knownNames[countryCode]
+		}
+		if c, ok := LookupCountry(countryCode); country == "" && ok {
+			country = c.Name
+		}
+		if country == "" {
+			country = countryCode
+		}
+
+		cities = append(cities, City{
+			Name:        fields[1],
+			Latitude:    latitude,
+			Longitude:   longitude,
+			Country:     country,
+			TimeZone:    fields[17],
+			CountryCode: countryCode,
+			Region:      fields[10],
+			Population:  population,
+			Elevation:   elevation,
+			GeoNameID:   geoNameID,
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read cities: %w", err)
+	}
+	return cities, nil
+}
+
+// ParseGeoNamesCountryInfo reads GeoNames countryInfo.txt and returns country names by ISO alpha-2 code
+func ParseGeoNamesCountryInfo(r io.Reader) (map[string]string, error) {
+	names := make(map[string]string)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		if len(fields) < 5 {
+			continue
+		}
+		names[fields[0]] = fields[4]
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read country info: %w", err)
+	}
+	return names, nil
+}
@@ -0,0 +1,140 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testGeoNamesCities is a few rows of the GeoNames geoname table; the Canadian London has no elevation
+const testGeoNamesCities = "2172517\tCanberra\tCanberra\t\t-35.28346\t149.12807\tP\tPPLC\tAU\t\t01\t\t\t\t367752\t\t576\tAustralia/Sydney\t2020-05-19\n" +
+	"2643743\tLondon\tLondon\tLondres,Londra\t51.50853\t-0.12574\tP\tPPLC\tGB\t\tENG\tGLA\t\t\t8961989\t25\t25\tEurope/London\t2023-01-12\n" +
+	"6058560\tLondon\tLondon\t\t42.98339\t-81.23304\tP\tPPLA2\tCA\t\t08\t\t\t\t422324\t\t252\tAmerica/Toronto\t2019-08-18\n" +
+	"6094817\tOttawa\tOttawa\t\t45.41117\t-75.69812\tP\tPPLC\tCA\t\t08\t\t\t\t1017449\t\t71\tAmerica/Toronto\t2022-01-18\n"
+
+const testGeoNamesCountryInfo = "#ISO\tISO3\tISO-Numeric\tfips\tCountry\tCapital\n" +
+	"AU\tAUS\t036\tAS\tAustralia\tCanberra\n" +
+	"CA\tCAN\t124\tCA\tCanada\tOttawa\n"
+
+func TestParseGeoNamesCities(t *testing.T) {
+	countryNames, err := ParseGeoNamesCountryInfo(strings.NewReader(testGeoNamesCountryInfo))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cities, err := ParseGeoNamesCities(strings.NewReader(testGeoNamesCities), countryNames)
+	if err != nil {
+		t.Fatalf("Expected the sample to parse, but got %v", err)
+	}
+	if len(cities) != 4 {
+		t.Fatalf("Expected 4 cities, but got %d", len(cities))
+	}
+
+	testCases := []struct {
+		index     int
+		name      string
+		country   string
+		code      string
+		region    string
+		timeZone  string
+		elevation float64
+	}{
+		{0, "Canberra", "Australia", "AU", "01", "Australia/Sydney", 576},
+		{1, "London", "UK", "GB", "ENG", "Europe/London", 25},
+		{2, "London", "Canada", "CA", "08", "America/Toronto", 252},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.country, func(t *testing.T) {
+			city := cities[tc.index]
+			if city.Name != tc.name || city.Country != tc.country || city.CountryCode != tc.code || city.Region != tc.region {
+				t.Errorf("Expected %s, %s (%s) region %s, but got %s, %s (%s) region %s",
+					tc.name, tc.country, tc.code, tc.region, city.Name, city.Country, city.CountryCode, city.Region)
+			}
+			if city.TimeZone != tc.timeZone {
+				t.Errorf("Expected time zone %s, but got %s", tc.timeZone, city.TimeZone)
+			}
+			if city.Elevation != tc.elevation {
+				t.Errorf("Expected elevation %v, but got %v", tc.elevation, city.Elevation)
+			}
+		})
+	}
+
+	if cities[0].Population != 367752 || cities[0].GeoNameID != 2172517 {
+		t.Errorf("Expected population 367752 and id 2172517, but got %d and %d", cities[0].Population, cities[0].GeoNameID)
+	}
+
+	if _, err := ParseGeoNamesCities(strings.NewReader("123\tNowhere\n"), nil); err == nil {
+		t.Errorf("Expected an error for a short line, but got none")
+	}
+}
+
+func TestGazetteerFindCityPrefersPopulous(t *testing.T) {
+	cities, err := ParseGeoNamesCities(strings.NewReader(testGeoNamesCities), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gazetteer := NewGazetteer(cities)
+
+	city, ok := gazetteer.FindCity("london")
+	if !ok || city.CountryCode != "GB" {
+		t.Errorf("Expected London, GB, but got %+v", city)
+	}
+
+	result := gazetteer.Search(CitySearchOptions{Query: "London"})
+	if result.Total != 2 || result.Cities[0].CountryCode != "GB" || result.Cities[1].CountryCode != "CA" {
+		t.Errorf("Expected London, GB before London, CA, but got %+v", result.Cities)
+	}
+
+	result = gazetteer.Search(CitySearchOptions{Query: "London", Country: "ca"})
+	if result.Total != 1 || result.Cities[0].CountryCode != "CA" {
+		t.Errorf("Expected only London, CA when filtering by country code, but got %+v", result.Cities)
+	}
+}
+
+func TestNewGazetteerLeavesCitiesUnchanged(t *testing.T) {
+	cities := []City{{Name: "Khartoum", Latitude: 15.5007, Longitude: 32.5599, Country: "Sudan", TimeZone: "Africa/Khartoum"}}
+	gazetteer := NewGazetteer(cities)
+
+	if city, ok := gazetteer.FindCity("Khartoum"); !ok || city.CountryCode != "SD" {
+		t.Errorf("Expected the country code to be filled in, but got %+v", city)
+	}
+	if cities[0].CountryCode != "" {
+		t.Errorf("Expected the caller's cities to be left unchanged, but got country code %q", cities[0].CountryCode)
+	}
+}
+
+func TestLoadGeoNamesFile(t *testing.T) {
+	dir := t.TempDir()
+	citiesPath := filepath.Join(dir, "cities15000.txt")
+	countryInfoPath := filepath.Join(dir, "countryInfo.txt")
+	if err := os.WriteFile(citiesPath, []byte(testGeoNamesCities), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(countryInfoPath, []byte(testGeoNamesCountryInfo), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	gazetteer, err := LoadGeoNamesFile(citiesPath, countryInfoPath)
+	if err != nil {
+		t.Fatalf("Expected the files to load, but got %v", err)
+	}
+
+	if _, ok := FindCity("Canberra"); ok {
+		t.Fatalf("Expected Canberra to be missing from the embedded cities")
+	}
+	SetGazetteer(gazetteer)
+	defer SetGazetteer(NewGazetteer(CommonCities))
+
+	city, ok := FindCity("Canberra")
+	if !ok || city.Country != "Australia" {
+		t.Errorf("Expected Canberra, Australia from the loaded gazetteer, but got %+v", city)
+	}
+	if countries := GetCountries(); len(countries) != 3 {
+		t.Errorf("Expected 3 countries, but got %v", countries)
+	}
+
+	if _, err := LoadGeoNamesFile(filepath.Join(dir, "missing.txt"), ""); err == nil {
+		t.Errorf("Expected an error for a missing file, but got none")
+	}
+}
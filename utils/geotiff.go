@@ -0,0 +1,295 @@
+package utils
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// TIFF and GeoTIFF tags read by ParseGeoTIFF
+const (
+	tiffImageWidth      = 256
+	tiffImageLength     = 257
+	tiffBitsPerSample   = 258
+	tiffCompression     = 259
+	tiffStripOffsets    = 273
+	tiffSamplesPerPixel = 277
+	tiffRowsPerStrip    = 278
+	tiffStripByteCounts = 279
+	tiffTileWidth       = 322
+	tiffTileLength      = 323
+	tiffTileOffsets     = 324
+	tiffTileByteCounts  = 325
+	tiffSampleFormat    = 339
+	geoModelPixelScale  = 33550
+	geoModelTiepoint    = 33922
+	geoKeyDirectory     = 34735
+	gdalNoData          = 42113
+)
+
+// GeoTIFF keys and values from the GeoKeyDirectory
+const (
+	geoKeyModelType      = 1024
+	geoKeyRasterType     = 1025
+	geoModelTypeGeodetic = 2
+	geoRasterPixelIsArea = 1
+)
+
+// TIFF sample formats
+const (
+	tiffSampleUint  = 1
+	tiffSampleInt   = 2
+	tiffSampleFloat = 3
+)
+
+// tiffTypeSizes are the sizes in bytes of the TIFF field types, indexed by type
+var tiffTypeSizes = [...]int{0, 1, 1, 2, 4, 8, 1, 1, 2, 4, 8, 4, 8}
+
+// tiffField is a decoded IFD entry: numbers for numeric types, text for ASCII
+type tiffField struct {
+	values []float64
+	text   string
+}
+
+// first returns the first value of a field, or fallback when it is missing
+func (f tiffField) first(fallback float64) float64 {
+	if len(f.values) == 0 {
+		return fallback
+	}
+	return f.values[0]
+}
+
+// ParseGeoTIFF reads a single band, uncompressed GeoTIFF elevation model in latitude and longitude,
+// such as SRTM or Copernicus DEM tiles. Strips and tiles of 8 to 64 bit integers or floats are supported.
+func ParseGeoTIFF(data []byte) (*ElevationModel, error) {
+	if len(data) < 8 {
+		return nil, fmt.Errorf("not a TIFF file")
+	}
+	var order binary.ByteOrder
+	switch string(data[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return nil, fmt.Errorf("not a TIFF file")
+	}
+	if order.Uint16(data[2:]) != 42 {
+		return nil, fmt.Errorf("unsupported TIFF version (BigTIFF is not supported)")
+	}
+
+	fields, err := readTIFFDirectory(data, order, order.Uint32(data[4:]))
+	if err != nil {
+		return nil, err
+	}
+
+	width := int(fields[tiffImageWidth].first(0))
+	height := int(fields[tiffImageLength].first(0))
+	bits := int(fields[tiffBitsPerSample].first(1))
+	format := int(fields[tiffSampleFormat].first(tiffSampleUint))
+	if compression := fields[tiffCompression].first(1); compression != 1 {
+		return nil, fmt.Errorf("compressed GeoTIFFs are not supported (compression %g)", compression)
+	}
+	if samples := fields[tiffSamplesPerPixel].first(1); samples != 1 {
+		return nil, fmt.Errorf("expected a single band, got %g", samples)
+	}
+	decode, err := tiffSampleDecoder(order, bits, format)
+	if err != nil {
+		return nil, err
+	}
+
+	heights := make([]float32, width*height)
+	for i := range heights {
+		heights[i] = float32(math.NaN())
+	}
+	bytesPerSample := bits / 8
+
+	// readBlock copies a strip or tile of blockWidth x blockHeight samples with its top-left corner at x, y
+	readBlock := func(offset float64, x, y, blockWidth, blockHeight int) error {
+		start := int(offset)
+		end := start + blockWidth*blockHeight*bytesPerSample
+		if start < 0 || end > len(data) {
+			return fmt.Errorf("image data beyond the end of the file")
+		}
+		for row := 0; row < blockHeight && y+row < height; row++ {
+			for col := 0; col < blockWidth && x+col < width; col++ {
+				sample := start + (row*blockWidth+col)*bytesPerSample
+				heights[(y+row)*width+x+col] = float32(decode(data[sample:]))
+			}
+		}
+		return nil
+	}
+
+	if offsets := fields[tiffTileOffsets].values; len(offsets) > 0 {
+		tileWidth := int(fields[tiffTileWidth].first(0))
+		tileHeight := int(fields[tiffTileLength].first(0))
+		if tileWidth <= 0 || tileHeight <= 0 {
+			return nil, fmt.Errorf("invalid tile size")
+		}
+		across := (width + tileWidth - 1) / tileWidth
+		for i, offset := range offsets {
+			if err := readBlock(offset, i%across*tileWidth, i/across*tileHeight, tileWidth, tileHeight); err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		rowsPerStrip := int(fields[tiffRowsPerStrip].first(float64(height)))
+		if rowsPerStrip <= 0 {
+			return nil, fmt.Errorf("invalid rows per strip")
+		}
+		for i, offset := range fields[tiffStripOffsets].values {
+			// The last strip may be shorter
+			rows := min(rowsPerStrip, height-i*rowsPerStrip)
+			if err := readBlock(offset, 0, i*rowsPerStrip, width, rows); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if noData, err := strconv.ParseFloat(strings.TrimSpace(fields[gdalNoData].text), 32); err == nil {
+		for i, value := range heights {
+			if value == float32(noData) {
+				heights[i] = float32(math.NaN())
+			}
+		}
+	}
+
+	return geoTIFFModel(fields, width, height, heights)
+}
+
+// geoTIFFModel places the raster on the globe using the tie point and pixel scale
+func geoTIFFModel(fields map[uint16]tiffField, width, height int, heights []float32) (*ElevationModel, error) {
+	scale := fields[geoModelPixelScale].values
+	tiepoint := fields[geoModelTiepoint].values
+	if len(scale) < 2 || len(tiepoint) < 6 {
+		return nil, fmt.Errorf("missing GeoTIFF tie point or pixel scale")
+	}
+
+	// The key directory is a header of four values followed by (key, location, count, value) entries
+	modelType, rasterType := geoModelTypeGeodetic, geoRasterPixelIsArea
+	keys := fields[geoKeyDirectory].values
+	for i := 4; i+3 < len(keys); i += 4 {
+		if keys[i+1] != 0 {
+			// Stored in another tag, which none of the keys used here are
+			continue
+		}
+		switch keys[i] {
+		case geoKeyModelType:
+			modelType = int(keys[i+3])
+		case geoKeyRasterType:
+			rasterType = int(keys[i+3])
+		}
+	}
+	if modelType != geoModelTypeGeodetic {
+		return nil, fmt.Errorf("only GeoTIFFs in latitude and longitude are supported")
+	}
+
+	lonStep, latStep := scale[0], scale[1]
+	west := tiepoint[3] - tiepoint[0]*lonStep
+	north := tiepoint[4] + tiepoint[1]*latStep
+	if rasterType == geoRasterPixelIsArea {
+		// The tie point is the corner of a pixel rather than its center
+		west += lonStep / 2
+		north -= latStep / 2
+	}
+	return newElevationModel(north, west, latStep, lonStep, height, width, heights)
+}
+
+// readTIFFDirectory decodes the entries of the first image file directory
+func readTIFFDirectory(data []byte, order binary.ByteOrder, offset uint32) (map[uint16]tiffField, error) {
+	if int(offset)+2 > len(data) {
+		return nil, fmt.Errorf("invalid TIFF directory offset")
+	}
+	count := int(order.Uint16(data[offset:]))
+	entries := int(offset) + 2
+	if entries+count*12 > len(data) {
+		return nil, fmt.Errorf("TIFF directory beyond the end of the file")
+	}
+
+	fields := make(map[uint16]tiffField, count)
+	for i := 0; i < count; i++ {
+		entry := data[entries+i*12:]
+		tag := order.Uint16(entry)
+		fieldType := int(order.Uint16(entry[2:]))
+		valueCount := int(order.Uint32(entry[4:]))
+		if fieldType <= 0 || fieldType >= len(tiffTypeSizes) {
+			// Unknown types can be skipped
+			continue
+		}
+
+		// Values of up to four bytes are stored in the entry itself
+		size := valueCount * tiffTypeSizes[fieldType]
+		value := entry[8:12]
+		if size > 4 {
+			start := int(order.Uint32(entry[8:]))
+			if start < 0 || size < 0 || start+size > len(data) {
+				return nil, fmt.Errorf("TIFF tag %d beyond the end of the file", tag)
+			}
+			value = data[start : start+size]
+		}
+
+		var field tiffField
+		if fieldType == 2 {
+			field.text = strings.TrimRight(string(value[:size]), "\x00")
+		} else {
+			field.values = make([]float64, valueCount)
+			for j := range field.values {
+				field.values[j] = tiffValue(order, fieldType, value[j*tiffTypeSizes[fieldType]:])
+			}
+		}
+		fields[tag] = field
+	}
+	return fields, nil
+}
+
+// tiffValue decodes one value of a numeric TIFF field type
+func tiffValue(order binary.ByteOrder, fieldType int, b []byte) float64 {
+	switch fieldType {
+	case 1, 7:
+		return float64(b[0])
+	case 3:
+		return float64(order.Uint16(b))
+	case 4:
+		return float64(order.Uint32(b))
+	case 5:
+		return float64(order.Uint32(b)) / float64(order.Uint32(b[4:]))
+	case 6:
+		return float64(int8(b[0]))
+	case 8:
+		return float64(int16(order.Uint16(b)))
+	case 9:
+		return float64(int32(order.Uint32(b)))
+	case 10:
+		return float64(int32(order.Uint32(b))) / float64(int32(order.Uint32(b[4:])))
+	case 11:
+		return float64(math.Float32frombits(order.Uint32(b)))
+	case 12:
+		return math.Float64frombits(order.Uint64(b))
+	}
+	return 0
+}
+
+// tiffSampleDecoder returns a function reading one pixel of the given size and sample format
+func tiffSampleDecoder(order binary.ByteOrder, bits, format int) (func([]byte) float64, error) {
+	switch {
+	case format == tiffSampleUint && bits == 8:
+		return func(b []byte) float64 { return float64(b[0]) }, nil
+	case format == tiffSampleInt && bits == 8:
+		return func(b []byte) float64 { return float64(int8(b[0])) }, nil
+	case format == tiffSampleUint && bits == 16:
+		return func(b []byte) float64 { return float64(order.Uint16(b)) }, nil
+	case format == tiffSampleInt && bits == 16:
+		return func(b []byte) float64 { return float64(int16(order.Uint16(b))) }, nil
+	case format == tiffSampleUint && bits == 32:
+		return func(b []byte) float64 { return float64(order.Uint32(b)) }, nil
+	case format == tiffSampleInt && bits == 32:
+		return func(b []byte) float64 { return float64(int32(order.Uint32(b))) }, nil
+	case format == tiffSampleFloat && bits == 32:
+		return func(b []byte) float64 { return float64(math.Float32frombits(order.Uint32(b))) }, nil
+	case format == tiffSampleFloat && bits == 64:
+		return func(b []byte) float64 { return math.Float64frombits(order.Uint64(b)) }, nil
+	}
+	return nil, fmt.Errorf("unsupported sample type: %d bit, format %d", bits, format)
+}
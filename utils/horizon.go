@@ -0,0 +1,207 @@
+package utils
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HorizonPoint is the elevation angle of the skyline in one direction
+type HorizonPoint struct {
+	Azimuth   float64 `json:"azimuth"`   // degrees eastward from north
+	Elevation float64 `json:"elevation"` // degrees above the astronomical horizon
+}
+
+// HorizonProfile is the skyline around an observer, such as valley sides or buildings.
+// Elevations between the given azimuths are interpolated linearly, wrapping through north.
+type HorizonProfile struct {
+	points []HorizonPoint // sorted by azimuth, azimuths in [0, 360)
+}
+
+// NewHorizonProfile checks and sorts the points of a horizon profile
+func NewHorizonProfile(points []HorizonPoint) (*HorizonProfile, error) {
+	if len(points) == 0 {
+		return nil, fmt.Errorf("horizon profile has no points")
+	}
+
+	sorted := make([]HorizonPoint, 0, len(points))
+	seen := make(map[float64]bool, len(points))
+	for _, point := range points {
+		if !(point.Azimuth >= 0 && point.Azimuth <= 360) {
+			return nil, fmt.Errorf("azimuth out of range: %g", point.Azimuth)
+		}
+		if !(point.Elevation >= -90 && point.Elevation <= 90) {
+			return nil, fmt.Errorf("elevation out of range: %g", point.Elevation)
+		}
+		// 360° is north again
+		point.Azimuth = math.Mod(point.Azimuth, 360)
+		if seen[point.Azimuth] {
+			return nil, fmt.Errorf("duplicate azimuth: %g", point.Azimuth)
+		}
+		seen[point.Azimuth] = true
+		sorted = append(sorted, point)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Azimuth < sorted[j].Azimuth })
+	return &HorizonProfile{points: sorted}, nil
+}
+
+// FlatHorizon returns a profile at 0° in every direction
+func FlatHorizon() *HorizonProfile {
+	return &HorizonProfile{points: []HorizonPoint{{Azimuth: 0, Elevation: 0}}}
+}
+
+// Points returns the points of the profile sorted by azimuth
+func (h *HorizonProfile) Points() []HorizonPoint {
+	return h.points
+}
+
+// ElevationAt returns the elevation of the skyline at an azimuth in degrees
+func (h *HorizonProfile) ElevationAt(azimuth float64) float64 {
+	azimuth = limitDegrees(azimuth)
+	n := len(h.points)
+	if n == 1 {
+		return h.points[0].Elevation
+	}
+
+	// The first point at or after the azimuth, and the one before it, wrapping around north
+	i := sort.Search(n, func(i int) bool { return h.points[i].Azimuth >= azimuth })
+	next := h.points[i%n]
+	previous := h.points[(i+n-1)%n]
+
+	span := limitDegrees(next.Azimuth - previous.Azimuth)
+	if span == 0 {
+		return next.Elevation
+	}
+	fraction := limitDegrees(azimuth-previous.Azimuth) / span
+	return previous.Elevation + fraction*(next.Elevation-previous.Elevation)
+}
+
+// ParseHorizonCSV reads a horizon profile with one "azimuth,elevation" pair per line.
+// Commas, semicolons, tabs or spaces separate the values; a header line and lines starting with # are skipped.
+func ParseHorizonCSV(r io.Reader) (*HorizonProfile, error) {
+	var points []HorizonPoint
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.FieldsFunc(line, func(r rune) bool {
+			return r == ',' || r == ';' || r == '\t' || r == ' '
+		})
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("line %d: expected azimuth and elevation", lineNumber)
+		}
+		azimuth, errAzimuth := strconv.ParseFloat(fields[0], 64)
+		elevation, errElevation := strconv.ParseFloat(fields[1], 64)
+		if errAzimuth != nil || errElevation != nil {
+			if len(points) == 0 && lineNumber == 1 {
+				// Column names
+				continue
+			}
+			return nil, fmt.Errorf("line %d: invalid number", lineNumber)
+		}
+		points = append(points, HorizonPoint{Azimuth: azimuth, Elevation: elevation})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read horizon profile: %w", err)
+	}
+	return NewHorizonProfile(points)
+}
+
+// ParseHorizonJSON reads a horizon profile from a JSON array of {"azimuth": …, "elevation": …} objects
+func ParseHorizonJSON(r io.Reader) (*HorizonProfile, error) {
+	var points []HorizonPoint
+	if err := json.NewDecoder(r).Decode(&points); err != nil {
+		return nil, fmt.Errorf("invalid horizon profile: %w", err)
+	}
+	return NewHorizonProfile(points)
+}
+
+// DirectSunPeriod is a stretch of time when the sun is above the skyline
+type DirectSunPeriod struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// HorizonDaylight describes when the sun clears a horizon profile on one day
+type HorizonDaylight struct {
+	FirstLight time.Time         // zero when the sun never clears the skyline
+	LastLight  time.Time         // zero when the sun never clears the skyline
+	DirectSun  time.Duration     // total time the sun is above the skyline
+	Periods    []DirectSunPeriod // the sun can dip behind a peak or a building and reappear
+}
+
+// horizonScanStep is how often the sun is checked against the skyline
+const horizonScanStep = time.Minute
+
+// CalculateHorizonDaylight finds when the sun is above a horizon profile during the local day of date.
+// The sun counts as visible while its upper limb is above the skyline, matching the flat-horizon sunrise.
+func CalculateHorizonDaylight(algorithm Algorithm, observer Observer, profile *HorizonProfile, date time.Time) HorizonDaylight {
+	visible := func(t time.Time) bool {
+		altitude, azimuth := CalculateSunPositionForObserver(algorithm, observer, t)
+		return altitude+spaSunRadius > profile.ElevationAt(azimuth)
+	}
+
+	year, month, day := date.Date()
+	start := time.Date(year, month, day, 0, 0, 0, 0, date.Location())
+	end := time.Date(year, month, day+1, 0, 0, 0, 0, date.Location())
+
+	var daylight HorizonDaylight
+	var periodStart time.Time
+	wasVisible := visible(start)
+	if wasVisible {
+		periodStart = start
+	}
+	for t := start; t.Before(end); {
+		next := t.Add(horizonScanStep)
+		if next.After(end) {
+			next = end
+		}
+		isVisible := visible(next)
+		if isVisible != wasVisible {
+			crossing := bisectVisibility(visible, t, next, wasVisible)
+			if isVisible {
+				periodStart = crossing
+			} else {
+				daylight.Periods = append(daylight.Periods, DirectSunPeriod{Start: periodStart, End: crossing})
+			}
+		}
+		wasVisible = isVisible
+		t = next
+	}
+	if wasVisible {
+		daylight.Periods = append(daylight.Periods, DirectSunPeriod{Start: periodStart, End: end})
+	}
+
+	for _, period := range daylight.Periods {
+		daylight.DirectSun += period.End.Sub(period.Start)
+	}
+	if len(daylight.Periods) > 0 {
+		daylight.FirstLight = daylight.Periods[0].Start
+		daylight.LastLight = daylight.Periods[len(daylight.Periods)-1].End
+	}
+	return daylight
+}
+
+// bisectVisibility narrows a change of visibility between a and b down to a second
+func bisectVisibility(visible func(time.Time) bool, a, b time.Time, visibleAtA bool) time.Time {
+	for b.Sub(a) > time.Second {
+		mid := a.Add(b.Sub(a) / 2)
+		if visible(mid) == visibleAtA {
+			a = mid
+		} else {
+			b = mid
+		}
+	}
+	return a.Add(b.Sub(a) / 2).Round(time.Second)
+}
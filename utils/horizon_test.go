@@ -0,0 +1,178 @@
+package utils
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHorizonProfileElevationAt(t *testing.T) {
+	profile, err := NewHorizonProfile([]HorizonPoint{
+		{Azimuth: 270, Elevation: 10},
+		{Azimuth: 90, Elevation: 20},
+		{Azimuth: 360, Elevation: 4},
+		{Azimuth: 180, Elevation: 2},
+	})
+	if err != nil {
+		t.Fatalf("Expected a profile, but got %v", err)
+	}
+
+	testCases := []struct {
+		azimuth   float64
+		elevation float64
+	}{
+		{0, 4},
+		{90, 20},
+		{45, 12},
+		{135, 11},
+		{315, 7},
+		{360, 4},
+		{-45, 7},
+	}
+	for _, tc := range testCases {
+		if got := profile.ElevationAt(tc.azimuth); math.Abs(got-tc.elevation) > 1e-9 {
+			t.Errorf("Expected %g° at azimuth %g°, but got %g°", tc.elevation, tc.azimuth, got)
+		}
+	}
+
+	if got := FlatHorizon().ElevationAt(123); got != 0 {
+		t.Errorf("Expected a flat horizon at 0°, but got %g°", got)
+	}
+}
+
+func TestNewHorizonProfileErrors(t *testing.T) {
+	testCases := map[string][]HorizonPoint{
+		"no points":         nil,
+		"azimuth too large": {{Azimuth: 361, Elevation: 0}},
+		"negative azimuth":  {{Azimuth: -1, Elevation: 0}},
+		"elevation":         {{Azimuth: 0, Elevation: 91}},
+		"duplicate azimuth": {{Azimuth: 0, Elevation: 1}, {Azimuth: 360, Elevation: 2}},
+	}
+	for name, points := range testCases {
+		if _, err := NewHorizonProfile(points); err == nil {
+			t.Errorf("Expected an error for %s, but got none", name)
+		}
+	}
+}
+
+func TestParseHorizonCSV(t *testing.T) {
+	input := "azimuth,elevation\n# surveyed 2024\n0,5\n90;10\n180\t15\n\n270 20\n"
+	profile, err := ParseHorizonCSV(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Expected a profile, but got %v", err)
+	}
+	if points := profile.Points(); len(points) != 4 || points[3].Azimuth != 270 || points[3].Elevation != 20 {
+		t.Errorf("Expected 4 points ending at 270°/20°, but got %v", points)
+	}
+
+	for _, input := range []string{"0,5\nnorth,10\n", "0\n", ""} {
+		if _, err := ParseHorizonCSV(strings.NewReader(input)); err == nil {
+			t.Errorf("Expected an error for %q, but got none", input)
+		}
+	}
+}
+
+func TestParseHorizonJSON(t *testing.T) {
+	profile, err := ParseHorizonJSON(strings.NewReader(`[{"azimuth": 90, "elevation": 12.5}, {"azimuth": 270, "elevation": 3}]`))
+	if err != nil {
+		t.Fatalf("Expected a profile, but got %v", err)
+	}
+	if got := profile.ElevationAt(90); got != 12.5 {
+		t.Errorf("Expected 12.5°, but got %g°", got)
+	}
+
+	for _, input := range []string{`{"azimuth": 90}`, `[]`, `[{"azimuth": 400, "elevation": 0}]`} {
+		if _, err := ParseHorizonJSON(strings.NewReader(input)); err == nil {
+			t.Errorf("Expected an error for %s, but got none", input)
+		}
+	}
+}
+
+func TestCalculateHorizonDaylightFlatHorizon(t *testing.T) {
+	// A flat profile reproduces the ordinary sunrise and sunset
+	observer := NewObserver(51.5074, -0.1278)
+	date := time.Date(2024, time.March, 20, 12, 0, 0, 0, time.UTC)
+
+	for _, algorithm := range []Algorithm{AlgorithmFast, AlgorithmSPA} {
+		daylight := CalculateHorizonDaylight(algorithm, observer, FlatHorizon(), date)
+		sunrise, sunset := CalculateSunriseSunsetForObserver(observer, date)
+
+		if len(daylight.Periods) != 1 {
+			t.Fatalf("Expected one period of sun, but got %d", len(daylight.Periods))
+		}
+		if diff := daylight.FirstLight.Sub(sunrise); diff.Abs() > 2*time.Minute {
+			t.Errorf("%s: expected first light near %v, but got %v", algorithm, sunrise, daylight.FirstLight)
+		}
+		if diff := daylight.LastLight.Sub(sunset); diff.Abs() > 2*time.Minute {
+			t.Errorf("%s: expected last light near %v, but got %v", algorithm, sunset, daylight.LastLight)
+		}
+		if daylight.DirectSun != daylight.LastLight.Sub(daylight.FirstLight) {
+			t.Errorf("Expected direct sun of %v, but got %v", daylight.LastLight.Sub(daylight.FirstLight), daylight.DirectSun)
+		}
+	}
+}
+
+func TestCalculateHorizonDaylightValley(t *testing.T) {
+	observer := NewObserver(51.5074, -0.1278)
+	date := time.Date(2024, time.June, 21, 12, 0, 0, 0, time.UTC)
+	flat := CalculateHorizonDaylight(AlgorithmFast, observer, FlatHorizon(), date)
+
+	// Valley sides rising 15° to the east and west delay sunrise and bring sunset forward
+	valley, err := NewHorizonProfile([]HorizonPoint{
+		{Azimuth: 0, Elevation: 0},
+		{Azimuth: 45, Elevation: 15},
+		{Azimuth: 135, Elevation: 15},
+		{Azimuth: 180, Elevation: 0},
+		{Azimuth: 225, Elevation: 15},
+		{Azimuth: 315, Elevation: 15},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	daylight := CalculateHorizonDaylight(AlgorithmFast, observer, valley, date)
+	if !daylight.FirstLight.After(flat.FirstLight.Add(time.Hour)) || !daylight.LastLight.Before(flat.LastLight.Add(-time.Hour)) {
+		t.Errorf("Expected the valley to shorten the day by over an hour at each end, but got %v to %v (flat %v to %v)",
+			daylight.FirstLight, daylight.LastLight, flat.FirstLight, flat.LastLight)
+	}
+
+	// At first light the sun's upper limb sits on the skyline
+	altitude, azimuth := CalculateSunPositionForObserver(AlgorithmFast, observer, daylight.FirstLight)
+	if diff := altitude + spaSunRadius - valley.ElevationAt(azimuth); math.Abs(diff) > 0.05 {
+		t.Errorf("Expected the sun on the skyline at first light, but it was %.3f° off", diff)
+	}
+
+	// A tall building due south hides the sun around midday
+	building, err := NewHorizonProfile([]HorizonPoint{
+		{Azimuth: 0, Elevation: 0},
+		{Azimuth: 150, Elevation: 0},
+		{Azimuth: 151, Elevation: 80},
+		{Azimuth: 209, Elevation: 80},
+		{Azimuth: 210, Elevation: 0},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	daylight = CalculateHorizonDaylight(AlgorithmFast, observer, building, date)
+	if len(daylight.Periods) != 2 {
+		t.Fatalf("Expected the building to split the day in two, but got %d periods", len(daylight.Periods))
+	}
+	if hidden := flat.DirectSun - daylight.DirectSun; hidden < time.Hour || hidden > 3*time.Hour {
+		t.Errorf("Expected the building to hide the sun for 1-3 hours, but got %v", hidden)
+	}
+	if daylight.FirstLight != daylight.Periods[0].Start || daylight.LastLight != daylight.Periods[1].End {
+		t.Errorf("Expected first and last light at the ends of the periods")
+	}
+}
+
+func TestCalculateHorizonDaylightPolar(t *testing.T) {
+	observer := NewObserver(78.2232, 15.6267) // Longyearbyen
+	midsummer := CalculateHorizonDaylight(AlgorithmFast, observer, FlatHorizon(), time.Date(2024, time.June, 21, 12, 0, 0, 0, time.UTC))
+	if midsummer.DirectSun != 24*time.Hour {
+		t.Errorf("Expected 24 hours of sun, but got %v", midsummer.DirectSun)
+	}
+	midwinter := CalculateHorizonDaylight(AlgorithmFast, observer, FlatHorizon(), time.Date(2024, time.December, 21, 12, 0, 0, 0, time.UTC))
+	if midwinter.DirectSun != 0 || !midwinter.FirstLight.IsZero() || !midwinter.LastLight.IsZero() {
+		t.Errorf("Expected no sun, but got %v from %v", midwinter.DirectSun, midwinter.FirstLight)
+	}
+}
@@ -0,0 +1,134 @@
+package utils
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Defaults for the IP lookup cache
+const (
+	DefaultIPCacheSize        = 10000
+	DefaultIPCacheTTL         = 24 * time.Hour
+	DefaultIPCacheNegativeTTL = 5 * time.Minute
+)
+
+// CachingProvider wraps an IPLocationProvider with an LRU cache whose entries expire after a TTL.
+// Failed lookups are cached for a shorter time so a failing provider doesn't slow every request.
+type CachingProvider struct {
+	provider    IPLocationProvider
+	size        int
+	ttl         time.Duration
+	negativeTTL time.Duration
+	now         func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List // most recently used at the front
+}
+
+// ipCacheEntry is one cached lookup result
+type ipCacheEntry struct {
+	ip       string
+	location *IPLocation
+	err      error
+	expires  time.Time
+}
+
+// NewCachingProvider returns a provider that caches up to size lookups from provider
+func NewCachingProvider(provider IPLocationProvider, size int, ttl, negativeTTL time.Duration) *CachingProvider {
+	if size <= 0 {
+		size = DefaultIPCacheSize
+	}
+	return &CachingProvider{
+		provider:    provider,
+		size:        size,
+		ttl:         ttl,
+		negativeTTL: negativeTTL,
+		now:         time.Now,
+		entries:     make(map[string]*list.Element),
+		order:       list.New(),
+	}
+}
+
+// Lookup returns a cached result for the IP address, or asks the wrapped provider and caches its answer
+func (c *CachingProvider) Lookup(ctx context.Context, ip string) (*IPLocation, error) {
+	if location, err, ok := c.get(ip); ok {
+		return location, err
+	}
+
+	location, err := c.provider.Lookup(ctx, ip)
+
+	// The caller giving up says nothing about the provider, so don't remember it
+	if err != nil && errors.Is(ctx.Err(), context.Canceled) {
+		return nil, err
+	}
+
+	ttl := c.ttl
+	if err != nil {
+		ttl = c.negativeTTL
+	}
+	if ttl > 0 {
+		c.put(ip, location, err, ttl)
+	}
+	return location, err
+}
+
+// Len returns the number of cached entries, including expired ones not yet evicted
+func (c *CachingProvider) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// get returns a live cache entry and marks it as recently used
+func (c *CachingProvider) get(ip string) (*IPLocation, error, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[ip]
+	if !ok {
+		return nil, nil, false
+	}
+	entry := element.Value.(*ipCacheEntry)
+	if c.now().After(entry.expires) {
+		c.order.Remove(element)
+		delete(c.entries, ip)
+		return nil, nil, false
+	}
+
+	c.order.MoveToFront(element)
+	if entry.location == nil {
+		return nil, entry.err, true
+	}
+	// Return a copy so callers can't modify the cached value
+	location := *entry.location
+	return &location, entry.err, true
+}
+
+// put stores a result, evicting the least recently used entry when the cache is full
+func (c *CachingProvider) put(ip string, location *IPLocation, err error, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := &ipCacheEntry{ip: ip, err: err, expires: c.now().Add(ttl)}
+	if location != nil {
+		stored := *location
+		entry.location = &stored
+	}
+
+	if element, ok := c.entries[ip]; ok {
+		element.Value = entry
+		c.order.MoveToFront(element)
+		return
+	}
+
+	c.entries[ip] = c.order.PushFront(entry)
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*ipCacheEntry).ip)
+	}
+}
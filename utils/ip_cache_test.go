@@ -0,0 +1,139 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// countingProvider counts lookups and fails for IPs listed in failures
+type countingProvider struct {
+	calls    map[string]int
+	failures map[string]bool
+}
+
+func newCountingProvider() *countingProvider {
+	return &countingProvider{calls: make(map[string]int), failures: make(map[string]bool)}
+}
+
+func (p *countingProvider) Lookup(ctx context.Context, ip string) (*IPLocation, error) {
+	p.calls[ip]++
+	if p.failures[ip] {
+		return nil, fmt.Errorf("lookup failed for %s", ip)
+	}
+	return &IPLocation{Country: "Sudan", City: ip}, nil
+}
+
+// blockingProvider waits until the context is done
+type blockingProvider struct{}
+
+func (blockingProvider) Lookup(ctx context.Context, ip string) (*IPLocation, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestCachingProviderCachesAndExpires(t *testing.T) {
+	backend := newCountingProvider()
+	cache := NewCachingProvider(backend, 10, time.Hour, time.Minute)
+	now := time.Date(2026, time.January, 28, 12, 0, 0, 0, time.UTC)
+	cache.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		location, err := cache.Lookup(context.Background(), "1.2.3.4")
+		if err != nil || location.City != "1.2.3.4" {
+			t.Fatalf("Expected a cached location, but got %v, %v", location, err)
+		}
+	}
+	if backend.calls["1.2.3.4"] != 1 {
+		t.Errorf("Expected 1 provider call, but got %d", backend.calls["1.2.3.4"])
+	}
+
+	// Modifying a returned value must not change the cache
+	location, _ := cache.Lookup(context.Background(), "1.2.3.4")
+	location.City = "changed"
+	if location, _ = cache.Lookup(context.Background(), "1.2.3.4"); location.City != "1.2.3.4" {
+		t.Errorf("Expected the cached value to be unchanged, but got %s", location.City)
+	}
+
+	now = now.Add(2 * time.Hour)
+	cache.Lookup(context.Background(), "1.2.3.4")
+	if backend.calls["1.2.3.4"] != 2 {
+		t.Errorf("Expected the entry to expire after the TTL, but got %d provider calls", backend.calls["1.2.3.4"])
+	}
+}
+
+func TestCachingProviderCachesFailuresBriefly(t *testing.T) {
+	backend := newCountingProvider()
+	backend.failures["5.6.7.8"] = true
+	cache := NewCachingProvider(backend, 10, time.Hour, time.Minute)
+	now := time.Date(2026, time.January, 28, 12, 0, 0, 0, time.UTC)
+	cache.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		if _, err := cache.Lookup(context.Background(), "5.6.7.8"); err == nil {
+			t.Fatalf("Expected the cached failure to be returned, but got none")
+		}
+	}
+	if backend.calls["5.6.7.8"] != 1 {
+		t.Errorf("Expected 1 provider call, but got %d", backend.calls["5.6.7.8"])
+	}
+
+	now = now.Add(2 * time.Minute)
+	cache.Lookup(context.Background(), "5.6.7.8")
+	if backend.calls["5.6.7.8"] != 2 {
+		t.Errorf("Expected the failure to expire after the negative TTL, but got %d provider calls", backend.calls["5.6.7.8"])
+	}
+}
+
+func TestCachingProviderEvictsLeastRecentlyUsed(t *testing.T) {
+	backend := newCountingProvider()
+	cache := NewCachingProvider(backend, 2, time.Hour, time.Minute)
+
+	cache.Lookup(context.Background(), "10.0.0.1")
+	cache.Lookup(context.Background(), "10.0.0.2")
+	cache.Lookup(context.Background(), "10.0.0.1") // 10.0.0.2 is now the least recently used
+	cache.Lookup(context.Background(), "10.0.0.3")
+
+	if cache.Len() != 2 {
+		t.Errorf("Expected 2 cached entries, but got %d", cache.Len())
+	}
+
+	cache.Lookup(context.Background(), "10.0.0.1")
+	cache.Lookup(context.Background(), "10.0.0.2")
+	if backend.calls["10.0.0.1"] != 1 {
+		t.Errorf("Expected 10.0.0.1 to stay cached, but got %d provider calls", backend.calls["10.0.0.1"])
+	}
+	if backend.calls["10.0.0.2"] != 2 {
+		t.Errorf("Expected 10.0.0.2 to be evicted, but got %d provider calls", backend.calls["10.0.0.2"])
+	}
+}
+
+func TestGetLocationFromIPContextTimeout(t *testing.T) {
+	cache := NewCachingProvider(blockingProvider{}, 10, time.Hour, time.Minute)
+	SetIPLocationProvider(cache)
+	SetIPLookupTimeout(20 * time.Millisecond)
+	defer func() {
+		SetIPLocationProvider(NewIPAPIProvider())
+		SetIPLookupTimeout(DefaultIPLookupTimeout)
+	}()
+
+	start := time.Now()
+	if _, err := GetLocationFromIPContext(context.Background(), "1.2.3.4"); err == nil {
+		t.Errorf("Expected a timeout error, but got none")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected the lookup to give up quickly, but it took %v", elapsed)
+	}
+	if cache.Len() != 1 {
+		t.Errorf("Expected the timed out lookup to be cached, but got %d entries", cache.Len())
+	}
+
+	// A caller that cancels is not the provider's fault, so nothing is cached
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	GetLocationFromIPContext(ctx, "5.6.7.8")
+	if cache.Len() != 1 {
+		t.Errorf("Expected a canceled lookup not to be cached, but got %d entries", cache.Len())
+	}
+}
@@ -1,233 +1,136 @@
 package utils
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 )
 
-// CountryCapitalMap maps country names to their capital cities
-var CountryCapitalMap = map[string]string{
-	"Afghanistan":         "Kabul",
-	"Albania":             "Tirana",
-	"Algeria":             "Algiers",
-	"American Samoa":      "Pago Pago",
-	"Andorra":             "Andorra la Vella",
-	"Angola":              "Luanda",
-	"Antigua and Barbuda": "St. John's",
-	"Argentina":           "Buenos Aires",
-	"Armenia":             "Yerevan",
-	"Australia":           "Canberra",
-	"Austria":             "Vienna",
-	"Azerbaijan":          "Baku",
-	"Bahamas":             "Nassau",
-	"Bahrain":             "Manama",
-	"Bangladesh":          "Dhaka",
-	"Barbados":            "Bridgetown",
-	"Belarus":             "Minsk",
-	"Belgium":             "Brussels",
-	"Belize":              "Belmopan",
-	"Benin":               "Porto-Novo",
-	"Bhutan":              "Thimphu",
-	"Bolivia":             "La Paz",
-	"Bosnia and Herzegovina": "Sarajevo",
-	"Botswana":            "Gaborone",
-	"Brazil":              "Brasília",
-	"Brunei":              "Bandar Seri Begawan",
-	"Bulgaria":            "Sofia",
-	"Burkina Faso":        "Ouagadougou",
-	"Burundi":             "Gitega",
-	"Cabo Verde":          "Praia",
-	"Cambodia":            "Phnom Penh",
-	"Cameroon":            "Yaoundé",
-	"Canada":              "Ottawa",
-	"Central African Republic": "Bangui",
-	"Chad":                "N'Djamena",
-	"Chile":               "Santiago",
-	"China":               "Beijing",
-	"Colombia":            "Bogotá",
-	"Comoros":             "Moroni",
-	"Congo, Democratic Republic of the": "Kinshasa",
-	"Congo, Republic of the": "Brazzaville",
-	"Costa Rica":          "San José",
-	"Croatia":             "Zagreb",
-	"Cuba":                "Havana",
-	"Cyprus":              "Nicosia",
-	"Czech Republic":      "Prague",
-	"Denmark":             "Copenhagen",
-	"Djibouti":            "Djibouti",
-	"Dominica":            "Roseau",
-	"Dominican Republic":  "Santo Domingo",
-	"Ecuador":             "Quito",
-	"Egypt":               "Cairo",
-	"El Salvador":         "San Salvador",
-	"Equatorial Guinea":   "Malabo",
-	"Eritrea":             "Asmara",
-	"Estonia":             "Tallinn",
-	"Eswatini":            "Mbabane",
-	"Ethiopia":            "Addis Ababa",
-	"Fiji":                "Suva",
-	"Finland":             "Helsinki",
-	"France":              "Paris",
-	"Gabon":               "Libreville",
-	"Gambia":              "Banjul",
-	"Georgia":             "Tbilisi",
-	"Germany":             "Berlin",
-	"Ghana":               "Accra",
-	"Greece":              "Athens",
-	"Grenada":             "St. George's",
-	"Guatemala":           "Guatemala City",
-	"Guinea":              "Conakry",
-	"Guinea-Bissau":       "Bissau",
-	"Guyana":              "Georgetown",
-	"Haiti":               "Port-au-Prince",
-	"Honduras":            "Tegucigalpa",
-	"Hungary":             "Budapest",
-	"Iceland":             "Reykjavik",
-	"India":               "New Delhi",
-	"Indonesia":           "Jakarta",
-	"Iran":                "Tehran",
-	"Iraq":                "Baghdad",
-	"Ireland":             "Dublin",
-	"Israel":              "Jerusalem",
-	"Italy":               "Rome",
-	"Ivory Coast":         "Yamoussoukro",
-	"Jamaica":             "Kingston",
-	"Japan":               "Tokyo",
-	"Jordan":              "Amman",
-	"Kazakhstan":          "Astana",
-	"Kenya":               "Nairobi",
-	"Kiribati":            "Tarawa",
-	"Korea, North":        "Pyongyang",
-	"Korea, South":        "Seoul",
-	"Kosovo":              "Pristina",
-	"Kuwait":              "Kuwait City",
-	"Kyrgyzstan":          "Bishkek",
-	"Laos":                "Vientiane",
-	"Latvia":              "Riga",
-	"Lebanon":             "Beirut",
-	"Lesotho":             "Maseru",
-	"Liberia":             "Monrovia",
-	"Libya":               "Tripoli",
-	"Liechtenstein":       "Vaduz",
-	"Lithuania":           "Vilnius",
-	"Luxembourg":          "Luxembourg City",
-	"Madagascar":          "Antananarivo",
-	"Malawi":              "Lilongwe",
-	"Malaysia":            "Kuala Lumpur",
-	"Maldives":            "Malé",
-	"Mali":                "Bamako",
-	"Malta":               "Valletta",
-	"Marshall Islands":    "Majuro",
-	"Mauritania":          "Nouakchott",
-	"Mauritius":           "Port Louis",
-	"Mexico":              "Mexico City",
-	"Micronesia":          "Palikir",
-	"Moldova":             "Chișinău",
-	"Monaco":              "Monaco",
-	"Mongolia":            "Ulaanbaatar",
-	"Montenegro":          "Podgorica",
-	"Morocco":             "Rabat",
-	"Mozambique":          "Maputo",
-	"Myanmar":             "Naypyidaw",
-	"Namibia":             "Windhoek",
-	"Nauru":               "Yaren",
-	"Nepal":               "Kathmandu",
-	"Netherlands":         "Amsterdam",
-	"New Zealand":         "Wellington",
-	"Nicaragua":           "Managua",
-	"Niger":               "Niamey",
-	"Nigeria":             "Abuja",
-	"North Macedonia":     "Skopje",
-	"Norway":              "Oslo",
-	"Oman":                "Muscat",
-	"Pakistan":            "Islamabad",
-	"Palau":               "Ngerulmud",
-	"Panama":              "Panama City",
-	"Papua New Guinea":    "Port Moresby",
-	"Paraguay":            "Asunción",
-	"Peru":                "Lima",
-	"Philippines":         "Manila",
-	"Poland":              "Warsaw",
-	"Portugal":            "Lisbon",
-	"Qatar":               "Doha",
-	"Romania":             "Bucharest",
-	"Russia":              "Moscow",
-	"Rwanda":              "Kigali",
-	"Saint Kitts and Nevis": "Basseterre",
-	"Saint Lucia":         "Castries",
-	"Saint Vincent and the Grenadines": "Kingstown",
-	"Samoa":               "Apia",
-	"San Marino":          "San Marino",
-	"Sao Tome and Principe": "São Tomé",
-	"Saudi Arabia":        "Riyadh",
-	"Senegal":             "Dakar",
-	"Serbia":              "Belgrade",
-	"Seychelles":          "Victoria",
-	"Sierra Leone":        "Freetown",
-	"Singapore":           "Singapore",
-	"Slovakia":            "Bratislava",
-	"Slovenia":            "Ljubljana",
-	"Solomon Islands":     "Honiara",
-	"Somalia":             "Mogadishu",
-	"South Africa":        "Pretoria",
-	"South Sudan":         "Juba",
-	"Spain":               "Madrid",
-	"Sri Lanka":           "Colombo",
-	"Sudan":               "Khartoum",
-	"Suriname":            "Paramaribo",
-	"Sweden":              "Stockholm",
-	"Switzerland":         "Bern",
-	"Syria":               "Damascus",
-	"Taiwan":              "Taipei",
-	"Tajikistan":          "Dushanbe",
-	"Tanzania":            "Dodoma",
-	"Thailand":            "Bangkok",
-	"Timor-Leste":         "Dili",
-	"Togo":                "Lomé",
-	"Tonga":               "Nuku'alofa",
-	"Trinidad and Tobago": "Port of Spain",
-	"Tunisia":             "Tunis",
-	"Turkey":              "Ankara",
-	"Turkmenistan":        "Ashgabat",
-	"Tuvalu":              "Funafuti",
-	"Uganda":              "Kampala",
-	"Ukraine":             "Kyiv",
-	"United Arab Emirates": "Abu Dhabi",
-	"United Kingdom":      "London",
-	"United States":       "Washington, D.C.",
-	"Uruguay":             "Montevideo",
-	"Uzbekistan":          "Tashkent",
-	"Vanuatu":             "Port Vila",
-	"Vatican City":        "Vatican City",
-	"Venezuela":           "Caracas",
-	"Vietnam":             "Hanoi",
-	"Yemen":               "Sana'a",
-	"Zambia":              "Lusaka",
-	"Zimbabwe":            "Harare",
-}
-
 // IPLocation represents the response from the IP geolocation service
 type IPLocation struct {
-	Country string `json:"country"`
-	City    string `json:"city"`
-	Region  string `json:"region"`
-	Lat     string `json:"lat"`
-	Lon     string `json:"lon"`
+	Country     string `json:"country"`
+	CountryCode string `json:"country_code"` // ISO 3166-1 alpha-2
+	City        string `json:"city"`
+	Region      string `json:"region"`
+	Lat         string `json:"lat"`
+	Lon         string `json:"lon"`
+}
+
+// IPLocationProvider resolves an IP address to an approximate location
+type IPLocationProvider interface {
+	Lookup(ctx context.Context, ip string) (*IPLocation, error)
+}
+
+// Names of the IP geolocation providers that can be configured
+const (
+	IPProviderIPAPI = "ipapi"
+	IPProviderMMDB  = "mmdb"
+	IPProviderNone  = "none"
+)
+
+// DefaultIPLookupTimeout bounds how long a request waits for IP geolocation
+const DefaultIPLookupTimeout = 2 * time.Second
+
+var (
+	ipLocationProviderMu sync.RWMutex
+	ipLocationProvider   IPLocationProvider = NewCachingProvider(NewIPAPIProvider(), DefaultIPCacheSize, DefaultIPCacheTTL, DefaultIPCacheNegativeTTL)
+	ipLookupTimeout                         = DefaultIPLookupTimeout
+)
+
+// NewIPLocationProvider creates a provider by name; the mmdb provider reads the database at dbPath
+func NewIPLocationProvider(name, dbPath string) (IPLocationProvider, error) {
+	switch strings.ToLower(name) {
+	case "", IPProviderIPAPI:
+		return NewIPAPIProvider(), nil
+	case IPProviderMMDB:
+		if dbPath == "" {
+			return nil, fmt.Errorf("the mmdb provider needs a database path")
+		}
+		return OpenMMDBProvider(dbPath)
+	case IPProviderNone:
+		return disabledProvider{}, nil
+	}
+	return nil, fmt.Errorf("unknown IP geolocation provider: %s", name)
 }
 
-// GetLocationFromIP gets the location information from an IP address using ipapi.co
+// SetIPLocationProvider replaces the provider used by GetLocationFromIP
+func SetIPLocationProvider(provider IPLocationProvider) {
+	ipLocationProviderMu.Lock()
+	defer ipLocationProviderMu.Unlock()
+	ipLocationProvider = provider
+}
+
+// SetIPLookupTimeout sets how long GetLocationFromIPContext waits for the provider; zero disables the limit
+func SetIPLookupTimeout(timeout time.Duration) {
+	ipLocationProviderMu.Lock()
+	defer ipLocationProviderMu.Unlock()
+	ipLookupTimeout = timeout
+}
+
+// GetLocationFromIP gets the location information from an IP address using the configured provider
 func GetLocationFromIP(ip string) (*IPLocation, error) {
+	return GetLocationFromIPContext(context.Background(), ip)
+}
+
+// GetLocationFromIPContext gets the location information from an IP address using the configured provider,
+// giving up when ctx is done or the lookup timeout passes
+func GetLocationFromIPContext(ctx context.Context, ip string) (*IPLocation, error) {
+	ipLocationProviderMu.RLock()
+	provider := ipLocationProvider
+	timeout := ipLookupTimeout
+	ipLocationProviderMu.RUnlock()
+
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	return provider.Lookup(ctx, ip)
+}
+
+// disabledProvider is used when IP geolocation is turned off
+type disabledProvider struct{}
+
+func (disabledProvider) Lookup(ctx context.Context, ip string) (*IPLocation, error) {
+	return nil, fmt.Errorf("IP geolocation is disabled")
+}
+
+// IPAPIProvider looks up locations with the ipapi.co web service
+type IPAPIProvider struct {
+	BaseURL string
+	Client  *http.Client
+}
+
+// NewIPAPIProvider returns a provider for https://ipapi.co with a request timeout
+func NewIPAPIProvider() *IPAPIProvider {
+	return &IPAPIProvider{
+		BaseURL: "https://ipapi.co",
+		Client:  &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// Lookup gets the location information from an IP address using ipapi.co
+func (p *IPAPIProvider) Lookup(ctx context.Context, ip string) (*IPLocation, error) {
 	// If IP is empty or localhost, return a default location
 	if ip == "" || ip == "127.0.0.1" || ip == "::1" {
 		ip = ""
 	}
 
-	url := fmt.Sprintf("https://ipapi.co/%s/json/", ip)
+	url := fmt.Sprintf("%s/%s/json/", p.BaseURL, ip)
 
-	resp, err := http.Get(url)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := p.Client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to make request: %w", err)
 	}
@@ -242,42 +145,136 @@ func GetLocationFromIP(ip string) (*IPLocation, error) {
 		return nil, fmt.Errorf("failed to read response body: %w", err)
 	}
 
-	var location IPLocation
-	err = json.Unmarshal(body, &location)
+	var result ipapiResponse
+	err = json.Unmarshal(body, &result)
 	if err != nil {
 		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
+	if result.Error {
+		return nil, fmt.Errorf("lookup failed: %s", result.Reason)
+	}
 
-	return &location, nil
+	location := &IPLocation{
+		Country:     result.CountryName,
+		CountryCode: result.CountryCode,
+		City:        result.City,
+		Region:      result.Region,
+	}
+	if result.Latitude != nil && result.Longitude != nil {
+		location.Lat = strconv.FormatFloat(*result.Latitude, 'f', -1, 64)
+		location.Lon = strconv.FormatFloat(*result.Longitude, 'f', -1, 64)
+	}
+	return location, nil
 }
 
-// GetCapitalCityForCountry returns the capital city for a given country
-func GetCapitalCityForCountry(country string) string {
-	// Normalize the country name to title case for comparison
-	countryNormalized := normalizeCountryName(country)
-	
-	if capital, exists := CountryCapitalMap[countryNormalized]; exists {
-		return capital
+// ipapiResponse is the part of the ipapi.co JSON response that is used
+type ipapiResponse struct {
+	CountryName string   `json:"country_name"`
+	CountryCode string   `json:"country_code"`
+	City        string   `json:"city"`
+	Region      string   `json:"region"`
+	Latitude    *float64 `json:"latitude"`
+	Longitude   *float64 `json:"longitude"`
+	Error       bool     `json:"error"`
+	Reason      string   `json:"reason"`
+}
+
+// MMDBProvider looks up locations in a local GeoLite2 or DB-IP lite database, so it works offline
+type MMDBProvider struct {
+	reader *MMDBReader
+}
+
+// OpenMMDBProvider loads a MaxMind DB file for offline lookups
+func OpenMMDBProvider(path string) (*MMDBProvider, error) {
+	reader, err := OpenMMDB(path)
+	if err != nil {
+		return nil, err
+	}
+	return &MMDBProvider{reader: reader}, nil
+}
+
+// NewMMDBProvider creates a provider from an already opened database
+func NewMMDBProvider(reader *MMDBReader) *MMDBProvider {
+	return &MMDBProvider{reader: reader}
+}
+
+// Lookup gets the location information from an IP address in the local database
+func (p *MMDBProvider) Lookup(ctx context.Context, ip string) (*IPLocation, error) {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return nil, fmt.Errorf("invalid IP address: %q", ip)
+	}
+
+	record, err := p.reader.Lookup(parsed)
+	if err != nil {
+		return nil, fmt.Errorf("database lookup failed: %w", err)
+	}
+	if record == nil {
+		return nil, fmt.Errorf("IP address not found in database: %s", ip)
 	}
-	
-	// If not found, try to find by partial match
-	for countryName, capital := range CountryCapitalMap {
-		if strings.Contains(strings.ToLower(countryName), strings.ToLower(country)) ||
-		   strings.Contains(strings.ToLower(country), strings.ToLower(countryName)) {
-			return capital
+
+	country := record["country"]
+	if country == nil {
+		// Anycast and satellite ranges only carry the registered country
+		country = record["registered_country"]
+	}
+	location := &IPLocation{
+		Country:     mmdbName(country),
+		CountryCode: mmdbISOCode(country),
+		City:        mmdbName(record["city"]),
+	}
+	if subdivisions, ok := record["subdivisions"].([]interface{}); ok && len(subdivisions) > 0 {
+		location.Region = mmdbName(subdivisions[0])
+	}
+	if coordinates, ok := record["location"].(map[string]interface{}); ok {
+		lat, latOK := coordinates["latitude"].(float64)
+		lon, lonOK := coordinates["longitude"].(float64)
+		if latOK && lonOK {
+			location.Lat = strconv.FormatFloat(lat, 'f', -1, 64)
+			location.Lon = strconv.FormatFloat(lon, 'f', -1, 64)
 		}
 	}
-	
+	return location, nil
+}
+
+// mmdbName returns the English name from a GeoIP2 place record such as country or city
+func mmdbName(value interface{}) string {
+	place, ok := value.(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	names, ok := place["names"].(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	name, _ := names["en"].(string)
+	return name
+}
+
+// mmdbISOCode returns the ISO alpha-2 code from a GeoIP2 country record
+func mmdbISOCode(value interface{}) string {
+	place, ok := value.(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	code, _ := place["iso_code"].(string)
+	return code
+}
+
+// GetCapitalCityForCountry returns the capital for an ISO alpha-2 or alpha-3 code, country name or alias,
+// or "" if the country is unknown
+func GetCapitalCityForCountry(country string) string {
+	if c, ok := LookupCountry(country); ok {
+		return c.Capital
+	}
 	return ""
 }
 
-// normalizeCountryName normalizes the country name to title case
-func normalizeCountryName(name string) string {
-	words := strings.Split(name, " ")
-	for i, word := range words {
-		if len(word) > 0 {
-			words[i] = strings.Title(strings.ToLower(word))
-		}
+// FindCapitalCity returns the capital of a country from the active gazetteer, if it is listed
+func FindCapitalCity(country string) (City, bool) {
+	c, ok := LookupCountry(country)
+	if !ok {
+		return City{}, false
 	}
-	return strings.Join(words, " ")
-}
\ No newline at end of file
+	return DefaultGazetteer().FindCityInCountry(c.Capital, c.Alpha2)
+}
@@ -1,23 +1,26 @@
 package utils
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 )
 
 func TestCountryCapitalMap(t *testing.T) {
-	// Test that some known countries have their capitals in the map
+	// Test that some known countries have their capitals in the map, keyed by ISO alpha-2 code
 	testCases := []struct {
 		country string
 		capital string
 	}{
-		{"United States", "Washington, D.C."},
-		{"United Kingdom", "London"},
-		{"France", "Paris"},
-		{"Germany", "Berlin"},
-		{"Japan", "Tokyo"},
-		{"Australia", "Canberra"},
-		{"Canada", "Ottawa"},
-		{"Sudan", "Khartoum"},
+		{"US", "Washington, D.C."},
+		{"GB", "London"},
+		{"FR", "Paris"},
+		{"DE", "Berlin"},
+		{"JP", "Tokyo"},
+		{"AU", "Canberra"},
+		{"CA", "Ottawa"},
+		{"SD", "Khartoum"},
 	}
 
 	for _, tc := range testCases {
@@ -47,6 +50,17 @@ func TestGetCapitalCityForCountry(t *testing.T) {
 		{"Australia", "Canberra"},
 		{"Canada", "Ottawa"},
 		{"Sudan", "Khartoum"},
+		{"united states", "Washington, D.C."},
+		{"USA", "Washington, D.C."},
+		{"GBR", "London"},
+		{"sd", "Khartoum"},
+		{"Niger", "Niamey"},
+		{"Nigeria", "Abuja"},
+		{"Guinea", "Conakry"},
+		{"Equatorial Guinea", "Malabo"},
+		{"Papua New Guinea", "Port Moresby"},
+		{"South Sudan", "Juba"},
+		{"Côte d'Ivoire", "Yamoussoukro"},
 	}
 
 	for _, tc := range testCases {
@@ -61,31 +75,36 @@ func TestGetCapitalCityForCountry(t *testing.T) {
 
 func TestGetCapitalCityForCountryNotFound(t *testing.T) {
 	// Test that the function returns empty string for unknown countries
-	result := GetCapitalCityForCountry("NonExistentCountry")
-	if result != "" {
-		t.Errorf("Expected empty string for non-existent country, but got %s", result)
+	for _, country := range []string{"NonExistentCountry", "Guin", "United", "XX"} {
+		if result := GetCapitalCityForCountry(country); result != "" {
+			t.Errorf("Expected empty string for %q, but got %s", country, result)
+		}
 	}
 }
 
-func TestNormalizeCountryName(t *testing.T) {
-	// Test that the normalizeCountryName function works correctly
-	testCases := []struct {
-		input    string
-		expected string
-	}{
-		{"united states", "United States"},
-		{"UNITED KINGDOM", "United Kingdom"},
-		{"france", "France"},
-		{"south korea", "South Korea"},
-		{"cape verde", "Cape Verde"},
+func TestIPAPIProviderLookup(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/192.0.2.1/json/" {
+			w.Write([]byte(`{"error": true, "reason": "Reserved IP Address"}`))
+			return
+		}
+		w.Write([]byte(`{"city": "Niamey", "region": "Niamey", "country": "NE", "country_name": "Niger", "country_code": "NE", "latitude": 13.5137, "longitude": 2.1098}`))
+	}))
+	defer server.Close()
+
+	provider := &IPAPIProvider{BaseURL: server.URL, Client: server.Client()}
+	location, err := provider.Lookup(context.Background(), "41.203.64.1")
+	if err != nil {
+		t.Fatalf("Expected a location, but got %v", err)
+	}
+	if location.Country != "Niger" || location.CountryCode != "NE" || location.City != "Niamey" {
+		t.Errorf("Expected Niamey, Niger (NE), but got %s, %s (%s)", location.City, location.Country, location.CountryCode)
+	}
+	if location.Lat != "13.5137" || location.Lon != "2.1098" {
+		t.Errorf("Expected coordinates 13.5137, 2.1098, but got %s, %s", location.Lat, location.Lon)
 	}
 
-	for _, tc := range testCases {
-		t.Run(tc.input, func(t *testing.T) {
-			result := normalizeCountryName(tc.input)
-			if result != tc.expected {
-				t.Errorf("Expected normalized name %s for input %s, but got %s", tc.expected, tc.input, result)
-			}
-		})
+	if _, err := provider.Lookup(context.Background(), "192.0.2.1"); err == nil {
+		t.Errorf("Expected an error for an error response, but got none")
 	}
-}
\ No newline at end of file
+}
@@ -0,0 +1,213 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// ClearSkyModel selects the model used to estimate irradiance under a cloudless sky
+type ClearSkyModel string
+
+const (
+	// ClearSkyHaurwitz needs only the solar zenith angle (Haurwitz 1945)
+	ClearSkyHaurwitz ClearSkyModel = "haurwitz"
+	// ClearSkyIneichen uses the Linke turbidity and the site elevation (Ineichen and Perez 2002)
+	ClearSkyIneichen ClearSkyModel = "ineichen"
+	// ClearSkyBird models ozone, water vapor and aerosols separately (Bird and Hulstrom 1981)
+	ClearSkyBird ClearSkyModel = "bird"
+)
+
+// SolarConstant is the mean total solar irradiance at 1 AU, in W/m² (Kopp and Lean 2011)
+const SolarConstant = 1361.0
+
+// ParseClearSkyModel converts a user supplied name into a ClearSkyModel, defaulting to ClearSkyIneichen when empty
+func ParseClearSkyModel(name string) (ClearSkyModel, error) {
+	switch ClearSkyModel(strings.ToLower(strings.TrimSpace(name))) {
+	case "", ClearSkyIneichen:
+		return ClearSkyIneichen, nil
+	case ClearSkyHaurwitz:
+		return ClearSkyHaurwitz, nil
+	case ClearSkyBird:
+		return ClearSkyBird, nil
+	}
+	return "", fmt.Errorf("unknown clear-sky model: %s", name)
+}
+
+// Irradiance holds the three components of solar irradiance, in W/m²
+type Irradiance struct {
+	GHI float64 `json:"ghi"` // global horizontal
+	DNI float64 `json:"dni"` // direct normal
+	DHI float64 `json:"dhi"` // diffuse horizontal
+}
+
+// ClearSkyConditions describes the atmosphere for the Ineichen-Perez and Bird models
+type ClearSkyConditions struct {
+	LinkeTurbidity    float64 `json:"linke_turbidity"`    // Ineichen-Perez; about 2 for very clean air, 3 to 5 in cities
+	Ozone             float64 `json:"ozone"`              // Bird; total column ozone, atm-cm
+	PrecipitableWater float64 `json:"precipitable_water"` // Bird; cm
+	AOD380            float64 `json:"aod380"`             // Bird; aerosol optical depth at 380 nm
+	AOD500            float64 `json:"aod500"`             // Bird; aerosol optical depth at 500 nm
+	Albedo            float64 `json:"albedo"`             // Bird; ground reflectance, 0 to 1
+}
+
+// DefaultClearSkyConditions returns a moderately clear mid-latitude atmosphere
+func DefaultClearSkyConditions() ClearSkyConditions {
+	return ClearSkyConditions{
+		LinkeTurbidity:    3,
+		Ozone:             0.3,
+		PrecipitableWater: 1.5,
+		AOD380:            0.15,
+		AOD500:            0.1,
+		Albedo:            0.2,
+	}
+}
+
+// ClearSkyRangeError reports a clear-sky condition outside the range the models were fitted for
+type ClearSkyRangeError struct {
+	Field string // linke_turbidity, ozone, precipitable_water, aod380, aod500 or albedo
+	Value float64
+	Min   float64
+	Max   float64
+}
+
+func (e *ClearSkyRangeError) Error() string {
+	return fmt.Sprintf("%s out of range: %f", e.Field, e.Value)
+}
+
+// Validate checks that every condition is within its accepted range
+func (c ClearSkyConditions) Validate() error {
+	checks := []ClearSkyRangeError{
+		{Field: "linke_turbidity", Value: c.LinkeTurbidity, Min: 1, Max: 10},
+		{Field: "ozone", Value: c.Ozone, Min: 0, Max: 1},
+		{Field: "precipitable_water", Value: c.PrecipitableWater, Min: 0, Max: 10},
+		{Field: "aod380", Value: c.AOD380, Min: 0, Max: 5},
+		{Field: "aod500", Value: c.AOD500, Min: 0, Max: 5},
+		{Field: "albedo", Value: c.Albedo, Min: 0, Max: 1},
+	}
+	for _, check := range checks {
+		if !(check.Value >= check.Min && check.Value <= check.Max) {
+			return &check
+		}
+	}
+	return nil
+}
+
+// EarthSunDistance returns the distance from the Earth to the sun in AU
+func EarthSunDistance(dateTime time.Time) float64 {
+	return calculateSPA(spaInput{dateTime: dateTime, deltaT: EstimateDeltaT(dateTime)}).r
+}
+
+// ExtraterrestrialIrradiance returns the irradiance at the top of the atmosphere on a surface
+// facing the sun, in W/m², which varies by about ±3.4% over the year with the Earth-sun distance
+func ExtraterrestrialIrradiance(dateTime time.Time) float64 {
+	r := EarthSunDistance(dateTime)
+	return SolarConstant / (r * r)
+}
+
+// ClearSkyIrradiance estimates the clear-sky irradiance for an apparent solar zenith angle in degrees
+// and an extraterrestrial normal irradiance in W/m². All components are zero with the sun below the horizon.
+func ClearSkyIrradiance(model ClearSkyModel, zenith, extraterrestrial float64, observer Observer, conditions ClearSkyConditions) Irradiance {
+	if zenith >= 90 {
+		return Irradiance{}
+	}
+	switch model {
+	case ClearSkyHaurwitz:
+		return haurwitz(zenith, extraterrestrial)
+	case ClearSkyBird:
+		return bird(zenith, extraterrestrial, observer.Pressure, conditions)
+	default:
+		return ineichenPerez(zenith, extraterrestrial, observer.Elevation, observer.Pressure, conditions.LinkeTurbidity)
+	}
+}
+
+// RelativeAirmass returns the optical path length through the atmosphere relative to the zenith
+// for an apparent zenith angle in degrees (Kasten and Young 1989)
+func RelativeAirmass(zenith float64) float64 {
+	if zenith >= 90 {
+		return math.Inf(1)
+	}
+	return 1 / (math.Cos(degToRad(zenith)) + 0.50572*math.Pow(96.07995-zenith, -1.6364))
+}
+
+// haurwitz estimates the global irradiance from the zenith angle alone. The model has no direct
+// and diffuse split, so the global irradiance is divided with the Erbs correlation.
+func haurwitz(zenith, extraterrestrial float64) Irradiance {
+	cosZenith := math.Cos(degToRad(zenith))
+	ghi := 1098 * cosZenith * math.Exp(-0.059/cosZenith)
+	return splitGlobalIrradiance(ghi, cosZenith, extraterrestrial)
+}
+
+// splitGlobalIrradiance divides global horizontal irradiance into its direct and diffuse parts
+// from the clearness index (Erbs, Klein and Duffie 1982)
+func splitGlobalIrradiance(ghi, cosZenith, extraterrestrial float64) Irradiance {
+	kt := math.Min(ghi/(extraterrestrial*cosZenith), 1)
+
+	var diffuseFraction float64
+	switch {
+	case kt <= 0.22:
+		diffuseFraction = 1 - 0.09*kt
+	case kt <= 0.8:
+		diffuseFraction = 0.9511 - 0.1604*kt + 4.388*kt*kt - 16.638*kt*kt*kt + 12.336*kt*kt*kt*kt
+	default:
+		diffuseFraction = 0.165
+	}
+
+	dhi := ghi * diffuseFraction
+	return Irradiance{GHI: ghi, DNI: (ghi - dhi) / cosZenith, DHI: dhi}
+}
+
+// ineichenPerez implements the Ineichen and Perez clear-sky model with the Linke turbidity
+// at air mass 2, following the formulation used by pvlib
+func ineichenPerez(zenith, extraterrestrial, elevation, pressure, linkeTurbidity float64) Irradiance {
+	cosZenith := math.Cos(degToRad(zenith))
+	absoluteAirmass := RelativeAirmass(zenith) * pressure / 1013.25
+
+	fh1 := math.Exp(-elevation / 8000)
+	fh2 := math.Exp(-elevation / 1250)
+	cg1 := 5.09e-05*elevation + 0.868
+	cg2 := 3.92e-05*elevation + 0.0387
+
+	ghi := cg1 * extraterrestrial * cosZenith * math.Exp(-cg2*absoluteAirmass*(fh1+fh2*(linkeTurbidity-1)))
+
+	// The beam irradiance is the smaller of the model's own estimate and the one implied by the global irradiance
+	b := 0.664 + 0.163/fh1
+	dni := extraterrestrial * b * math.Exp(-0.09*absoluteAirmass*(linkeTurbidity-1))
+	impliedDNI := ghi * (1 - (0.1-0.2*math.Exp(-linkeTurbidity))/(0.1+0.882/fh1)) / cosZenith
+	dni = math.Max(math.Min(dni, impliedDNI), 0)
+
+	return Irradiance{GHI: ghi, DNI: dni, DHI: ghi - dni*cosZenith}
+}
+
+// bird implements the Bird and Hulstrom broadband clear-sky model
+func bird(zenith, extraterrestrial, pressure float64, c ClearSkyConditions) Irradiance {
+	cosZenith := math.Cos(degToRad(zenith))
+	// Bird uses Kasten's 1966 air mass formula
+	airmass := 1 / (cosZenith + 0.15*math.Pow(93.885-zenith, -1.25))
+	pressureAirmass := airmass * pressure / 1013.25
+
+	// Transmittances of Rayleigh scattering, ozone, mixed gases, water vapor and aerosols
+	rayleigh := math.Exp(-0.0903 * math.Pow(pressureAirmass, 0.84) * (1 + pressureAirmass - math.Pow(pressureAirmass, 1.01)))
+	ozoneAirmass := c.Ozone * airmass
+	ozone := 1 - 0.1611*ozoneAirmass*math.Pow(1+139.48*ozoneAirmass, -0.3034) -
+		0.002715*ozoneAirmass/(1+0.044*ozoneAirmass+0.0003*ozoneAirmass*ozoneAirmass)
+	gases := math.Exp(-0.0127 * math.Pow(pressureAirmass, 0.26))
+	waterAirmass := c.PrecipitableWater * airmass
+	water := 1 - 2.4959*waterAirmass/(math.Pow(1+79.034*waterAirmass, 0.6828)+6.385*waterAirmass)
+	taua := 0.2758*c.AOD380 + 0.35*c.AOD500
+	aerosol := math.Exp(-math.Pow(taua, 0.873) * (1 + taua - math.Pow(taua, 0.7088)) * math.Pow(airmass, 0.9108))
+	aerosolAbsorption := 1 - 0.1*(1-airmass+math.Pow(airmass, 1.06))*(1-aerosol)
+
+	// Forward scattering fraction of the aerosols and the sky albedo
+	const asymmetry = 0.85
+	skyAlbedo := 0.0685 + (1-asymmetry)*(1-aerosol/aerosolAbsorption)
+
+	dni := 0.9662 * extraterrestrial * aerosol * water * gases * ozone * rayleigh
+	directHorizontal := dni * cosZenith
+	scattered := extraterrestrial * cosZenith * 0.79 * ozone * gases * water * aerosolAbsorption *
+		(0.5*(1-rayleigh) + asymmetry*(1-aerosol/aerosolAbsorption)) / (1 - airmass + math.Pow(airmass, 1.02))
+	ghi := (directHorizontal + scattered) / (1 - c.Albedo*skyAlbedo)
+
+	return Irradiance{GHI: ghi, DNI: dni, DHI: ghi - directHorizontal}
+}
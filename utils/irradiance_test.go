@@ -0,0 +1,155 @@
+package utils
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestParseClearSkyModel(t *testing.T) {
+	testCases := []struct {
+		name     string
+		expected ClearSkyModel
+		wantErr  bool
+	}{
+		{"", ClearSkyIneichen, false},
+		{"haurwitz", ClearSkyHaurwitz, false},
+		{" Bird ", ClearSkyBird, false},
+		{"INEICHEN", ClearSkyIneichen, false},
+		{"cloudy", "", true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			model, err := ParseClearSkyModel(tc.name)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Expected error %v, but got %v", tc.wantErr, err)
+			}
+			if model != tc.expected {
+				t.Errorf("Expected %s, but got %s", tc.expected, model)
+			}
+		})
+	}
+}
+
+func TestExtraterrestrialIrradiance(t *testing.T) {
+	// Highest near perihelion in early January and lowest near aphelion in early July
+	perihelion := ExtraterrestrialIrradiance(time.Date(2024, time.January, 3, 0, 0, 0, 0, time.UTC))
+	aphelion := ExtraterrestrialIrradiance(time.Date(2024, time.July, 5, 0, 0, 0, 0, time.UTC))
+	if math.Abs(perihelion-1407.6) > 1 {
+		t.Errorf("Expected about 1407.6 W/m² at perihelion, but got %f", perihelion)
+	}
+	if math.Abs(aphelion-1316.5) > 1 {
+		t.Errorf("Expected about 1316.5 W/m² at aphelion, but got %f", aphelion)
+	}
+}
+
+func TestClearSkyIrradianceReferenceValues(t *testing.T) {
+	observer := NewObserver(0, 0)
+	observer.Pressure = 1013.25
+	conditions := DefaultClearSkyConditions()
+
+	testCases := []struct {
+		name     string
+		model    ClearSkyModel
+		zenith   float64
+		expected float64
+	}{
+		// 1098 cos z exp(-0.059 / cos z)
+		{"Haurwitz overhead", ClearSkyHaurwitz, 0, 1035.09},
+		{"Haurwitz at 60°", ClearSkyHaurwitz, 60, 487.89},
+		// 0.868 I0 exp(-0.0387 AM (TL - 1)) at sea level with TL = 3
+		{"Ineichen overhead", ClearSkyIneichen, 0, 1051.89},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			irradiance := ClearSkyIrradiance(tc.model, tc.zenith, SolarConstant, observer, conditions)
+			if math.Abs(irradiance.GHI-tc.expected) > 0.05 {
+				t.Errorf("Expected GHI %.2f, but got %.2f", tc.expected, irradiance.GHI)
+			}
+		})
+	}
+}
+
+func TestClearSkyIrradianceComponents(t *testing.T) {
+	observer := NewObserver(0, 0)
+	conditions := DefaultClearSkyConditions()
+
+	for _, model := range []ClearSkyModel{ClearSkyHaurwitz, ClearSkyIneichen, ClearSkyBird} {
+		t.Run(string(model), func(t *testing.T) {
+			previous := math.Inf(1)
+			for zenith := 0.0; zenith < 90; zenith += 5 {
+				irradiance := ClearSkyIrradiance(model, zenith, SolarConstant, observer, conditions)
+
+				// The global irradiance is the direct beam on the horizontal plus the diffuse
+				closure := irradiance.DNI*math.Cos(degToRad(zenith)) + irradiance.DHI
+				if math.Abs(closure-irradiance.GHI) > 1e-6 {
+					t.Errorf("Expected DNI cos z + DHI = GHI at zenith %.0f, but got %f and %f", zenith, closure, irradiance.GHI)
+				}
+				if irradiance.GHI > previous || irradiance.DNI < 0 || irradiance.DHI < 0 {
+					t.Errorf("Expected positive irradiance falling with zenith at %.0f, but got %+v", zenith, irradiance)
+				}
+				if irradiance.DNI > SolarConstant {
+					t.Errorf("Expected DNI below the solar constant at zenith %.0f, but got %f", zenith, irradiance.DNI)
+				}
+				previous = irradiance.GHI
+			}
+
+			if night := ClearSkyIrradiance(model, 95, SolarConstant, observer, conditions); night != (Irradiance{}) {
+				t.Errorf("Expected no irradiance with the sun below the horizon, but got %+v", night)
+			}
+		})
+	}
+}
+
+func TestClearSkyIrradianceAtmosphere(t *testing.T) {
+	observer := NewObserver(0, 0)
+	clean := DefaultClearSkyConditions()
+	hazy := clean
+	hazy.LinkeTurbidity = 6
+	hazy.AOD380 = 0.6
+	hazy.AOD500 = 0.4
+
+	for _, model := range []ClearSkyModel{ClearSkyIneichen, ClearSkyBird} {
+		t.Run(string(model), func(t *testing.T) {
+			clear := ClearSkyIrradiance(model, 30, SolarConstant, observer, clean)
+			turbid := ClearSkyIrradiance(model, 30, SolarConstant, observer, hazy)
+			if turbid.DNI >= clear.DNI || turbid.DHI <= clear.DHI {
+				t.Errorf("Expected haze to weaken the beam and brighten the sky, but got %+v and %+v", clear, turbid)
+			}
+		})
+	}
+
+	// Thinner air at altitude lets more of the beam through
+	mountain := NewObserver(0, 0)
+	mountain.Elevation = 3000
+	mountain.Pressure = PressureAtElevation(3000)
+	low := ClearSkyIrradiance(ClearSkyIneichen, 30, SolarConstant, observer, clean)
+	high := ClearSkyIrradiance(ClearSkyIneichen, 30, SolarConstant, mountain, clean)
+	if high.DNI <= low.DNI {
+		t.Errorf("Expected a stronger beam at 3000 m, but got %f and %f", high.DNI, low.DNI)
+	}
+}
+
+func TestClearSkyConditionsValidate(t *testing.T) {
+	if err := DefaultClearSkyConditions().Validate(); err != nil {
+		t.Errorf("Expected the default conditions to be valid, but got %v", err)
+	}
+
+	conditions := DefaultClearSkyConditions()
+	conditions.Albedo = 1.5
+	err := conditions.Validate()
+	rangeErr, ok := err.(*ClearSkyRangeError)
+	if !ok || rangeErr.Field != "albedo" {
+		t.Errorf("Expected an albedo range error, but got %v", err)
+	}
+
+	conditions = DefaultClearSkyConditions()
+	conditions.LinkeTurbidity = math.NaN()
+	err = conditions.Validate()
+	rangeErr, ok = err.(*ClearSkyRangeError)
+	if !ok || rangeErr.Field != "linke_turbidity" {
+		t.Errorf("Expected a linke_turbidity range error for NaN, but got %v", err)
+	}
+}
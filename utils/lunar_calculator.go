@@ -0,0 +1,281 @@
+package utils
+
+import (
+	"math"
+	"time"
+)
+
+// Lunar position from the truncated ELP-2000/82 theory in Meeus, Astronomical Algorithms (2nd ed.),
+// chapter 47: about 10" in longitude and 4" in latitude. Phase and illumination follow chapter 48.
+
+// SynodicMonth is the mean time from one new moon to the next, in days
+const SynodicMonth = 29.530588853
+
+// MoonPhase names the phase of the moon
+type MoonPhase string
+
+// Moon phases, in order from new moon
+const (
+	NewMoon        MoonPhase = "new_moon"
+	WaxingCrescent MoonPhase = "waxing_crescent"
+	FirstQuarter   MoonPhase = "first_quarter"
+	WaxingGibbous  MoonPhase = "waxing_gibbous"
+	FullMoon       MoonPhase = "full_moon"
+	WaningGibbous  MoonPhase = "waning_gibbous"
+	LastQuarter    MoonPhase = "last_quarter"
+	WaningCrescent MoonPhase = "waning_crescent"
+)
+
+// moonPhases are the eight phases, each centered on a multiple of 45° of elongation
+var moonPhases = []MoonPhase{NewMoon, WaxingCrescent, FirstQuarter, WaxingGibbous, FullMoon, WaningGibbous, LastQuarter, WaningCrescent}
+
+// MoonPosition is the moon as seen by an observer at one moment
+type MoonPosition struct {
+	Altitude     float64   `json:"altitude"`     // topocentric, with refraction, degrees
+	Azimuth      float64   `json:"azimuth"`      // degrees eastward from north
+	Distance     float64   `json:"distance"`     // center of the Earth to center of the moon, km
+	Illumination float64   `json:"illumination"` // illuminated fraction of the disk, 0 to 1
+	PhaseAngle   float64   `json:"phase_angle"`  // sun-moon-Earth angle, degrees
+	Elongation   float64   `json:"elongation"`   // moon's ecliptic longitude east of the sun, 0 to 360 degrees
+	Phase        MoonPhase `json:"phase"`
+	Age          float64   `json:"age"` // days since the last new moon
+}
+
+// lunarTerm is one periodic term: multiples of D, M, M' and F and its coefficient(s)
+type lunarTerm struct {
+	d, m, mPrime, f float64
+	a, b            float64
+}
+
+// Periodic terms for the moon's longitude (a, 0.000001°) and distance (b, 0.001 km), Meeus table 47.A
+var lunarLongitudeDistanceTerms = []lunarTerm{
+	{0, 0, 1, 0, 6288774, -20905355}, {2, 0, -1, 0, 1274027, -3699111}, {2, 0, 0, 0, 658314, -2955968},
+	{0, 0, 2, 0, 213618, -569925}, {0, 1, 0, 0, -185116, 48888}, {0, 0, 0, 2, -114332, -3149},
+	{2, 0, -2, 0, 58793, 246158}, {2, -1, -1, 0, 57066, -152138}, {2, 0, 1, 0, 53322, -170733},
+	{2, -1, 0, 0, 45758, -204586}, {0, 1, -1, 0, -40923, -129620}, {1, 0, 0, 0, -34720, 108743},
+	{0, 1, 1, 0, -30383, 104755}, {2, 0, 0, -2, 15327, 10321}, {0, 0, 1, 2, -12528, 0},
+	{0, 0, 1, -2, 10980, 79661}, {4, 0, -1, 0, 10675, -34782}, {0, 0, 3, 0, 10034, -23210},
+	{4, 0, -2, 0, 8548, -21636}, {2, 1, -1, 0, -7888, 24208}, {2, 1, 0, 0, -6766, 30824},
+	{1, 0, -1, 0, -5163, -8379}, {1, 1, 0, 0, 4987, -16675}, {2, -1, 1, 0, 4036, -12831},
+	{2, 0, 2, 0, 3994, -10445}, {4, 0, 0, 0, 3861, -11650}, {2, 0, -3, 0, 3665, 14403},
+	{0, 1, -2, 0, -2689, -7003}, {2, 0, -1, 2, -2602, 0}, {2, -1, -2, 0, 2390, 10056},
+	{1, 0, 1, 0, -2348, 6322}, {2, -2, 0, 0, 2236, -9884}, {0, 1, 2, 0, -2120, 5751},
+	{0, 2, 0, 0, -2069, 0}, {2, -2, -1, 0, 2048, -4950}, {2, 0, 1, -2, -1773, 4130},
+	{2, 0, 0, 2, -1595, 0}, {4, -1, -1, 0, 1215, -3958}, {0, 0, 2, 2, -1110, 0},
+	{3, 0, -1, 0, -892, 3258}, {2, 1, 1, 0, -810, 2616}, {4, -1, -2, 0, 759, -1897},
+	{0, 2, -1, 0, -713, -2117}, {2, 2, -1, 0, -700, 2354}, {2, 1, -2, 0, 691, 0},
+	{2, -1, 0, -2, 596, 0}, {4, 0, 1, 0, 549, -1423}, {0, 0, 4, 0, 537, -1117},
+	{4, -1, 0, 0, 520, -1571}, {1, 0, -2, 0, -487, -1739}, {2, 1, 0, -2, -399, 0},
+	{0, 0, 2, -2, -381, -4421}, {1, 1, 1, 0, 351, 0}, {3, 0, -2, 0, -340, 0},
+	{4, 0, -3, 0, 330, 0}, {2, -1, 2, 0, 327, 0}, {0, 2, 1, 0, -323, 1165},
+	{1, 1, -1, 0, 299, 0}, {2, 0, 3, 0, 294, 0}, {2, 0, -1, -2, 0, 8752},
+}
+
+// Periodic terms for the moon's latitude (a, 0.000001°), Meeus table 47.B
+var lunarLatitudeTerms = []lunarTerm{
+	{0, 0, 0, 1, 5128122, 0}, {0, 0, 1, 1, 280602, 0}, {0, 0, 1, -1, 277693, 0},
+	{2, 0, 0, -1, 173237, 0}, {2, 0, -1, 1, 55413, 0}, {2, 0, -1, -1, 46271, 0},
+	{2, 0, 0, 1, 32573, 0}, {0, 0, 2, 1, 17198, 0}, {2, 0, 1, -1, 9266, 0},
+	{0, 0, 2, -1, 8822, 0}, {2, -1, 0, -1, 8216, 0}, {2, 0, -2, -1, 4324, 0},
+	{2, 0, 1, 1, 4200, 0}, {2, 1, 0, -1, -3359, 0}, {2, -1, -1, 1, 2463, 0},
+	{2, -1, 0, 1, 2211, 0}, {2, -1, -1, -1, 2065, 0}, {0, 1, -1, -1, -1870, 0},
+	{4, 0, -1, -1, 1828, 0}, {0, 1, 0, 1, -1794, 0}, {0, 0, 0, 3, -1749, 0},
+	{0, 1, -1, 1, -1565, 0}, {1, 0, 0, 1, -1491, 0}, {0, 1, 1, 1, -1475, 0},
+	{0, 1, 1, -1, -1410, 0}, {0, 1, 0, -1, -1344, 0}, {1, 0, 0, -1, -1335, 0},
+	{0, 0, 3, 1, 1107, 0}, {4, 0, 0, -1, 1021, 0}, {4, 0, -1, 1, 833, 0},
+	{0, 0, 1, -3, 777, 0}, {4, 0, -2, 1, 671, 0}, {2, 0, 0, -3, 607, 0},
+	{2, 0, 2, -1, 596, 0}, {2, -1, 1, -1, 491, 0}, {2, 0, -2, 1, -451, 0},
+	{0, 0, 3, -1, 439, 0}, {2, 0, 2, 1, 422, 0}, {2, 0, -3, -1, 421, 0},
+	{2, 1, -1, 1, -366, 0}, {2, 1, 0, 1, -351, 0}, {4, 0, 0, 1, 331, 0},
+	{2, -1, 1, 1, 315, 0}, {2, -2, 0, -1, 302, 0}, {0, 0, 1, 3, -283, 0},
+	{2, 1, 1, -1, -229, 0}, {1, 1, 0, -1, 223, 0}, {1, 1, 0, 1, 223, 0},
+	{0, 1, -2, -1, -220, 0}, {2, 1, -1, -1, -220, 0}, {1, 0, 1, 1, -185, 0},
+	{2, -1, -2, -1, 181, 0}, {0, 1, 2, 1, -177, 0}, {4, 0, -2, -1, 176, 0},
+	{4, -1, -1, -1, 166, 0}, {1, 0, 1, -1, -164, 0}, {4, 0, 1, -1, 132, 0},
+	{1, 0, -1, -1, -119, 0}, {4, -1, 0, -1, 115, 0}, {2, -2, 0, 1, 107, 0},
+}
+
+// lunarResult holds the geocentric and topocentric coordinates of the moon at one moment
+type lunarResult struct {
+	lambda       float64 // apparent geocentric ecliptic longitude (degrees)
+	beta         float64 // geocentric ecliptic latitude (degrees)
+	distance     float64 // Earth-moon distance (km)
+	alpha        float64 // apparent geocentric right ascension (degrees)
+	delta        float64 // apparent geocentric declination (degrees)
+	elevation    float64 // topocentric elevation without refraction (degrees)
+	azimuth      float64 // topocentric azimuth, eastward from north (degrees)
+	sunLambda    float64 // apparent geocentric longitude of the sun (degrees)
+	sunDistance  float64 // Earth-sun distance (km)
+	semidiameter float64 // geocentric angular radius (degrees)
+}
+
+// kilometersPerAU converts the SPA's Earth radius vector to kilometers
+const kilometersPerAU = 149597870.7
+
+// calculateLunar computes the moon's position for an observer
+func calculateLunar(observer Observer, dateTime time.Time) lunarResult {
+	var res lunarResult
+
+	jd := julianDay(dateTime)
+	deltaT := EstimateDeltaT(dateTime)
+	jde := jd + deltaT/86400.0
+	t := (jde - 2451545.0) / 36525.0
+
+	// Mean longitude, elongation, anomalies and argument of latitude, in degrees
+	lPrime := 218.3164477 + t*(481267.88123421+t*(-0.0015786+t*(1.0/538841.0-t/65194000.0)))
+	d := 297.8501921 + t*(445267.1114034+t*(-0.0018819+t*(1.0/545868.0-t/113065000.0)))
+	m := 357.5291092 + t*(35999.0502909+t*(-0.0001536+t/24490000.0))
+	mPrime := 134.9633964 + t*(477198.8675055+t*(0.0087414+t*(1.0/69699.0-t/14712000.0)))
+	f := 93.2720950 + t*(483202.0175233+t*(-0.0036539+t*(-1.0/3526000.0+t/863310000.0)))
+	a1 := 119.75 + 131.849*t
+	a2 := 53.09 + 479264.290*t
+	a3 := 313.45 + 481266.484*t
+
+	// Eccentricity of the Earth's orbit, which scales the terms that involve the sun's anomaly
+	e := 1 - t*(0.002516+0.0000074*t)
+
+	var sumL, sumR, sumB float64
+	for _, term := range lunarLongitudeDistanceTerms {
+		arg := degToRad(term.d*d + term.m*m + term.mPrime*mPrime + term.f*f)
+		scale := math.Pow(e, math.Abs(term.m))
+		sumL += term.a * scale * math.Sin(arg)
+		sumR += term.b * scale * math.Cos(arg)
+	}
+	for _, term := range lunarLatitudeTerms {
+		arg := degToRad(term.d*d + term.m*m + term.mPrime*mPrime + term.f*f)
+		sumB += term.a * math.Pow(e, math.Abs(term.m)) * math.Sin(arg)
+	}
+
+	// Additive terms for the action of Venus, Jupiter and the flattening of the Earth
+	sumL += 3958*math.Sin(degToRad(a1)) + 1962*math.Sin(degToRad(lPrime-f)) + 318*math.Sin(degToRad(a2))
+	sumB += -2235*math.Sin(degToRad(lPrime)) + 382*math.Sin(degToRad(a3)) +
+		175*math.Sin(degToRad(a1-f)) + 175*math.Sin(degToRad(a1+f)) +
+		127*math.Sin(degToRad(lPrime-mPrime)) - 115*math.Sin(degToRad(lPrime+mPrime))
+
+	// Nutation and the true obliquity of the ecliptic (Meeus 22.2)
+	deltaPsi, deltaEpsilon := spaNutation(t)
+	epsilon0 := 23.0 + 26.0/60.0 + (21.448-t*(46.8150+t*(0.00059-t*0.001813)))/3600.0
+	epsilon := epsilon0 + deltaEpsilon
+
+	res.lambda = limitDegrees(lPrime + sumL/1000000.0 + deltaPsi)
+	res.beta = sumB / 1000000.0
+	res.distance = 385000.56 + sumR/1000.0
+	res.semidiameter = radToDeg(math.Asin(1737.4 / res.distance))
+
+	// Geocentric right ascension and declination
+	lambdaRad := degToRad(res.lambda)
+	betaRad := degToRad(res.beta)
+	epsilonRad := degToRad(epsilon)
+	res.alpha = limitDegrees(radToDeg(math.Atan2(
+		math.Sin(lambdaRad)*math.Cos(epsilonRad)-math.Tan(betaRad)*math.Sin(epsilonRad),
+		math.Cos(lambdaRad))))
+	res.delta = radToDeg(math.Asin(math.Sin(betaRad)*math.Cos(epsilonRad) +
+		math.Cos(betaRad)*math.Sin(epsilonRad)*math.Sin(lambdaRad)))
+
+	// Topocentric position, correcting for the moon's large parallax
+	hourAngle := limitDegrees(apparentSiderealTime(jd, deltaPsi, epsilon) + observer.Longitude - res.alpha)
+	parallax := radToDeg(math.Asin(6378.14 / res.distance))
+	hourAnglePrime, deltaPrime := topocentricCorrection(hourAngle, res.delta, parallax, observer.Latitude, observer.Elevation)
+	res.elevation, res.azimuth = horizontalCoordinates(hourAnglePrime, deltaPrime, observer.Latitude)
+
+	// The sun's apparent longitude and distance for the phase
+	sun := calculateSPA(spaInput{dateTime: dateTime, deltaT: deltaT})
+	res.sunLambda = limitDegrees(sun.lambda)
+	res.sunDistance = sun.r * kilometersPerAU
+
+	return res
+}
+
+// CalculateMoonPosition returns the moon's position, distance, illumination and phase for an observer,
+// correcting refraction for the observer's pressure and temperature
+func CalculateMoonPosition(observer Observer, dateTime time.Time) MoonPosition {
+	res := calculateLunar(observer, dateTime)
+
+	// Geocentric elongation and phase angle (Meeus 48.2 and 48.3)
+	cosPsi := math.Cos(degToRad(res.beta)) * math.Cos(degToRad(res.lambda-res.sunLambda))
+	psi := math.Acos(math.Max(-1, math.Min(1, cosPsi)))
+	phaseAngle := math.Atan2(res.sunDistance*math.Sin(psi), res.distance-res.sunDistance*math.Cos(psi))
+
+	elongation := limitDegrees(res.lambda - res.sunLambda)
+	return MoonPosition{
+		Altitude:     res.elevation + spaRefraction(res.elevation, observer.Pressure, observer.Temperature),
+		Azimuth:      res.azimuth,
+		Distance:     res.distance,
+		Illumination: (1 + math.Cos(phaseAngle)) / 2,
+		PhaseAngle:   radToDeg(phaseAngle),
+		Elongation:   elongation,
+		Phase:        moonPhases[int(math.Round(elongation/45))%len(moonPhases)],
+		Age:          moonAge(dateTime, elongation),
+	}
+}
+
+// moonAge returns the days since the last new moon, when the moon's elongation was zero.
+// The moon's speed varies, so the mean rate gives a first guess that is then refined.
+func moonAge(dateTime time.Time, elongation float64) float64 {
+	age := elongation / 360 * SynodicMonth
+	for i := 0; i < 4; i++ {
+		newMoon := dateTime.Add(-time.Duration(age * float64(24*time.Hour)))
+		res := calculateLunar(NewObserver(0, 0), newMoon)
+		residual := limitDegrees(res.lambda-res.sunLambda+180) - 180 // -180 to 180
+		age += residual / 360 * SynodicMonth
+	}
+	return age
+}
+
+// moonriseAltitude is the topocentric altitude of the moon's center at rise and set, in degrees,
+// taking the moon's semidiameter, the observer's refraction and the horizon dip into account
+// as SunriseAltitude does for the sun
+func moonriseAltitude(observer Observer, semidiameter float64) float64 {
+	return -(semidiameter + spaAtmosRefract*observer.RefractionScale() + observer.HorizonDip())
+}
+
+// moonSearchStep is how often the moon's altitude is sampled when searching for rise and set
+const moonSearchStep = 10 * time.Minute
+
+// CalculateMoonriseMoonset returns the moonrise and moonset on the given date in the date's time zone.
+// The moon rises about 50 minutes later each day, so some days have no moonrise or no moonset;
+// the corresponding time is zero then.
+func CalculateMoonriseMoonset(observer Observer, date time.Time) (moonrise, moonset time.Time) {
+	year, month, day := date.Date()
+	start := time.Date(year, month, day, 0, 0, 0, 0, date.Location())
+	end := time.Date(year, month, day+1, 0, 0, 0, 0, date.Location())
+
+	above := func(t time.Time) float64 {
+		res := calculateLunar(observer, t)
+		return res.elevation - moonriseAltitude(observer, res.semidiameter)
+	}
+
+	previous := above(start)
+	for t := start; t.Before(end); {
+		next := t.Add(moonSearchStep)
+		if next.After(end) {
+			next = end
+		}
+		current := above(next)
+		if (previous < 0) != (current < 0) {
+			crossing := bisectMoonCrossing(above, t, next, previous)
+			if previous < 0 && moonrise.IsZero() {
+				moonrise = crossing
+			} else if previous >= 0 && moonset.IsZero() {
+				moonset = crossing
+			}
+		}
+		previous = current
+		t = next
+	}
+	return moonrise, moonset
+}
+
+// bisectMoonCrossing narrows a sign change of f between a and b down to a second
+func bisectMoonCrossing(f func(time.Time) float64, a, b time.Time, fa float64) time.Time {
+	for b.Sub(a) > time.Second {
+		mid := a.Add(b.Sub(a) / 2)
+		fm := f(mid)
+		if (fa < 0) == (fm < 0) {
+			a, fa = mid, fm
+		} else {
+			b = mid
+		}
+	}
+	return a.Add(b.Sub(a) / 2).Round(time.Second)
+}
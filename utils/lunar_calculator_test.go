@@ -0,0 +1,121 @@
+package utils
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestCalculateLunarMeeusExample(t *testing.T) {
+	// Meeus example 47.a: 1992 April 12 at 0h TD
+	td := time.Date(1992, time.April, 12, 0, 0, 0, 0, time.UTC)
+	ut := td.Add(-time.Duration(EstimateDeltaT(td) * float64(time.Second)))
+	result := calculateLunar(NewObserver(0, 0), ut)
+
+	testCases := []struct {
+		name      string
+		got       float64
+		expected  float64
+		tolerance float64
+	}{
+		{"Apparent longitude", result.lambda, 133.167265, 1e-4},
+		{"Latitude", result.beta, -3.229126, 1e-4},
+		{"Distance", result.distance, 368409.7, 1},
+		{"Right ascension", result.alpha, 134.688470, 1e-4},
+		{"Declination", result.delta, 13.768368, 1e-4},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if math.Abs(tc.got-tc.expected) > tc.tolerance {
+				t.Errorf("Expected %f, but got %f", tc.expected, tc.got)
+			}
+		})
+	}
+}
+
+func TestCalculateMoonPositionPhases(t *testing.T) {
+	observer := NewObserver(51.5074, -0.1278)
+
+	testCases := []struct {
+		name         string
+		dateTime     time.Time
+		phase        MoonPhase
+		illumination float64
+		age          float64
+	}{
+		{"New moon", time.Date(2024, time.April, 8, 18, 21, 0, 0, time.UTC), NewMoon, 0, 0},
+		{"First quarter", time.Date(2024, time.April, 15, 19, 13, 0, 0, time.UTC), FirstQuarter, 0.5, 7.03},
+		{"Full moon", time.Date(2024, time.April, 23, 23, 49, 0, 0, time.UTC), FullMoon, 1, 15.23},
+		{"Last quarter", time.Date(2024, time.May, 1, 11, 27, 0, 0, time.UTC), LastQuarter, 0.5, 22.71},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			moon := CalculateMoonPosition(observer, tc.dateTime)
+			if moon.Phase != tc.phase {
+				t.Errorf("Expected phase %s, but got %s", tc.phase, moon.Phase)
+			}
+			if math.Abs(moon.Illumination-tc.illumination) > 0.01 {
+				t.Errorf("Expected illumination %.2f, but got %.4f", tc.illumination, moon.Illumination)
+			}
+			if math.Abs(moon.Age-tc.age) > 0.02 {
+				t.Errorf("Expected age %.2f days, but got %.4f", tc.age, moon.Age)
+			}
+			if moon.Distance < 356000 || moon.Distance > 407000 {
+				t.Errorf("Expected a distance between perigee and apogee, but got %.0f km", moon.Distance)
+			}
+		})
+	}
+}
+
+func TestCalculateMoonriseMoonset(t *testing.T) {
+	london, _ := time.LoadLocation("Europe/London")
+	observer := NewObserver(51.5074, -0.1278)
+
+	for day := 1; day <= 30; day++ {
+		date := time.Date(2024, time.April, day, 12, 0, 0, 0, london)
+		moonrise, moonset := CalculateMoonriseMoonset(observer, date)
+
+		for _, event := range []time.Time{moonrise, moonset} {
+			if event.IsZero() {
+				continue
+			}
+			if y, m, d := event.In(london).Date(); y != 2024 || m != time.April || d != day {
+				t.Errorf("Expected an event on April %d, but got %v", day, event)
+			}
+			// At rise and set the refracted upper limb is on the horizon
+			result := calculateLunar(observer, event)
+			if top := result.elevation + result.semidiameter + 34.0/60.0; math.Abs(top) > 0.01 {
+				t.Errorf("Expected the upper limb on the horizon at %v, but got %.3f°", event, top)
+			}
+		}
+	}
+
+	// On the day of the full moon it rises around sunset and sets around sunrise
+	moonrise, moonset := CalculateMoonriseMoonset(observer, time.Date(2024, time.April, 24, 0, 0, 0, 0, london))
+	if moonrise.IsZero() || moonrise.In(london).Hour() < 19 {
+		t.Errorf("Expected a moonrise in the evening, but got %v", moonrise)
+	}
+	if moonset.IsZero() || moonset.In(london).Hour() > 7 {
+		t.Errorf("Expected a moonset in the morning, but got %v", moonset)
+	}
+
+	// The dip of the horizon from a raised observer outweighs the thinner air
+	mountain := observer
+	mountain.Elevation = 3000
+	mountain.Pressure = PressureAtElevation(mountain.Elevation)
+	mountain.Temperature = -5
+	date := time.Date(2024, time.April, 24, 0, 0, 0, 0, london)
+	highMoonrise, highMoonset := CalculateMoonriseMoonset(mountain, date)
+	for _, event := range []time.Time{highMoonrise, highMoonset} {
+		result := calculateLunar(mountain, event)
+		top := result.elevation + result.semidiameter + spaAtmosRefract*mountain.RefractionScale() + mountain.HorizonDip()
+		if math.Abs(top) > 0.01 {
+			t.Errorf("Expected the upper limb on the observer's horizon at %v, but got %.3f°", event, top)
+		}
+	}
+	if !highMoonrise.Before(moonrise) || !highMoonset.After(moonset) {
+		t.Errorf("Expected an earlier moonrise and a later moonset at elevation, but got %v and %v against %v and %v", highMoonrise, highMoonset, moonrise, moonset)
+	}
+}
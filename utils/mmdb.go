@@ -0,0 +1,351 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"net"
+	"os"
+)
+
+// mmdbMetadataMarker precedes the metadata section at the end of a MaxMind DB file
+var mmdbMetadataMarker = []byte("\xab\xcd\xefMaxMind.com")
+
+// Data section field types from the MaxMind DB format specification
+const (
+	mmdbExtended  = 0
+	mmdbPointer   = 1
+	mmdbString    = 2
+	mmdbDouble    = 3
+	mmdbBytes     = 4
+	mmdbUint16    = 5
+	mmdbUint32    = 6
+	mmdbMap       = 7
+	mmdbInt32     = 8
+	mmdbUint64    = 9
+	mmdbUint128   = 10
+	mmdbArray     = 11
+	mmdbContainer = 12
+	mmdbEndMarker = 13
+	mmdbBoolean   = 14
+	mmdbFloat     = 15
+)
+
+// MMDBReader reads MaxMind DB files such as GeoLite2 and DB-IP lite databases
+type MMDBReader struct {
+	buffer       []byte
+	nodeCount    uint
+	recordSize   uint
+	ipVersion    uint
+	databaseType string
+	dataStart    uint // offset of the data section in buffer
+	ipv4Start    uint // node where IPv4 addresses begin in an IPv6 tree
+}
+
+// OpenMMDB loads a MaxMind DB file into memory
+func OpenMMDB(path string) (*MMDBReader, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read database: %w", err)
+	}
+	return NewMMDBReader(data)
+}
+
+// NewMMDBReader parses a MaxMind DB held in memory
+func NewMMDBReader(data []byte) (*MMDBReader, error) {
+	markerIndex := bytes.LastIndex(data, mmdbMetadataMarker)
+	if markerIndex < 0 {
+		return nil, fmt.Errorf("invalid MaxMind DB: metadata marker not found")
+	}
+	metadataStart := uint(markerIndex + len(mmdbMetadataMarker))
+
+	metadataDecoder := mmdbDecoder{buffer: data[metadataStart:]}
+	value, _, err := metadataDecoder.decode(0)
+	if err != nil {
+		return nil, fmt.Errorf("invalid MaxMind DB metadata: %w", err)
+	}
+	metadata, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid MaxMind DB metadata: not a map")
+	}
+
+	reader := &MMDBReader{buffer: data}
+	reader.nodeCount = mmdbUint(metadata["node_count"])
+	reader.recordSize = mmdbUint(metadata["record_size"])
+	reader.ipVersion = mmdbUint(metadata["ip_version"])
+	reader.databaseType, _ = metadata["database_type"].(string)
+
+	if reader.recordSize != 24 && reader.recordSize != 28 && reader.recordSize != 32 {
+		return nil, fmt.Errorf("unsupported record size: %d", reader.recordSize)
+	}
+	if reader.ipVersion != 4 && reader.ipVersion != 6 {
+		return nil, fmt.Errorf("unsupported IP version: %d", reader.ipVersion)
+	}
+
+	treeSize := reader.nodeCount * reader.recordSize / 4
+	reader.dataStart = treeSize + 16 // the tree is followed by 16 zero bytes
+	if reader.dataStart > uint(markerIndex) {
+		return nil, fmt.Errorf("invalid MaxMind DB: search tree larger than file")
+	}
+
+	// IPv4 addresses live under ::/96 in an IPv6 tree, so walk the 96 zero bits once up front
+	if reader.ipVersion == 6 {
+		node := uint(0)
+		for i := 0; i < 96 && node < reader.nodeCount; i++ {
+			node = reader.readRecord(node, 0)
+		}
+		reader.ipv4Start = node
+	}
+
+	return reader, nil
+}
+
+// DatabaseType returns the database_type from the metadata, for example GeoLite2-City
+func (r *MMDBReader) DatabaseType() string {
+	return r.databaseType
+}
+
+// Lookup returns the decoded record for an IP address, or nil if the address is not in the database
+func (r *MMDBReader) Lookup(ip net.IP) (map[string]interface{}, error) {
+	node, bitCount, err := r.startNode(ip)
+	if err != nil {
+		return nil, err
+	}
+
+	address := ip.To16()
+	if bitCount == 32 {
+		address = ip.To4()
+	}
+
+	for i := 0; i < bitCount && node < r.nodeCount; i++ {
+		bit := uint(address[i>>3]>>(7-uint(i&7))) & 1
+		node = r.readRecord(node, bit)
+	}
+
+	if node == r.nodeCount {
+		// Empty record: the address is not in the database
+		return nil, nil
+	}
+	if node < r.nodeCount {
+		return nil, fmt.Errorf("invalid MaxMind DB: search tree is deeper than the address")
+	}
+
+	// Pointers into the data section are offset by the node count and the 16 byte separator
+	offset := node - r.nodeCount - 16
+	decoder := mmdbDecoder{buffer: r.buffer[r.dataStart:]}
+	value, _, err := decoder.decode(offset)
+	if err != nil {
+		return nil, err
+	}
+	record, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid MaxMind DB record: not a map")
+	}
+	return record, nil
+}
+
+// startNode returns the node to start searching from and how many address bits to walk
+func (r *MMDBReader) startNode(ip net.IP) (uint, int, error) {
+	if ipv4 := ip.To4(); ipv4 != nil {
+		if r.ipVersion == 6 {
+			return r.ipv4Start, 32, nil
+		}
+		return 0, 32, nil
+	}
+	if ip.To16() == nil {
+		return 0, 0, fmt.Errorf("invalid IP address")
+	}
+	if r.ipVersion == 4 {
+		return 0, 0, fmt.Errorf("cannot look up an IPv6 address in an IPv4 database")
+	}
+	return 0, 128, nil
+}
+
+// readRecord returns the left (bit 0) or right (bit 1) record of a search tree node
+func (r *MMDBReader) readRecord(node, bit uint) uint {
+	switch r.recordSize {
+	case 24:
+		offset := node*6 + bit*3
+		b := r.buffer[offset : offset+3]
+		return uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2])
+	case 28:
+		offset := node * 7
+		b := r.buffer[offset : offset+7]
+		if bit == 0 {
+			return uint(b[3]&0xF0)<<20 | uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2])
+		}
+		return uint(b[3]&0x0F)<<24 | uint(b[4])<<16 | uint(b[5])<<8 | uint(b[6])
+	default:
+		offset := node*8 + bit*4
+		return uint(binary.BigEndian.Uint32(r.buffer[offset : offset+4]))
+	}
+}
+
+// mmdbDecoder decodes values from a MaxMind DB data section
+type mmdbDecoder struct {
+	buffer []byte
+}
+
+// decode reads the value at offset and returns it with the offset just past it
+func (d *mmdbDecoder) decode(offset uint) (interface{}, uint, error) {
+	if offset >= uint(len(d.buffer)) {
+		return nil, 0, fmt.Errorf("unexpected end of data at offset %d", offset)
+	}
+	control := d.buffer[offset]
+	offset++
+
+	fieldType := uint(control >> 5)
+	if fieldType == mmdbExtended {
+		if offset >= uint(len(d.buffer)) {
+			return nil, 0, fmt.Errorf("unexpected end of data in extended type")
+		}
+		fieldType = 7 + uint(d.buffer[offset])
+		offset++
+	}
+
+	// Pointers encode their target in the size bits, and decoding continues after the pointer itself
+	if fieldType == mmdbPointer {
+		target, next, err := d.decodePointer(control, offset)
+		if err != nil {
+			return nil, 0, err
+		}
+		value, _, err := d.decode(target)
+		return value, next, err
+	}
+
+	size, offset, err := d.decodeSize(control, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	switch fieldType {
+	case mmdbMap:
+		result := make(map[string]interface{}, size)
+		for i := uint(0); i < size; i++ {
+			key, next, err := d.decode(offset)
+			if err != nil {
+				return nil, 0, err
+			}
+			keyString, ok := key.(string)
+			if !ok {
+				return nil, 0, fmt.Errorf("map key is not a string")
+			}
+			value, next, err := d.decode(next)
+			if err != nil {
+				return nil, 0, err
+			}
+			result[keyString] = value
+			offset = next
+		}
+		return result, offset, nil
+	case mmdbArray:
+		result := make([]interface{}, 0, size)
+		for i := uint(0); i < size; i++ {
+			value, next, err := d.decode(offset)
+			if err != nil {
+				return nil, 0, err
+			}
+			result = append(result, value)
+			offset = next
+		}
+		return result, offset, nil
+	case mmdbBoolean:
+		return size != 0, offset, nil
+	}
+
+	end := offset + size
+	if end > uint(len(d.buffer)) {
+		return nil, 0, fmt.Errorf("unexpected end of data for type %d", fieldType)
+	}
+	payload := d.buffer[offset:end]
+
+	switch fieldType {
+	case mmdbString:
+		return string(payload), end, nil
+	case mmdbDouble:
+		if size != 8 {
+			return nil, 0, fmt.Errorf("invalid double size: %d", size)
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(payload)), end, nil
+	case mmdbFloat:
+		if size != 4 {
+			return nil, 0, fmt.Errorf("invalid float size: %d", size)
+		}
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(payload))), end, nil
+	case mmdbBytes, mmdbUint128:
+		return append([]byte(nil), payload...), end, nil
+	case mmdbUint16, mmdbUint32, mmdbUint64:
+		var value uint64
+		for _, b := range payload {
+			value = value<<8 | uint64(b)
+		}
+		return value, end, nil
+	case mmdbInt32:
+		var value uint32
+		for _, b := range payload {
+			value = value<<8 | uint32(b)
+		}
+		return int64(int32(value)), end, nil
+	case mmdbContainer, mmdbEndMarker:
+		return nil, end, nil
+	}
+	return nil, 0, fmt.Errorf("unknown data type: %d", fieldType)
+}
+
+// decodeSize reads the payload size from the control byte and any following size bytes
+func (d *mmdbDecoder) decodeSize(control byte, offset uint) (uint, uint, error) {
+	size := uint(control & 0x1F)
+	if size < 29 {
+		return size, offset, nil
+	}
+
+	extra := size - 28 // 1, 2 or 3 more bytes
+	if offset+extra > uint(len(d.buffer)) {
+		return 0, 0, fmt.Errorf("unexpected end of data in size")
+	}
+	var value uint
+	for _, b := range d.buffer[offset : offset+extra] {
+		value = value<<8 | uint(b)
+	}
+	switch size {
+	case 29:
+		return 29 + value, offset + extra, nil
+	case 30:
+		return 285 + value, offset + extra, nil
+	default:
+		return 65821 + value, offset + extra, nil
+	}
+}
+
+// decodePointer returns the data section offset a pointer refers to and the offset after the pointer
+func (d *mmdbDecoder) decodePointer(control byte, offset uint) (uint, uint, error) {
+	pointerSize := uint((control>>3)&0x3) + 1
+	if offset+pointerSize > uint(len(d.buffer)) {
+		return 0, 0, fmt.Errorf("unexpected end of data in pointer")
+	}
+
+	var value uint
+	for _, b := range d.buffer[offset : offset+pointerSize] {
+		value = value<<8 | uint(b)
+	}
+
+	high := uint(control & 0x7)
+	switch pointerSize {
+	case 1:
+		value |= high << 8
+	case 2:
+		value = (value | high<<16) + 2048
+	case 3:
+		value = (value | high<<24) + 526336
+	}
+	return value, offset + pointerSize, nil
+}
+
+// mmdbUint converts a decoded unsigned integer to uint, returning 0 for other types
+func mmdbUint(value interface{}) uint {
+	if v, ok := value.(uint64); ok {
+		return uint(v)
+	}
+	return 0
+}
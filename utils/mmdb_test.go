@@ -0,0 +1,274 @@
+package utils
+
+import (
+	"context"
+	"encoding/binary"
+	"math"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// testMMDBRecord is a search tree record: empty, a child node or a data section offset
+type testMMDBRecord struct {
+	kind  int // 0 empty, 1 node, 2 data
+	value int
+}
+
+// testMMDBWriter builds small MaxMind DB files with 24 bit records for tests
+type testMMDBWriter struct {
+	nodes [][2]testMMDBRecord
+	data  []byte
+}
+
+func newTestMMDBWriter() *testMMDBWriter {
+	return &testMMDBWriter{nodes: make([][2]testMMDBRecord, 1)}
+}
+
+// addData appends an encoded value to the data section and returns its offset
+func (w *testMMDBWriter) addData(value []byte) int {
+	offset := len(w.data)
+	w.data = append(w.data, value...)
+	return offset
+}
+
+// insert points a network in an IPv6 tree at a data offset; IPv4 networks are stored under ::/96
+func (w *testMMDBWriter) insert(cidr string, dataOffset int) {
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		panic(err)
+	}
+	ones, _ := network.Mask.Size()
+	address := network.IP.To16()
+	if network.IP.To4() != nil {
+		ones += 96
+		address = append(make([]byte, 12), network.IP.To4()...)
+	}
+
+	node := 0
+	for i := 0; i < ones; i++ {
+		bit := (address[i/8] >> (7 - uint(i%8))) & 1
+		if i == ones-1 {
+			w.nodes[node][bit] = testMMDBRecord{kind: 2, value: dataOffset}
+			return
+		}
+		if w.nodes[node][bit].kind != 1 {
+			w.nodes = append(w.nodes, [2]testMMDBRecord{})
+			w.nodes[node][bit] = testMMDBRecord{kind: 1, value: len(w.nodes) - 1}
+		}
+		node = w.nodes[node][bit].value
+	}
+}
+
+// bytes serializes the tree, the data section and the metadata
+func (w *testMMDBWriter) bytes() []byte {
+	nodeCount := len(w.nodes)
+	var out []byte
+	for _, node := range w.nodes {
+		for _, record := range node {
+			value := nodeCount
+			switch record.kind {
+			case 1:
+				value = record.value
+			case 2:
+				value = nodeCount + 16 + record.value
+			}
+			out = append(out, byte(value>>16), byte(value>>8), byte(value))
+		}
+	}
+	out = append(out, make([]byte, 16)...)
+	out = append(out, w.data...)
+	out = append(out, mmdbMetadataMarker...)
+	out = append(out, encodeTestMap(
+		"node_count", encodeTestUint(mmdbUint32, uint64(nodeCount)),
+		"record_size", encodeTestUint(mmdbUint16, 24),
+		"ip_version", encodeTestUint(mmdbUint16, 6),
+		"database_type", encodeTestString("Test-City"),
+		"binary_format_major_version", encodeTestUint(mmdbUint16, 2),
+	)...)
+	return out
+}
+
+func encodeTestControl(fieldType, size int) []byte {
+	var out []byte
+	if fieldType <= 7 {
+		out = append(out, byte(fieldType<<5))
+	} else {
+		out = append(out, 0, byte(fieldType-7))
+	}
+	switch {
+	case size < 29:
+		out[0] |= byte(size)
+	case size < 285:
+		out[0] |= 29
+		out = append(out, byte(size-29))
+	default:
+		out[0] |= 30
+		out = append(out, byte((size-285)>>8), byte(size-285))
+	}
+	return out
+}
+
+func encodeTestString(s string) []byte {
+	return append(encodeTestControl(mmdbString, len(s)), s...)
+}
+
+func encodeTestDouble(f float64) []byte {
+	payload := make([]byte, 8)
+	binary.BigEndian.PutUint64(payload, math.Float64bits(f))
+	return append(encodeTestControl(mmdbDouble, 8), payload...)
+}
+
+func encodeTestUint(fieldType int, v uint64) []byte {
+	var payload []byte
+	for ; v > 0; v >>= 8 {
+		payload = append([]byte{byte(v)}, payload...)
+	}
+	return append(encodeTestControl(fieldType, len(payload)), payload...)
+}
+
+func encodeTestPointer(offset int) []byte {
+	// Only the smallest pointer form (offsets below 2048) is needed here
+	return []byte{byte(mmdbPointer<<5) | byte(offset>>8&0x7), byte(offset)}
+}
+
+// encodeTestMap encodes alternating keys and already encoded values
+func encodeTestMap(pairs ...interface{}) []byte {
+	out := encodeTestControl(mmdbMap, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		out = append(out, encodeTestString(pairs[i].(string))...)
+		out = append(out, pairs[i+1].([]byte)...)
+	}
+	return out
+}
+
+func encodeTestArray(values ...[]byte) []byte {
+	out := encodeTestControl(mmdbArray, len(values))
+	for _, value := range values {
+		out = append(out, value...)
+	}
+	return out
+}
+
+func encodeTestPlace(name string) []byte {
+	return encodeTestMap("names", encodeTestMap("en", encodeTestString(name)))
+}
+
+func encodeTestCountry(name, isoCode string) []byte {
+	return encodeTestMap("iso_code", encodeTestString(isoCode), "names", encodeTestMap("en", encodeTestString(name)))
+}
+
+// writeTestMMDB creates a database with a London network, a Sudanese network and an IPv6 network
+func writeTestMMDB(t *testing.T) string {
+	w := newTestMMDBWriter()
+
+	sudan := w.addData(encodeTestCountry("Sudan", "SD"))
+
+	london := w.addData(encodeTestMap(
+		"city", encodeTestPlace("London"),
+		"country", encodeTestCountry("United Kingdom", "GB"),
+		"subdivisions", encodeTestArray(encodeTestPlace("England")),
+		"location", encodeTestMap("latitude", encodeTestDouble(51.5142), "longitude", encodeTestDouble(-0.0931)),
+	))
+	khartoum := w.addData(encodeTestMap(
+		"country", encodeTestPointer(sudan),
+		"location", encodeTestMap("latitude", encodeTestDouble(15.5), "longitude", encodeTestDouble(32.5)),
+	))
+	anycast := w.addData(encodeTestMap(
+		"registered_country", encodeTestPointer(sudan),
+	))
+
+	w.insert("81.2.69.0/24", london)
+	w.insert("197.252.0.0/16", khartoum)
+	w.insert("2001:db8::/32", anycast)
+
+	path := filepath.Join(t.TempDir(), "test.mmdb")
+	if err := os.WriteFile(path, w.bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestMMDBProviderLookup(t *testing.T) {
+	provider, err := NewIPLocationProvider(IPProviderMMDB, writeTestMMDB(t))
+	if err != nil {
+		t.Fatalf("Expected the database to open, but got %v", err)
+	}
+
+	testCases := []struct {
+		ip      string
+		country string
+		code    string
+		city    string
+		region  string
+		lat     string
+		lon     string
+	}{
+		{"81.2.69.160", "United Kingdom", "GB", "London", "England", "51.5142", "-0.0931"},
+		{"197.252.10.1", "Sudan", "SD", "", "", "15.5", "32.5"},
+		{"2001:db8::1", "Sudan", "SD", "", "", "", ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.ip, func(t *testing.T) {
+			location, err := provider.Lookup(context.Background(), tc.ip)
+			if err != nil {
+				t.Fatalf("Expected a location for %s, but got %v", tc.ip, err)
+			}
+			if location.Country != tc.country || location.City != tc.city || location.Region != tc.region {
+				t.Errorf("Expected %s/%s/%s, but got %s/%s/%s", tc.country, tc.region, tc.city, location.Country, location.Region, location.City)
+			}
+			if location.CountryCode != tc.code {
+				t.Errorf("Expected country code %s, but got %s", tc.code, location.CountryCode)
+			}
+			if location.Lat != tc.lat || location.Lon != tc.lon {
+				t.Errorf("Expected coordinates %s, %s, but got %s, %s", tc.lat, tc.lon, location.Lat, location.Lon)
+			}
+		})
+	}
+
+	for _, ip := range []string{"8.8.8.8", "81.2.70.1", "2001:db9::1", "not-an-ip"} {
+		if _, err := provider.Lookup(context.Background(), ip); err == nil {
+			t.Errorf("Expected an error for %s, but got none", ip)
+		}
+	}
+}
+
+func TestNewIPLocationProvider(t *testing.T) {
+	if _, err := NewIPLocationProvider("carrier-pigeon", ""); err == nil {
+		t.Errorf("Expected an error for an unknown provider, but got none")
+	}
+	if _, err := NewIPLocationProvider(IPProviderMMDB, ""); err == nil {
+		t.Errorf("Expected an error for the mmdb provider without a database, but got none")
+	}
+	if _, err := NewIPLocationProvider(IPProviderMMDB, filepath.Join(t.TempDir(), "missing.mmdb")); err == nil {
+		t.Errorf("Expected an error for a missing database, but got none")
+	}
+
+	provider, err := NewIPLocationProvider(IPProviderNone, "")
+	if err != nil {
+		t.Fatalf("Expected the none provider, but got %v", err)
+	}
+	if _, err := provider.Lookup(context.Background(), "81.2.69.160"); err == nil {
+		t.Errorf("Expected lookups to fail when geolocation is disabled, but got none")
+	}
+}
+
+func TestGetLocationFromIPUsesConfiguredProvider(t *testing.T) {
+	provider, err := OpenMMDBProvider(writeTestMMDB(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	SetIPLocationProvider(provider)
+	defer SetIPLocationProvider(NewIPAPIProvider())
+
+	location, err := GetLocationFromIP("81.2.69.160")
+	if err != nil {
+		t.Fatalf("Expected a location, but got %v", err)
+	}
+	if capital := GetCapitalCityForCountry(location.Country); capital != "London" {
+		t.Errorf("Expected capital London, but got %s", capital)
+	}
+}
@@ -0,0 +1,197 @@
+package utils
+
+import (
+	"math"
+	"sort"
+)
+
+// EarthRadiusKm is the mean radius of the Earth used for great-circle distances
+const EarthRadiusKm = 6371.0088
+
+// NearbyCityRadiusKm is how close a city must be to name a location after it
+const NearbyCityRadiusKm = 25.0
+
+// NearestCity is a city found by reverse geocoding, with the distance and direction to it
+type NearestCity struct {
+	City       City    `json:"city"`
+	DistanceKm float64 `json:"distance_km"`
+	Bearing    float64 `json:"bearing"`   // initial bearing from the query point, degrees clockwise from north
+	Direction  string  `json:"direction"` // 16 point compass direction of the bearing
+}
+
+// kdNode is a node of a 3-d tree over cities as unit vectors, so nearby points on either
+// side of the antimeridian or near the poles are also near each other in the tree
+type kdNode struct {
+	point       [3]float64
+	index       int
+	axis        int
+	left, right *kdNode
+}
+
+// unitVector converts latitude and longitude in degrees to a point on the unit sphere
+func unitVector(lat, lon float64) [3]float64 {
+	latRad := lat * math.Pi / 180
+	lonRad := lon * math.Pi / 180
+	return [3]float64{
+		math.Cos(latRad) * math.Cos(lonRad),
+		math.Cos(latRad) * math.Sin(lonRad),
+		math.Sin(latRad),
+	}
+}
+
+// buildKDTree builds a balanced tree from the given city indexes
+func buildKDTree(points [][3]float64, indexes []int, depth int) *kdNode {
+	if len(indexes) == 0 {
+		return nil
+	}
+	axis := depth % 3
+	sort.Slice(indexes, func(a, b int) bool {
+		return points[indexes[a]][axis] < points[indexes[b]][axis]
+	})
+	median := len(indexes) / 2
+	return &kdNode{
+		point: points[indexes[median]],
+		index: indexes[median],
+		axis:  axis,
+		left:  buildKDTree(points, indexes[:median], depth+1),
+		right: buildKDTree(points, indexes[median+1:], depth+1),
+	}
+}
+
+// kdCandidate is a city found during a search with its squared chord distance to the target
+type kdCandidate struct {
+	index    int
+	distance float64
+}
+
+// search keeps the k closest points to target in best, ordered nearest first
+func (n *kdNode) search(target [3]float64, k int, best []kdCandidate) []kdCandidate {
+	if n == nil {
+		return best
+	}
+
+	var distance float64
+	for i := range target {
+		d := n.point[i] - target[i]
+		distance += d * d
+	}
+	if len(best) < k || distance < best[len(best)-1].distance {
+		position := sort.Search(len(best), func(i int) bool { return best[i].distance > distance })
+		best = append(best, kdCandidate{})
+		copy(best[position+1:], best[position:])
+		best[position] = kdCandidate{index: n.index, distance: distance}
+		if len(best) > k {
+			best = best[:k]
+		}
+	}
+
+	diff := target[n.axis] - n.point[n.axis]
+	near, far := n.left, n.right
+	if diff > 0 {
+		near, far = n.right, n.left
+	}
+	best = near.search(target, k, best)
+	// Only cross the splitting plane if a closer point could be on the other side
+	if len(best) < k || diff*diff < best[len(best)-1].distance {
+		best = far.search(target, k, best)
+	}
+	return best
+}
+
+// NearestCities returns up to k cities closest to the coordinates, nearest first
+func (g *Gazetteer) NearestCities(lat, lon float64, k int) []NearestCity {
+	g.treeOnce.Do(func() {
+		points := make([][3]float64, len(g.cities))
+		indexes := make([]int, len(g.cities))
+		for i, city := range g.cities {
+			points[i] = unitVector(city.Latitude, city.Longitude)
+			indexes[i] = i
+		}
+		g.tree = buildKDTree(points, indexes, 0)
+	})
+
+	results := []NearestCity{}
+	if k <= 0 {
+		return results
+	}
+	for _, candidate := range g.tree.search(unitVector(lat, lon), k, nil) {
+		city := g.cities[candidate.index]
+		bearing := InitialBearing(lat, lon, city.Latitude, city.Longitude)
+		results = append(results, NearestCity{
+			City:       city,
+			DistanceKm: GreatCircleDistance(lat, lon, city.Latitude, city.Longitude),
+			Bearing:    bearing,
+			Direction:  CompassDirection(bearing),
+		})
+	}
+	return results
+}
+
+// NearestCity returns the city closest to the coordinates
+func (g *Gazetteer) NearestCity(lat, lon float64) (NearestCity, bool) {
+	nearest := g.NearestCities(lat, lon, 1)
+	if len(nearest) == 0 {
+		return NearestCity{}, false
+	}
+	return nearest[0], true
+}
+
+// FindNearestCity returns the city in the active gazetteer closest to the coordinates
+func FindNearestCity(lat, lon float64) (NearestCity, bool) {
+	return DefaultGazetteer().NearestCity(lat, lon)
+}
+
+// FindNearbyCity returns the closest city if it is within NearbyCityRadiusKm of the coordinates
+func FindNearbyCity(lat, lon float64) (City, bool) {
+	nearest, ok := FindNearestCity(lat, lon)
+	if !ok || nearest.DistanceKm > NearbyCityRadiusKm {
+		return City{}, false
+	}
+	return nearest.City, true
+}
+
+// GreatCircleDistance returns the haversine distance in kilometers between two points
+func GreatCircleDistance(lat1, lon1, lat2, lon2 float64) float64 {
+	lat1Rad := lat1 * math.Pi / 180
+	lat2Rad := lat2 * math.Pi / 180
+	dLat := (lat2 - lat1) * math.Pi / 180
+	dLon := (lon2 - lon1) * math.Pi / 180
+
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1Rad)*math.Cos(lat2Rad)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * EarthRadiusKm * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// InitialBearing returns the direction to travel from the first point to reach the second
+// along a great circle, in degrees clockwise from north
+func InitialBearing(lat1, lon1, lat2, lon2 float64) float64 {
+	lat1Rad := lat1 * math.Pi / 180
+	lat2Rad := lat2 * math.Pi / 180
+	dLon := (lon2 - lon1) * math.Pi / 180
+
+	y := math.Sin(dLon) * math.Cos(lat2Rad)
+	x := math.Cos(lat1Rad)*math.Sin(lat2Rad) - math.Sin(lat1Rad)*math.Cos(lat2Rad)*math.Cos(dLon)
+	bearing := math.Atan2(y, x) * 180 / math.Pi
+	return math.Mod(bearing+360, 360)
+}
+
+// DestinationPoint returns the point reached by traveling a distance in kilometers from a start point
+// along a great circle with the given initial bearing in degrees clockwise from north
+func DestinationPoint(lat, lon, bearing, distanceKm float64) (float64, float64) {
+	latRad := lat * math.Pi / 180
+	bearingRad := bearing * math.Pi / 180
+	angular := distanceKm / EarthRadiusKm
+
+	destLat := math.Asin(math.Sin(latRad)*math.Cos(angular) + math.Cos(latRad)*math.Sin(angular)*math.Cos(bearingRad))
+	dLon := math.Atan2(math.Sin(bearingRad)*math.Sin(angular)*math.Cos(latRad), math.Cos(angular)-math.Sin(latRad)*math.Sin(destLat))
+	destLon := math.Mod(lon+dLon*180/math.Pi+540, 360) - 180
+	return destLat * 180 / math.Pi, destLon
+}
+
+// compassPoints are the 16 compass directions starting from north
+var compassPoints = []string{"N", "NNE", "NE", "ENE", "E", "ESE", "SE", "SSE", "S", "SSW", "SW", "WSW", "W", "WNW", "NW", "NNW"}
+
+// CompassDirection names the 16 point compass direction closest to a bearing
+func CompassDirection(bearing float64) string {
+	index := int(math.Round(math.Mod(bearing+360, 360)/22.5)) % len(compassPoints)
+	return compassPoints[index]
+}
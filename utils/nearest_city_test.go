@@ -0,0 +1,87 @@
+package utils
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestGreatCircleDistanceAndBearing(t *testing.T) {
+	testCases := []struct {
+		name                        string
+		lat1, lon1, lat2, lon2      float64
+		expectedKm, expectedBearing float64
+		direction                   string
+	}{
+		{"London to Paris", 51.5074, -0.1278, 48.8566, 2.3522, 343.6, 148.1, "SSE"},
+		{"Along the equator", 0, 0, 0, 1, 111.2, 90, "E"},
+		{"Across the antimeridian", 0, 179.5, 0, -179.5, 111.2, 90, "E"},
+		{"Due north", 10, 20, 20, 20, 1111.9, 0, "N"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			distance := GreatCircleDistance(tc.lat1, tc.lon1, tc.lat2, tc.lon2)
+			if math.Abs(distance-tc.expectedKm) > 0.5 {
+				t.Errorf("Expected distance %.1f km, but got %.1f km", tc.expectedKm, distance)
+			}
+			bearing := InitialBearing(tc.lat1, tc.lon1, tc.lat2, tc.lon2)
+			if math.Abs(bearing-tc.expectedBearing) > 0.5 {
+				t.Errorf("Expected bearing %.1f, but got %.1f", tc.expectedBearing, bearing)
+			}
+			if direction := CompassDirection(bearing); direction != tc.direction {
+				t.Errorf("Expected direction %s, but got %s", tc.direction, direction)
+			}
+		})
+	}
+}
+
+func TestNearestCitiesMatchesLinearScan(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	cities := make([]City, 2000)
+	for i := range cities {
+		cities[i] = City{Name: "City", Latitude: random.Float64()*180 - 90, Longitude: random.Float64()*360 - 180}
+	}
+	gazetteer := NewGazetteer(cities)
+
+	for i := 0; i < 200; i++ {
+		lat, lon := random.Float64()*180-90, random.Float64()*360-180
+
+		bestDistance := math.Inf(1)
+		for _, city := range cities {
+			bestDistance = math.Min(bestDistance, GreatCircleDistance(lat, lon, city.Latitude, city.Longitude))
+		}
+
+		nearest := gazetteer.NearestCities(lat, lon, 3)
+		if len(nearest) != 3 {
+			t.Fatalf("Expected 3 cities, but got %d", len(nearest))
+		}
+		if math.Abs(nearest[0].DistanceKm-bestDistance) > 1e-6 {
+			t.Fatalf("Expected the nearest city at %.3f km from (%.3f, %.3f), but got %.3f km", bestDistance, lat, lon, nearest[0].DistanceKm)
+		}
+		if nearest[0].DistanceKm > nearest[1].DistanceKm || nearest[1].DistanceKm > nearest[2].DistanceKm {
+			t.Errorf("Expected cities sorted by distance, but got %v", nearest)
+		}
+	}
+}
+
+func TestFindNearbyCity(t *testing.T) {
+	testCases := []struct {
+		name     string
+		lat, lon float64
+		city     string
+	}{
+		{"Exact coordinates", 15.5007, 32.5599, "Khartoum"},
+		{"Just outside Reykjavik", 64.10, -21.80, "Reykjavik"},
+		{"Middle of the Pacific", 0, 180, ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			city, ok := FindNearbyCity(tc.lat, tc.lon)
+			if ok != (tc.city != "") || city.Name != tc.city {
+				t.Errorf("Expected %q, but got %q (found %v)", tc.city, city.Name, ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+)
+
+// Standard atmosphere at sea level used by the refraction formulas
+const (
+	StandardPressure    = 1010.0 // millibars
+	StandardTemperature = 10.0   // degrees Celsius
+)
+
+// Observer represents a point on the Earth's surface and the local atmospheric conditions
+type Observer struct {
+	Latitude    float64  `json:"latitude"`
+	Longitude   float64  `json:"longitude"`
+	Elevation   float64  `json:"elevation"`        // meters above sea level
+	Height      *float64 `json:"height,omitempty"` // meters above the surrounding terrain; overrides Elevation for the horizon dip
+	Pressure    float64  `json:"pressure"`         // millibars
+	Temperature float64  `json:"temperature"`      // degrees Celsius
+}
+
+// NewObserver returns an observer at sea level in a standard atmosphere
+func NewObserver(latitude, longitude float64) Observer {
+	return Observer{
+		Latitude:    latitude,
+		Longitude:   longitude,
+		Pressure:    StandardPressure,
+		Temperature: StandardTemperature,
+	}
+}
+
+// PressureAtElevation estimates the mean air pressure in millibars at an elevation in meters
+// using the barometric formula, scaled so that sea level matches StandardPressure
+func PressureAtElevation(elevation float64) float64 {
+	if elevation <= 0 {
+		return StandardPressure
+	}
+	return StandardPressure * math.Pow(1-2.25577e-5*elevation, 5.25588)
+}
+
+// ObserverRangeError reports an observer value outside its physically meaningful range
+type ObserverRangeError struct {
+	Field string // latitude, longitude, elevation, height, pressure or temperature
+	Value float64
+	Min   float64
+	Max   float64
+}
+
+func (e *ObserverRangeError) Error() string {
+	return fmt.Sprintf("%s out of range: %f", e.Field, e.Value)
+}
+
+// Validate checks that the observer's values are physically meaningful, returning an *ObserverRangeError if not
+func (o Observer) Validate() error {
+	checks := []ObserverRangeError{
+		{Field: "latitude", Value: o.Latitude, Min: -90, Max: 90},
+		{Field: "longitude", Value: o.Longitude, Min: -180, Max: 180},
+		{Field: "elevation", Value: o.Elevation, Min: -500, Max: 10000},
+		{Field: "pressure", Value: o.Pressure, Min: 0, Max: 1100},
+		{Field: "temperature", Value: o.Temperature, Min: -90, Max: 60},
+	}
+	if o.Height != nil {
+		checks = append(checks, ObserverRangeError{Field: "height", Value: *o.Height, Min: 0, Max: 10000})
+	}
+	for _, check := range checks {
+		// Written so that NaN fails; pressure must be strictly positive
+		if !(check.Value >= check.Min && check.Value <= check.Max) || (check.Field == "pressure" && check.Value <= 0) {
+			return &check
+		}
+	}
+	return nil
+}
+
+// RefractionScale returns the factor applied to standard-atmosphere refraction for the observer's pressure and temperature
+func (o Observer) RefractionScale() float64 {
+	return (o.Pressure / StandardPressure) * ((273.0 + StandardTemperature) / (273.0 + o.Temperature))
+}
+
+// HorizonDip returns how far below the astronomical horizon the visible horizon lies, in degrees,
+// for an observer raised above the surrounding terrain. The elevation is taken as that height unless
+// Height is set, as on a plateau where the horizon is the plateau itself rather than the sea.
+func (o Observer) HorizonDip() float64 {
+	height := o.Elevation
+	if o.Height != nil {
+		height = *o.Height
+	}
+	if height <= 0 {
+		return 0
+	}
+	// 1.76 arc minutes per square root of meter, including terrestrial refraction
+	return 1.76 * math.Sqrt(height) / 60.0
+}
+
+// SunriseAltitude returns the geometric altitude of the sun's center at sunrise and sunset, in degrees,
+// taking the sun's radius, the observer's refraction and the horizon dip into account
+func (o Observer) SunriseAltitude() float64 {
+	return -(spaSunRadius + spaAtmosRefract*o.RefractionScale() + o.HorizonDip())
+}
@@ -0,0 +1,96 @@
+package utils
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestObserverRefractionScaleStandardAtmosphere(t *testing.T) {
+	observer := NewObserver(15.5007, 32.5599)
+	if scale := observer.RefractionScale(); math.Abs(scale-1) > 1e-12 {
+		t.Errorf("Expected refraction scale 1 for a standard atmosphere, but got %f", scale)
+	}
+	if altitude := observer.SunriseAltitude(); math.Abs(altitude+0.833) > 0.001 {
+		t.Errorf("Expected sunrise altitude -0.833 at sea level, but got %f", altitude)
+	}
+}
+
+func TestPressureAtElevation(t *testing.T) {
+	testCases := []struct {
+		elevation float64
+		min       float64
+		max       float64
+	}{
+		{0, StandardPressure, StandardPressure},
+		{1500, 830, 850},
+		{4000, 610, 625},
+	}
+
+	for _, tc := range testCases {
+		pressure := PressureAtElevation(tc.elevation)
+		if pressure < tc.min || pressure > tc.max {
+			t.Errorf("Expected pressure between %f and %f at %f m, but got %f", tc.min, tc.max, tc.elevation, pressure)
+		}
+	}
+}
+
+func TestSunriseEarlierFromHighElevation(t *testing.T) {
+	date := time.Date(2026, time.June, 21, 12, 0, 0, 0, time.UTC)
+	seaLevel := NewObserver(-16.5, -68.15)
+	mountain := seaLevel
+	mountain.Elevation = 3640
+	mountain.Pressure = PressureAtElevation(mountain.Elevation)
+
+
+	seaSunrise, seaSunset := CalculateSunriseSunsetForObserver(seaLevel, date)
+	highSunrise, highSunset := CalculateSunriseSunsetForObserver(mountain, date)
+
+	if !highSunrise.Before(seaSunrise) {
+		t.Errorf("Expected sunrise at elevation (%v) before sea level sunrise (%v)", highSunrise, seaSunrise)
+	}
+	if !highSunset.After(seaSunset) {
+		t.Errorf("Expected sunset at elevation (%v) after sea level sunset (%v)", highSunset, seaSunset)
+	}
+
+	// On the La Paz plateau the horizon is the plateau, so an explicit height above it replaces the elevation
+	if dip := mountain.HorizonDip(); math.Abs(dip-1.76*math.Sqrt(3640)/60) > 1e-9 {
+		t.Errorf("Expected the dip from the elevation, but got %f", dip)
+	}
+	plateau := 0.0
+	mountain.Height = &plateau
+	if dip := mountain.HorizonDip(); dip != 0 {
+		t.Errorf("Expected no horizon dip on a plateau, but got %f", dip)
+	}
+}
+
+func TestObserverValidate(t *testing.T) {
+	observer := NewObserver(91, 0)
+	if err := observer.Validate(); err == nil {
+		t.Errorf("Expected an error for latitude 91, but got none")
+	}
+
+	observer = NewObserver(45, 10)
+	observer.Elevation = math.NaN()
+	if err := observer.Validate(); err == nil {
+		t.Errorf("Expected an error for a NaN elevation, but got none")
+	}
+
+	observer = NewObserver(45, 10)
+	height := -1.0
+	observer.Height = &height
+	if err := observer.Validate(); err == nil {
+		t.Errorf("Expected an error for a negative height, but got none")
+	}
+
+	observer = NewObserver(45, 10)
+	observer.Pressure = -1
+	err := observer.Validate()
+	rangeErr, ok := err.(*ObserverRangeError)
+	if !ok {
+		t.Fatalf("Expected an *ObserverRangeError for negative pressure, but got %v", err)
+	}
+	if rangeErr.Field != "pressure" {
+		t.Errorf("Expected the pressure field to be reported, but got %s", rangeErr.Field)
+	}
+}
@@ -0,0 +1,215 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// TranspositionModel selects how diffuse sky irradiance is carried onto a tilted surface
+type TranspositionModel string
+
+const (
+	// TranspositionIsotropic treats the sky as uniformly bright (Liu and Jordan 1963)
+	TranspositionIsotropic TranspositionModel = "isotropic"
+	// TranspositionHayDavies adds a circumsolar component weighted by the beam's strength (Hay and Davies 1980)
+	TranspositionHayDavies TranspositionModel = "haydavies"
+)
+
+// ParseTranspositionModel converts a user supplied name into a TranspositionModel, defaulting to TranspositionHayDavies when empty
+func ParseTranspositionModel(name string) (TranspositionModel, error) {
+	switch TranspositionModel(strings.ToLower(strings.TrimSpace(name))) {
+	case "", TranspositionHayDavies:
+		return TranspositionHayDavies, nil
+	case TranspositionIsotropic:
+		return TranspositionIsotropic, nil
+	}
+	return "", fmt.Errorf("unknown transposition model: %s", name)
+}
+
+// Panel is the orientation of a flat solar panel
+type Panel struct {
+	Tilt    float64 `json:"tilt"`    // degrees from horizontal, 0 to 90
+	Azimuth float64 `json:"azimuth"` // direction the panel faces, degrees eastward from north (180 faces south)
+}
+
+// POAIrradiance is the irradiance on the plane of a panel, in W/m², and the sun's angle of incidence
+type POAIrradiance struct {
+	Total            float64 `json:"total"`
+	Direct           float64 `json:"direct"`
+	SkyDiffuse       float64 `json:"sky_diffuse"`
+	GroundReflected  float64 `json:"ground_reflected"`
+	AngleOfIncidence float64 `json:"angle_of_incidence"` // degrees between the sun and the panel normal
+}
+
+// AngleOfIncidence returns the angle between the sun and the normal of a panel, in degrees,
+// for the sun's zenith angle and azimuth. Angles over 90 mean the sun is behind the panel.
+func AngleOfIncidence(panel Panel, zenith, azimuth float64) float64 {
+	return radToDeg(math.Acos(cosAngleOfIncidence(panel, zenith, azimuth)))
+}
+
+// cosAngleOfIncidence returns the cosine of the angle of incidence, clamped to [-1, 1]
+func cosAngleOfIncidence(panel Panel, zenith, azimuth float64) float64 {
+	tilt := degToRad(panel.Tilt)
+	z := degToRad(zenith)
+	cosAOI := math.Cos(z)*math.Cos(tilt) + math.Sin(z)*math.Sin(tilt)*math.Cos(degToRad(azimuth-panel.Azimuth))
+	return math.Max(-1, math.Min(1, cosAOI))
+}
+
+// PlaneOfArrayIrradiance transposes horizontal irradiance onto a panel for the sun's zenith angle and
+// azimuth. extraterrestrial is the normal irradiance at the top of the atmosphere, used by Hay-Davies
+// to estimate the circumsolar brightening, and albedo is the reflectance of the ground in front of the panel.
+func PlaneOfArrayIrradiance(model TranspositionModel, panel Panel, zenith, azimuth float64, irradiance Irradiance, extraterrestrial, albedo float64) POAIrradiance {
+	cosAOI := cosAngleOfIncidence(panel, zenith, azimuth)
+	poa := POAIrradiance{AngleOfIncidence: radToDeg(math.Acos(cosAOI))}
+	if zenith >= 90 || irradiance.GHI <= 0 {
+		return poa
+	}
+
+	cosTilt := math.Cos(degToRad(panel.Tilt))
+	poa.Direct = irradiance.DNI * math.Max(cosAOI, 0)
+	poa.GroundReflected = irradiance.GHI * albedo * (1 - cosTilt) / 2
+
+	isotropic := (1 + cosTilt) / 2
+	switch model {
+	case TranspositionIsotropic:
+		poa.SkyDiffuse = irradiance.DHI * isotropic
+	default:
+		// The anisotropy index is the fraction of the diffuse light treated as coming from the sun's direction;
+		// the zenith cosine is floored at 1° of altitude so the beam ratio stays finite at sunrise
+		anisotropy := math.Min(irradiance.DNI/extraterrestrial, 1)
+		beamRatio := math.Max(cosAOI, 0) / math.Max(math.Cos(degToRad(zenith)), 0.01745)
+		poa.SkyDiffuse = irradiance.DHI * (anisotropy*beamRatio + (1-anisotropy)*isotropic)
+	}
+
+	poa.Total = poa.Direct + poa.SkyDiffuse + poa.GroundReflected
+	return poa
+}
+
+// PanelSimulation holds the settings for estimating a panel's clear-sky energy yield
+type PanelSimulation struct {
+	Model         ClearSkyModel
+	Transposition TranspositionModel
+	Algorithm     Algorithm
+	Observer      Observer
+	Conditions    ClearSkyConditions // the albedo also sets the ground reflectance for transposition
+	Step          time.Duration      // sampling interval; DefaultPanelStep when zero
+}
+
+// DefaultPanelStep is the sampling interval for panel energy estimates
+const DefaultPanelStep = 10 * time.Minute
+
+// irradianceSample is the sun's position and the clear-sky irradiance at one moment, weighted by
+// the hours it stands for
+type irradianceSample struct {
+	zenith           float64
+	azimuth          float64
+	irradiance       Irradiance
+	extraterrestrial float64
+	hours            float64
+}
+
+// samples returns the daylight samples of the local day containing date, each weighted by weight days
+func (s PanelSimulation) samples(date time.Time, weight float64) []irradianceSample {
+	step := s.Step
+	if step <= 0 {
+		step = DefaultPanelStep
+	}
+
+	year, month, day := date.Date()
+	start := time.Date(year, month, day, 0, 0, 0, 0, date.Location())
+	end := time.Date(year, month, day+1, 0, 0, 0, 0, date.Location())
+
+	// The Earth-sun distance barely changes in a day, so evaluate it at noon
+	extraterrestrial := ExtraterrestrialIrradiance(start.Add(12 * time.Hour))
+
+	var samples []irradianceSample
+	for t := start.Add(step / 2); t.Before(end); t = t.Add(step) {
+		altitude, azimuth := CalculateSunPositionForObserver(s.Algorithm, s.Observer, t)
+		if altitude <= 0 {
+			continue
+		}
+		samples = append(samples, irradianceSample{
+			zenith:           90 - altitude,
+			azimuth:          azimuth,
+			irradiance:       ClearSkyIrradiance(s.Model, 90-altitude, extraterrestrial, s.Observer, s.Conditions),
+			extraterrestrial: extraterrestrial,
+			hours:            step.Hours() * weight,
+		})
+	}
+	return samples
+}
+
+// energy integrates the plane-of-array irradiance over the samples, in kWh/m²
+func (s PanelSimulation) energy(samples []irradianceSample, panel Panel) float64 {
+	var wattHours float64
+	for _, sample := range samples {
+		poa := PlaneOfArrayIrradiance(s.Transposition, panel, sample.zenith, sample.azimuth, sample.irradiance, sample.extraterrestrial, s.Conditions.Albedo)
+		wattHours += poa.Total * sample.hours
+	}
+	return wattHours / 1000
+}
+
+// DailyEnergy returns the clear-sky energy reaching a panel over the local day containing date, in kWh/m²
+func (s PanelSimulation) DailyEnergy(panel Panel, date time.Time) float64 {
+	return s.energy(s.samples(date, 1), panel)
+}
+
+// annualSamples returns one representative day per month, the 15th, weighted by the days in the month
+func (s PanelSimulation) annualSamples(year int, location *time.Location) []irradianceSample {
+	var samples []irradianceSample
+	for month := time.January; month <= time.December; month++ {
+		days := time.Date(year, month+1, 0, 0, 0, 0, 0, location).Day()
+		samples = append(samples, s.samples(time.Date(year, month, 15, 12, 0, 0, 0, location), float64(days))...)
+	}
+	return samples
+}
+
+// AnnualEnergy estimates the clear-sky energy reaching a panel over a year, in kWh/m²
+func (s PanelSimulation) AnnualEnergy(panel Panel, year int, location *time.Location) float64 {
+	return s.energy(s.annualSamples(year, location), panel)
+}
+
+// OptimalOrientation finds the tilt and azimuth with the largest clear-sky annual yield and returns it
+// with that yield in kWh/m². A 5° by 10° grid is searched first and the best cell refined to 1°.
+func (s PanelSimulation) OptimalOrientation(year int, location *time.Location) (Panel, float64) {
+	samples := s.annualSamples(year, location)
+
+	best := Panel{}
+	bestEnergy := s.energy(samples, best)
+	search := func(tilts, azimuths []float64) {
+		for _, tilt := range tilts {
+			for _, azimuth := range azimuths {
+				panel := Panel{Tilt: tilt, Azimuth: azimuth}
+				if energy := s.energy(samples, panel); energy > bestEnergy {
+					best, bestEnergy = panel, energy
+				}
+			}
+		}
+	}
+
+	search(degreeRange(5, 90, 5), degreeRange(0, 350, 10))
+
+	var tilts, azimuths []float64
+	for _, tilt := range degreeRange(best.Tilt-4, best.Tilt+4, 1) {
+		if tilt >= 0 && tilt <= 90 {
+			tilts = append(tilts, tilt)
+		}
+	}
+	for _, azimuth := range degreeRange(best.Azimuth-9, best.Azimuth+9, 1) {
+		azimuths = append(azimuths, limitDegrees(azimuth))
+	}
+	search(tilts, azimuths)
+
+	return best, bestEnergy
+}
+
+// degreeRange returns the values from first to last inclusive in steps of step
+func degreeRange(first, last, step float64) []float64 {
+	var values []float64
+	for v := first; v <= last; v += step {
+		values = append(values, v)
+	}
+	return values
+}
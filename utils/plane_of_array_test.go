@@ -0,0 +1,143 @@
+package utils
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestAngleOfIncidence(t *testing.T) {
+	testCases := []struct {
+		name     string
+		panel    Panel
+		zenith   float64
+		azimuth  float64
+		expected float64
+	}{
+		{"Flat panel", Panel{Tilt: 0, Azimuth: 180}, 35, 120, 35},
+		{"Facing the sun", Panel{Tilt: 40, Azimuth: 200}, 40, 200, 0},
+		{"Vertical wall at noon", Panel{Tilt: 90, Azimuth: 180}, 60, 180, 30},
+		{"Sun off to the side", Panel{Tilt: 90, Azimuth: 180}, 90, 90, 90},
+		{"Sun grazing the back", Panel{Tilt: 30, Azimuth: 180}, 60, 0, 90},
+		{"Sun behind the panel", Panel{Tilt: 60, Azimuth: 180}, 45, 0, 105},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := AngleOfIncidence(tc.panel, tc.zenith, tc.azimuth); math.Abs(got-tc.expected) > 1e-9 {
+				t.Errorf("Expected %f, but got %f", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestPlaneOfArrayIrradiance(t *testing.T) {
+	irradiance := Irradiance{GHI: 800, DNI: 850, DHI: 800 - 850*math.Cos(degToRad(30))}
+
+	// A flat panel receives exactly the horizontal irradiance under both models
+	for _, model := range []TranspositionModel{TranspositionIsotropic, TranspositionHayDavies} {
+		poa := PlaneOfArrayIrradiance(model, Panel{}, 30, 180, irradiance, SolarConstant, 0.2)
+		if math.Abs(poa.Total-irradiance.GHI) > 1e-9 || poa.GroundReflected != 0 {
+			t.Errorf("Expected %s on a flat panel to equal GHI %f, but got %+v", model, irradiance.GHI, poa)
+		}
+	}
+
+	// Isotropic: beam at normal incidence, half the sky and half the ground for a vertical panel
+	wall := Panel{Tilt: 90, Azimuth: 180}
+	poa := PlaneOfArrayIrradiance(TranspositionIsotropic, wall, 90-1e-9, 180, irradiance, SolarConstant, 0.2)
+	if math.Abs(poa.Direct-850) > 1e-3 || math.Abs(poa.SkyDiffuse-irradiance.DHI/2) > 1e-6 || math.Abs(poa.GroundReflected-80) > 1e-6 {
+		t.Errorf("Expected direct 850, sky %f and ground 80, but got %+v", irradiance.DHI/2, poa)
+	}
+
+	// Hay-Davies sends part of the diffuse light along the beam, so a panel facing the sun gains
+	facing := Panel{Tilt: 30, Azimuth: 180}
+	isotropic := PlaneOfArrayIrradiance(TranspositionIsotropic, facing, 30, 180, irradiance, SolarConstant, 0.2)
+	hayDavies := PlaneOfArrayIrradiance(TranspositionHayDavies, facing, 30, 180, irradiance, SolarConstant, 0.2)
+	if hayDavies.SkyDiffuse <= isotropic.SkyDiffuse || hayDavies.Direct != isotropic.Direct {
+		t.Errorf("Expected more sky diffuse from Hay-Davies facing the sun, but got %+v and %+v", isotropic, hayDavies)
+	}
+	if hayDavies.AngleOfIncidence > 1e-6 {
+		t.Errorf("Expected normal incidence, but got %f", hayDavies.AngleOfIncidence)
+	}
+
+	// With the sun behind the panel there is no beam, and below the horizon nothing at all
+	behind := PlaneOfArrayIrradiance(TranspositionHayDavies, Panel{Tilt: 70, Azimuth: 0}, 30, 180, irradiance, SolarConstant, 0.2)
+	if behind.Direct != 0 || behind.SkyDiffuse <= 0 {
+		t.Errorf("Expected diffuse light only with the sun behind the panel, but got %+v", behind)
+	}
+	if night := PlaneOfArrayIrradiance(TranspositionHayDavies, facing, 100, 180, Irradiance{}, SolarConstant, 0.2); night.Total != 0 {
+		t.Errorf("Expected nothing at night, but got %+v", night)
+	}
+}
+
+func TestParseTranspositionModel(t *testing.T) {
+	for name, expected := range map[string]TranspositionModel{"": TranspositionHayDavies, "Isotropic": TranspositionIsotropic, "haydavies": TranspositionHayDavies} {
+		if model, err := ParseTranspositionModel(name); err != nil || model != expected {
+			t.Errorf("Expected %s for %q, but got %s (%v)", expected, name, model, err)
+		}
+	}
+	if _, err := ParseTranspositionModel("perez"); err == nil {
+		t.Errorf("Expected an error for an unknown model, but got none")
+	}
+}
+
+func TestPanelSimulationDailyEnergy(t *testing.T) {
+	simulation := PanelSimulation{
+		Model:         ClearSkyIneichen,
+		Transposition: TranspositionHayDavies,
+		Algorithm:     AlgorithmFast,
+		Observer:      NewObserver(40.4168, -3.7038),
+		Conditions:    DefaultClearSkyConditions(),
+	}
+	madrid, _ := time.LoadLocation("Europe/Madrid")
+
+	summer := time.Date(2026, time.June, 21, 0, 0, 0, 0, madrid)
+	winter := time.Date(2026, time.December, 21, 0, 0, 0, 0, madrid)
+	south := Panel{Tilt: 40, Azimuth: 180}
+
+	// A clear midsummer day in Madrid gives about 8 kWh/m² on the horizontal
+	if flat := simulation.DailyEnergy(Panel{}, summer); flat < 7 || flat > 9 {
+		t.Errorf("Expected about 8 kWh/m² on a flat panel in June, but got %f", flat)
+	}
+	// In winter tilting toward the low sun more than doubles the yield
+	if flat, tilted := simulation.DailyEnergy(Panel{}, winter), simulation.DailyEnergy(south, winter); tilted < 2*flat {
+		t.Errorf("Expected a tilted panel to more than double the flat yield in December, but got %f and %f", tilted, flat)
+	}
+	if north := simulation.DailyEnergy(Panel{Tilt: 40, Azimuth: 0}, winter); north >= simulation.DailyEnergy(south, winter) {
+		t.Errorf("Expected a north facing panel to collect less in December, but got %f", north)
+	}
+}
+
+func TestPanelSimulationOptimalOrientation(t *testing.T) {
+	testCases := []struct {
+		name      string
+		latitude  float64
+		longitude float64
+		azimuth   float64
+		minTilt   float64
+		maxTilt   float64
+	}{
+		{"Madrid faces south", 40.4168, -3.7038, 180, 30, 45},
+		{"Sydney faces north", -33.8688, 151.2093, 0, 25, 40},
+		{"Reykjavik tilts steeply", 64.1466, -21.9426, 180, 45, 60},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			simulation := PanelSimulation{
+				Model:         ClearSkyIneichen,
+				Transposition: TranspositionHayDavies,
+				Algorithm:     AlgorithmFast,
+				Observer:      NewObserver(tc.latitude, tc.longitude),
+				Conditions:    DefaultClearSkyConditions(),
+			}
+			panel, energy := simulation.OptimalOrientation(2026, time.UTC)
+			if math.Abs(panel.Azimuth-tc.azimuth) > 5 || panel.Tilt < tc.minTilt || panel.Tilt > tc.maxTilt {
+				t.Errorf("Expected a tilt from %.0f to %.0f facing %.0f, but got %+v", tc.minTilt, tc.maxTilt, tc.azimuth, panel)
+			}
+			if flat := simulation.AnnualEnergy(Panel{}, 2026, time.UTC); energy <= flat {
+				t.Errorf("Expected the optimum to beat a flat panel, but got %f and %f", energy, flat)
+			}
+		})
+	}
+}
@@ -0,0 +1,220 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// PrayerMethod selects the convention for the Fajr and Isha twilight angles
+type PrayerMethod string
+
+const (
+	// PrayerMethodMWL is the Muslim World League convention, used in Europe and the Far East
+	PrayerMethodMWL PrayerMethod = "mwl"
+	// PrayerMethodISNA is the Islamic Society of North America convention
+	PrayerMethodISNA PrayerMethod = "isna"
+	// PrayerMethodUmmAlQura is the Umm al-Qura University, Makkah convention used in the Arabian Peninsula
+	PrayerMethodUmmAlQura PrayerMethod = "ummalqura"
+	// PrayerMethodEgyptian is the Egyptian General Authority of Survey convention, used in Africa and the Levant
+	PrayerMethodEgyptian PrayerMethod = "egyptian"
+	// PrayerMethodKarachi is the University of Islamic Sciences, Karachi convention used in South Asia
+	PrayerMethodKarachi PrayerMethod = "karachi"
+)
+
+// PrayerConvention holds the sun's depression below the horizon at Fajr and Isha, in degrees.
+// Conventions with an Isha interval put Isha that long after Maghrib instead of using an angle.
+type PrayerConvention struct {
+	FajrAngle           float64
+	IshaAngle           float64
+	IshaInterval        time.Duration
+	RamadanIshaInterval time.Duration // replaces IshaInterval during Ramadan when set
+}
+
+// prayerConventions are the published angles of each method
+var prayerConventions = map[PrayerMethod]PrayerConvention{
+	PrayerMethodMWL:       {FajrAngle: 18, IshaAngle: 17},
+	PrayerMethodISNA:      {FajrAngle: 15, IshaAngle: 15},
+	PrayerMethodUmmAlQura: {FajrAngle: 18.5, IshaInterval: 90 * time.Minute, RamadanIshaInterval: 120 * time.Minute},
+	PrayerMethodEgyptian:  {FajrAngle: 19.5, IshaAngle: 17.5},
+	PrayerMethodKarachi:   {FajrAngle: 18, IshaAngle: 18},
+}
+
+// ParsePrayerMethod converts a user supplied name into a PrayerMethod, defaulting to PrayerMethodMWL when empty
+func ParsePrayerMethod(name string) (PrayerMethod, error) {
+	method := PrayerMethod(strings.ToLower(strings.TrimSpace(name)))
+	if method == "" {
+		return PrayerMethodMWL, nil
+	}
+	if _, ok := prayerConventions[method]; ok {
+		return method, nil
+	}
+	return "", fmt.Errorf("unknown prayer method: %s", name)
+}
+
+// Convention returns the angles used by the method
+func (m PrayerMethod) Convention() PrayerConvention {
+	return prayerConventions[m]
+}
+
+// IshaAfterMaghrib returns the time from Maghrib to Isha, or 0 when Isha is set by an angle
+func (c PrayerConvention) IshaAfterMaghrib(ramadan bool) time.Duration {
+	if ramadan && c.RamadanIshaInterval > 0 {
+		return c.RamadanIshaInterval
+	}
+	return c.IshaInterval
+}
+
+// AsrMethod selects the shadow length that marks the start of Asr
+type AsrMethod string
+
+const (
+	// AsrShafii starts Asr when a shadow is its object's length longer than at noon (Shafi'i, Maliki and Hanbali)
+	AsrShafii AsrMethod = "shafii"
+	// AsrHanafi starts Asr when a shadow is twice its object's length longer than at noon
+	AsrHanafi AsrMethod = "hanafi"
+)
+
+// ParseAsrMethod converts a user supplied name into an AsrMethod, defaulting to AsrShafii when empty
+func ParseAsrMethod(name string) (AsrMethod, error) {
+	switch AsrMethod(strings.ToLower(strings.TrimSpace(name))) {
+	case "", AsrShafii:
+		return AsrShafii, nil
+	case AsrHanafi:
+		return AsrHanafi, nil
+	}
+	return "", fmt.Errorf("unknown Asr method: %s", name)
+}
+
+// shadowFactor is the shadow length, in object heights, added to the noon shadow at Asr
+func (m AsrMethod) shadowFactor() float64 {
+	if m == AsrHanafi {
+		return 2
+	}
+	return 1
+}
+
+// HighLatitudeRule selects how Fajr and Isha are placed when twilight lasts all night, as in summer
+// above about 48° latitude, or when the angle-based times fall unreasonably far from sunrise and sunset
+type HighLatitudeRule string
+
+const (
+	// HighLatitudeNone leaves Fajr and Isha missing when the sun doesn't reach their angles
+	HighLatitudeNone HighLatitudeRule = "none"
+	// HighLatitudeMiddleOfNight limits Fajr and Isha to the middle of the night
+	HighLatitudeMiddleOfNight HighLatitudeRule = "middle_of_night"
+	// HighLatitudeOneSeventh limits Fajr to the last seventh of the night and Isha to the first seventh
+	HighLatitudeOneSeventh HighLatitudeRule = "one_seventh"
+	// HighLatitudeAngleBased limits them to angle/60 of the night, e.g. a third for an 18° Fajr
+	HighLatitudeAngleBased HighLatitudeRule = "angle_based"
+)
+
+// ParseHighLatitudeRule converts a user supplied name into a HighLatitudeRule, defaulting to HighLatitudeAngleBased when empty
+func ParseHighLatitudeRule(name string) (HighLatitudeRule, error) {
+	switch HighLatitudeRule(strings.ToLower(strings.TrimSpace(name))) {
+	case "", HighLatitudeAngleBased:
+		return HighLatitudeAngleBased, nil
+	case HighLatitudeNone:
+		return HighLatitudeNone, nil
+	case HighLatitudeMiddleOfNight:
+		return HighLatitudeMiddleOfNight, nil
+	case HighLatitudeOneSeventh:
+		return HighLatitudeOneSeventh, nil
+	}
+	return "", fmt.Errorf("unknown high latitude rule: %s", name)
+}
+
+// nightPortion is the longest part of the night allowed between Fajr and sunrise, or sunset and Isha
+func (r HighLatitudeRule) nightPortion(angle float64, night time.Duration) time.Duration {
+	switch r {
+	case HighLatitudeMiddleOfNight:
+		return night / 2
+	case HighLatitudeOneSeventh:
+		return night / 7
+	default:
+		return time.Duration(angle / 60 * float64(night))
+	}
+}
+
+// PrayerOptions selects the conventions for CalculatePrayerTimes. Ramadan is set by the caller,
+// since the month's start depends on the moon sighting and the local calendar authority.
+type PrayerOptions struct {
+	Method       PrayerMethod
+	Asr          AsrMethod
+	HighLatitude HighLatitudeRule
+	Ramadan      bool // use the method's Ramadan Isha interval, if it has one
+}
+
+// PrayerTimes holds the daily prayer times; a zero time means the sun doesn't reach the defining angle
+type PrayerTimes struct {
+	Fajr    time.Time // dawn, when the sun reaches the method's Fajr angle below the horizon
+	Sunrise time.Time // end of the Fajr period
+	Dhuhr   time.Time // solar noon
+	Asr     time.Time // afternoon, by shadow length
+	Maghrib time.Time // sunset
+	Isha    time.Time // nightfall, by the method's Isha angle or interval
+
+	// Set when the high latitude rule moved Fajr or Isha
+	FajrAdjusted bool
+	IshaAdjusted bool
+}
+
+// CalculatePrayerTimes computes the prayer times for an observer on the given date, in the date's time zone.
+// Sunrise and Maghrib use the observer's refraction and horizon dip, like CalculateSunriseSunsetForObserver.
+func CalculatePrayerTimes(observer Observer, date time.Time, options PrayerOptions) PrayerTimes {
+	convention := options.Method.Convention()
+	if options.Method == "" {
+		convention = PrayerMethodMWL.Convention()
+	}
+	lat, lon := observer.Latitude, observer.Longitude
+
+	var times PrayerTimes
+	times.Sunrise, times.Maghrib = CalculateSunriseSunsetForObserver(observer, date)
+	times.Dhuhr = CalculateSolarNoon(lon, date)
+	if asrAltitude, ok := AsrAltitude(lat, lon, date, options.Asr); ok {
+		_, times.Asr = CalculateAltitudeCrossings(lat, lon, date, asrAltitude)
+	}
+	times.Fajr, _ = CalculateAltitudeCrossings(lat, lon, date, -convention.FajrAngle)
+	if interval := convention.IshaAfterMaghrib(options.Ramadan); interval > 0 {
+		if !times.Maghrib.IsZero() {
+			times.Isha = times.Maghrib.Add(interval)
+		}
+	} else {
+		_, times.Isha = CalculateAltitudeCrossings(lat, lon, date, -convention.IshaAngle)
+	}
+
+	// Without both sunrise and sunset there is no night to take a portion of
+	if options.HighLatitude == HighLatitudeNone || times.Sunrise.IsZero() || times.Maghrib.IsZero() {
+		return times
+	}
+	night := 24*time.Hour - times.Maghrib.Sub(times.Sunrise)
+
+	if limit := options.HighLatitude.nightPortion(convention.FajrAngle, night); times.Fajr.IsZero() || times.Sunrise.Sub(times.Fajr) > limit {
+		times.Fajr = times.Sunrise.Add(-limit)
+		times.FajrAdjusted = true
+	}
+	if convention.IshaInterval == 0 {
+		if limit := options.HighLatitude.nightPortion(convention.IshaAngle, night); times.Isha.IsZero() || times.Isha.Sub(times.Maghrib) > limit {
+			times.Isha = times.Maghrib.Add(limit)
+			times.IshaAdjusted = true
+		}
+	}
+	return times
+}
+
+// AsrAltitude returns the sun's altitude in degrees when Asr begins on the given date: the moment
+// a vertical object's shadow has grown by the method's shadow factor beyond its length at noon.
+// It reports false during polar night, when the sun stays below the horizon at noon and casts no shadow.
+// The noon shadow is taken on the same solar day as CalculateAltitudeCrossings.
+func AsrAltitude(latitude, longitude float64, date time.Time, method AsrMethod) (float64, bool) {
+	year, month, day := solarDate(longitude, date)
+	declination := calculateDeclinationAccurate(daysSinceJan1(year, month, day))
+
+	// The sun's zenith angle at noon; its tangent is the noon shadow length per unit of height
+	noonZenith := math.Abs(latitude*math.Pi/180 - declination)
+	if noonZenith >= math.Pi/2 {
+		return 0, false
+	}
+	return math.Atan(1/(method.shadowFactor()+math.Tan(noonZenith))) * 180 / math.Pi, true
+}
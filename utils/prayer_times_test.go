@@ -0,0 +1,210 @@
+package utils
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestParsePrayerOptions(t *testing.T) {
+	for _, name := range []string{"", "MWL", "isna", "ummalqura", "Egyptian", " karachi "} {
+		if _, err := ParsePrayerMethod(name); err != nil {
+			t.Errorf("Expected %q to be a prayer method, but got %v", name, err)
+		}
+	}
+	if method, _ := ParsePrayerMethod(""); method != PrayerMethodMWL {
+		t.Errorf("Expected the default method mwl, but got %s", method)
+	}
+	if _, err := ParsePrayerMethod("jafari"); err == nil {
+		t.Errorf("Expected an error for an unknown method, but got none")
+	}
+
+	if asr, _ := ParseAsrMethod("Hanafi"); asr != AsrHanafi {
+		t.Errorf("Expected hanafi, but got %s", asr)
+	}
+	if _, err := ParseAsrMethod("maliki"); err == nil {
+		t.Errorf("Expected an error for an unknown Asr method, but got none")
+	}
+
+	if rule, _ := ParseHighLatitudeRule(""); rule != HighLatitudeAngleBased {
+		t.Errorf("Expected the default rule angle_based, but got %s", rule)
+	}
+	if _, err := ParseHighLatitudeRule("nearest_latitude"); err == nil {
+		t.Errorf("Expected an error for an unknown high latitude rule, but got none")
+	}
+}
+
+func TestCalculatePrayerTimesReference(t *testing.T) {
+	// Published timetables for 1 January 2024, to the minute
+	testCases := []struct {
+		name     string
+		lat, lon float64
+		timeZone string
+		method   PrayerMethod
+		times    [6]string
+	}{
+		{"Makkah", 21.4225, 39.8262, "Asia/Riyadh", PrayerMethodUmmAlQura, [6]string{"05:36", "06:57", "12:23", "15:28", "17:50", "19:20"}},
+		{"Cairo", 30.0444, 31.2357, "Africa/Cairo", PrayerMethodEgyptian, [6]string{"05:18", "06:50", "11:58", "14:46", "17:06", "18:28"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			location, err := time.LoadLocation(tc.timeZone)
+			if err != nil {
+				t.Fatal(err)
+			}
+			date := time.Date(2024, time.January, 1, 0, 0, 0, 0, location)
+			times := CalculatePrayerTimes(NewObserver(tc.lat, tc.lon), date, PrayerOptions{Method: tc.method})
+
+			names := []string{"Fajr", "Sunrise", "Dhuhr", "Asr", "Maghrib", "Isha"}
+			for i, got := range []time.Time{times.Fajr, times.Sunrise, times.Dhuhr, times.Asr, times.Maghrib, times.Isha} {
+				want, _ := time.ParseInLocation("2006-01-02 15:04", "2024-01-01 "+tc.times[i], location)
+				if diff := got.Sub(want); diff < -2*time.Minute || diff > 2*time.Minute {
+					t.Errorf("Expected %s at %s, but got %s", names[i], tc.times[i], got.Format("15:04"))
+				}
+			}
+		})
+	}
+}
+
+func TestCalculatePrayerTimesAngles(t *testing.T) {
+	khartoum, _ := time.LoadLocation("Africa/Khartoum")
+	observer := NewObserver(15.5007, 32.5599)
+	date := time.Date(2026, time.January, 28, 0, 0, 0, 0, khartoum)
+
+	for _, method := range []PrayerMethod{PrayerMethodMWL, PrayerMethodISNA, PrayerMethodEgyptian, PrayerMethodKarachi} {
+		t.Run(string(method), func(t *testing.T) {
+			times := CalculatePrayerTimes(observer, date, PrayerOptions{Method: method})
+			order := []time.Time{times.Fajr, times.Sunrise, times.Dhuhr, times.Asr, times.Maghrib, times.Isha}
+			for i := 1; i < len(order); i++ {
+				if !order[i].After(order[i-1]) {
+					t.Fatalf("Expected the prayers in order, but got %v", order)
+				}
+			}
+			if times.FajrAdjusted || times.IshaAdjusted {
+				t.Errorf("Expected no high latitude adjustment in Khartoum")
+			}
+
+			// The sun is at the method's angles below the horizon at Fajr and Isha
+			convention := method.Convention()
+			if altitude, _ := CalculateSunPositionForObserver(AlgorithmSPA, observer, times.Fajr); math.Abs(altitude+convention.FajrAngle) > 0.2 {
+				t.Errorf("Expected the sun at -%g° at Fajr, but got %.2f°", convention.FajrAngle, altitude)
+			}
+			if altitude, _ := CalculateSunPositionForObserver(AlgorithmSPA, observer, times.Isha); math.Abs(altitude+convention.IshaAngle) > 0.2 {
+				t.Errorf("Expected the sun at -%g° at Isha, but got %.2f°", convention.IshaAngle, altitude)
+			}
+		})
+	}
+
+	// Umm al-Qura puts Isha a fixed interval after Maghrib
+	times := CalculatePrayerTimes(observer, date, PrayerOptions{Method: PrayerMethodUmmAlQura})
+	if times.Isha.Sub(times.Maghrib) != 90*time.Minute {
+		t.Errorf("Expected Isha 90 minutes after Maghrib, but got %v", times.Isha.Sub(times.Maghrib))
+	}
+	times = CalculatePrayerTimes(observer, date, PrayerOptions{Method: PrayerMethodUmmAlQura, Ramadan: true})
+	if times.Isha.Sub(times.Maghrib) != 120*time.Minute {
+		t.Errorf("Expected Isha 120 minutes after Maghrib in Ramadan, but got %v", times.Isha.Sub(times.Maghrib))
+	}
+	// Angle-based methods are unaffected by Ramadan
+	if a, b := CalculatePrayerTimes(observer, date, PrayerOptions{Method: PrayerMethodMWL}), CalculatePrayerTimes(observer, date, PrayerOptions{Method: PrayerMethodMWL, Ramadan: true}); !a.Isha.Equal(b.Isha) {
+		t.Errorf("Expected the same MWL Isha in Ramadan, but got %v and %v", a.Isha, b.Isha)
+	}
+}
+
+func TestCalculatePrayerTimesAsr(t *testing.T) {
+	riyadh, _ := time.LoadLocation("Asia/Riyadh")
+	observer := NewObserver(24.7136, 46.6753)
+
+	for _, date := range []time.Time{
+		time.Date(2026, time.March, 20, 0, 0, 0, 0, riyadh),
+		time.Date(2026, time.June, 21, 0, 0, 0, 0, riyadh),
+		time.Date(2026, time.December, 21, 0, 0, 0, 0, riyadh),
+	} {
+		shafii := CalculatePrayerTimes(observer, date, PrayerOptions{Method: PrayerMethodUmmAlQura, Asr: AsrShafii})
+		hanafi := CalculatePrayerTimes(observer, date, PrayerOptions{Method: PrayerMethodUmmAlQura, Asr: AsrHanafi})
+		if !hanafi.Asr.After(shafii.Asr.Add(30 * time.Minute)) {
+			t.Errorf("%s: expected Hanafi Asr well after Shafi'i Asr, but got %v and %v", date.Format("2006-01-02"), hanafi.Asr, shafii.Asr)
+		}
+
+		// The shadow at Asr is the noon shadow plus one (Shafi'i) or two (Hanafi) object lengths
+		noonAltitude, _ := CalculateSunPosition(observer.Latitude, observer.Longitude, shafii.Dhuhr)
+		for factor, asr := range map[float64]time.Time{1: shafii.Asr, 2: hanafi.Asr} {
+			altitude, _ := CalculateSunPosition(observer.Latitude, observer.Longitude, asr)
+			growth := 1/math.Tan(degToRad(altitude)) - 1/math.Tan(degToRad(noonAltitude))
+			if math.Abs(growth-factor) > 0.02 {
+				t.Errorf("%s: expected the shadow to grow by %g lengths at Asr, but got %.3f", date.Format("2006-01-02"), factor, growth)
+			}
+		}
+	}
+}
+
+func TestCalculatePrayerTimesNearDateLine(t *testing.T) {
+	// Kiritimati (UTC+14) and Honolulu (UTC-10) lie at almost the same longitude, so a solar day falls
+	// a calendar day later in Kiritimati and every prayer time must come out at the same instant
+	kiritimati, _ := time.LoadLocation("Pacific/Kiritimati")
+	honolulu, _ := time.LoadLocation("Pacific/Honolulu")
+	observer := NewObserver(45, -157.43)
+
+	ahead := CalculatePrayerTimes(observer, time.Date(2026, time.March, 20, 0, 0, 0, 0, kiritimati), PrayerOptions{Method: PrayerMethodMWL})
+	behind := CalculatePrayerTimes(observer, time.Date(2026, time.March, 19, 0, 0, 0, 0, honolulu), PrayerOptions{Method: PrayerMethodMWL})
+	for name, pair := range map[string][2]time.Time{
+		"Fajr":    {ahead.Fajr, behind.Fajr},
+		"Sunrise": {ahead.Sunrise, behind.Sunrise},
+		"Dhuhr":   {ahead.Dhuhr, behind.Dhuhr},
+		"Asr":     {ahead.Asr, behind.Asr},
+		"Maghrib": {ahead.Maghrib, behind.Maghrib},
+		"Isha":    {ahead.Isha, behind.Isha},
+	} {
+		if pair[0].IsZero() || !pair[0].Equal(pair[1]) {
+			t.Errorf("Expected the same %s in Kiritimati and Honolulu, but got %v and %v", name, pair[0].UTC(), pair[1].UTC())
+		}
+	}
+}
+
+func TestCalculatePrayerTimesHighLatitude(t *testing.T) {
+	// At midsummer in London the sun gets no lower than about 15° below the horizon
+	london, _ := time.LoadLocation("Europe/London")
+	observer := NewObserver(51.5074, -0.1278)
+	date := time.Date(2026, time.June, 21, 0, 0, 0, 0, london)
+
+	times := CalculatePrayerTimes(observer, date, PrayerOptions{Method: PrayerMethodMWL, HighLatitude: HighLatitudeNone})
+	if !times.Fajr.IsZero() || !times.Isha.IsZero() || times.Sunrise.IsZero() {
+		t.Fatalf("Expected no Fajr or Isha without a high latitude rule, but got %v and %v", times.Fajr, times.Isha)
+	}
+
+	night := 24*time.Hour - times.Maghrib.Sub(times.Sunrise)
+	testCases := []struct {
+		rule HighLatitudeRule
+		fajr time.Duration // before sunrise
+		isha time.Duration // after sunset
+	}{
+		{HighLatitudeMiddleOfNight, night / 2, night / 2},
+		{HighLatitudeOneSeventh, night / 7, night / 7},
+		{HighLatitudeAngleBased, time.Duration(18.0 / 60 * float64(night)), time.Duration(17.0 / 60 * float64(night))},
+	}
+	for _, tc := range testCases {
+		times := CalculatePrayerTimes(observer, date, PrayerOptions{Method: PrayerMethodMWL, HighLatitude: tc.rule})
+		if !times.FajrAdjusted || times.Sunrise.Sub(times.Fajr) != tc.fajr {
+			t.Errorf("%s: expected Fajr %v before sunrise, but got %v", tc.rule, tc.fajr, times.Sunrise.Sub(times.Fajr))
+		}
+		if !times.IshaAdjusted || times.Isha.Sub(times.Maghrib) != tc.isha {
+			t.Errorf("%s: expected Isha %v after sunset, but got %v", tc.rule, tc.isha, times.Isha.Sub(times.Maghrib))
+		}
+	}
+
+	// Inside the Arctic circle at midsummer there is no sunset to work from
+	tromso := CalculatePrayerTimes(NewObserver(69.6492, 18.9553), date, PrayerOptions{Method: PrayerMethodMWL})
+	if !tromso.Maghrib.IsZero() || !tromso.Fajr.IsZero() || !tromso.Isha.IsZero() || tromso.Dhuhr.IsZero() {
+		t.Errorf("Expected only Dhuhr and Asr during the midnight sun, but got %+v", tromso)
+	}
+
+	// In polar night the sun stays below the horizon at noon, so there is no shadow to time Asr by
+	winter := time.Date(2026, time.December, 21, 0, 0, 0, 0, time.UTC)
+	tromso = CalculatePrayerTimes(NewObserver(69.6492, 18.9553), winter, PrayerOptions{Method: PrayerMethodMWL})
+	if !tromso.Asr.IsZero() || !tromso.Sunrise.IsZero() || !tromso.Maghrib.IsZero() {
+		t.Errorf("Expected no Asr, sunrise or Maghrib during polar night, but got %+v", tromso)
+	}
+	if _, ok := AsrAltitude(69.6492, 18.9553, winter, AsrShafii); ok {
+		t.Errorf("Expected no Asr altitude during polar night")
+	}
+}
@@ -0,0 +1,95 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Years supported by the equinox and solstice calculation
+const (
+	MinSeasonYear = 1900
+	MaxSeasonYear = 2100
+)
+
+// Seasons holds the instants of the equinoxes and solstices of one year, in UTC
+type Seasons struct {
+	MarchEquinox     time.Time
+	JuneSolstice     time.Time
+	SeptemberEquinox time.Time
+	DecemberSolstice time.Time
+}
+
+// Mean equinox and solstice polynomials in Y = (year-2000)/1000, valid for 1000 to 3000.
+// Reference: Meeus, Astronomical Algorithms (2nd ed.), table 27.B.
+var meeusSeasonPolynomials = [4][5]float64{
+	{2451623.80984, 365242.37404, 0.05169, -0.00411, -0.00057}, // March equinox
+	{2451716.56767, 365241.62603, 0.00325, 0.00888, -0.00030},  // June solstice
+	{2451810.21715, 365242.01767, -0.11575, 0.00337, 0.00078},  // September equinox
+	{2451900.05952, 365242.74049, -0.06223, -0.00823, 0.00032}, // December solstice
+}
+
+// Periodic terms {A, B, C} for the equinox and solstice correction, Meeus table 27.C
+var meeusSeasonTerms = [][3]float64{
+	{485, 324.96, 1934.136}, {203, 337.23, 32964.467}, {199, 342.08, 20.186}, {182, 27.85, 445267.112},
+	{156, 73.14, 45036.886}, {136, 171.52, 22518.443}, {77, 222.54, 65928.934}, {74, 296.72, 3034.906},
+	{70, 243.58, 9037.513}, {58, 119.81, 33718.147}, {52, 297.17, 150.678}, {50, 21.02, 2281.226},
+	{45, 247.54, 29929.562}, {44, 325.15, 31555.956}, {29, 60.93, 4443.417}, {18, 155.12, 67555.328},
+	{17, 288.79, 4562.452}, {16, 198.04, 62894.029}, {14, 199.76, 31436.921}, {12, 95.39, 14577.848},
+	{12, 287.11, 31931.756}, {12, 320.81, 34777.259}, {9, 227.73, 1222.114}, {8, 15.45, 16859.074},
+}
+
+// CalculateSeasons returns the March and September equinoxes and the June and December solstices of a year.
+// Meeus's series gives each instant to within about a minute; it is then refined against the SPA's apparent
+// solar longitude until the sun is at exactly 0°, 90°, 180° or 270°, which brings it within about ten seconds.
+func CalculateSeasons(year int) (Seasons, error) {
+	if year < MinSeasonYear || year > MaxSeasonYear {
+		return Seasons{}, fmt.Errorf("year %d is outside %d to %d", year, MinSeasonYear, MaxSeasonYear)
+	}
+	return Seasons{
+		MarchEquinox:     seasonInstant(year, 0),
+		JuneSolstice:     seasonInstant(year, 1),
+		SeptemberEquinox: seasonInstant(year, 2),
+		DecemberSolstice: seasonInstant(year, 3),
+	}, nil
+}
+
+// seasonInstant returns the UTC instant the sun's apparent longitude reaches k*90 degrees in the given year
+func seasonInstant(year, k int) time.Time {
+	jde := meeusSeasonJDE(year, k)
+
+	// Meeus, chapter 27: JDE += 58 sin(k*90° - λ) converges in a few steps
+	for i := 0; i < 5; i++ {
+		instant := julianEphemerisDayToUTC(jde)
+		result := calculateSPA(spaInput{dateTime: instant, deltaT: EstimateDeltaT(instant)})
+		correction := 58 * math.Sin(degToRad(float64(k)*90-result.lambda))
+		jde += correction
+		if math.Abs(correction) < 1e-6 {
+			break
+		}
+	}
+	return julianEphemerisDayToUTC(jde).Round(time.Second)
+}
+
+// meeusSeasonJDE returns the Julian Ephemeris Day of an equinox or solstice from Meeus's series
+func meeusSeasonJDE(year, k int) float64 {
+	y := (float64(year) - 2000) / 1000
+	p := meeusSeasonPolynomials[k]
+	jde0 := p[0] + y*(p[1]+y*(p[2]+y*(p[3]+y*p[4])))
+
+	t := (jde0 - 2451545.0) / 36525
+	w := degToRad(35999.373*t - 2.47)
+	deltaLambda := 1 + 0.0334*math.Cos(w) + 0.0007*math.Cos(2*w)
+
+	var s float64
+	for _, term := range meeusSeasonTerms {
+		s += term[0] * math.Cos(degToRad(term[1]+term[2]*t))
+	}
+	return jde0 + 0.00001*s/deltaLambda
+}
+
+// julianEphemerisDayToUTC converts a Julian Ephemeris Day (TT) to a UTC time using the ΔT estimate
+func julianEphemerisDayToUTC(jde float64) time.Time {
+	tt := time.Unix(0, 0).UTC().Add(time.Duration((jde - 2440587.5) * float64(24*time.Hour)))
+	return tt.Add(-time.Duration(EstimateDeltaT(tt) * float64(time.Second)))
+}
@@ -0,0 +1,60 @@
+package utils
+
+import (
+	"math"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestCalculateSeasons(t *testing.T) {
+	// Published instants from the US Naval Observatory, to the minute
+	testCases := []struct {
+		year     int
+		expected [4]string
+	}{
+		{2024, [4]string{"2024-03-20T03:06:00Z", "2024-06-20T20:51:00Z", "2024-09-22T12:44:00Z", "2024-12-21T09:20:00Z"}},
+		{2025, [4]string{"2025-03-20T09:01:00Z", "2025-06-21T02:42:00Z", "2025-09-22T18:19:00Z", "2025-12-21T15:03:00Z"}},
+		{2000, [4]string{"2000-03-20T07:35:00Z", "2000-06-21T01:48:00Z", "2000-09-22T17:27:00Z", "2000-12-21T13:37:00Z"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(strconv.Itoa(tc.year), func(t *testing.T) {
+			seasons, err := CalculateSeasons(tc.year)
+			if err != nil {
+				t.Fatalf("Expected seasons for %d, but got %v", tc.year, err)
+			}
+			got := [4]time.Time{seasons.MarchEquinox, seasons.JuneSolstice, seasons.SeptemberEquinox, seasons.DecemberSolstice}
+			for i, expectedStr := range tc.expected {
+				expected, _ := time.Parse(time.RFC3339, expectedStr)
+				if diff := got[i].Sub(expected); diff < -time.Minute || diff > time.Minute {
+					t.Errorf("Expected %s, but got %s", expectedStr, got[i].Format(time.RFC3339))
+				}
+			}
+		})
+	}
+}
+
+func TestCalculateSeasonsMeeusExample(t *testing.T) {
+	// Meeus example 27.a: the June solstice of 1962 at JDE 2437837.39245, 21:25:08 TD before refinement
+	if jde := meeusSeasonJDE(1962, 1); math.Abs(jde-2437837.39245) > 0.0001 {
+		t.Errorf("Expected JDE 2437837.39245, but got %.5f", jde)
+	}
+}
+
+func TestCalculateSeasonsRange(t *testing.T) {
+	for _, year := range []int{MinSeasonYear, MaxSeasonYear} {
+		seasons, err := CalculateSeasons(year)
+		if err != nil {
+			t.Errorf("Expected seasons for %d, but got %v", year, err)
+		}
+		if seasons.MarchEquinox.Year() != year || seasons.DecemberSolstice.Year() != year {
+			t.Errorf("Expected events in %d, but got %v", year, seasons)
+		}
+	}
+	for _, year := range []int{MinSeasonYear - 1, MaxSeasonYear + 1} {
+		if _, err := CalculateSeasons(year); err == nil {
+			t.Errorf("Expected an error for %d, but got none", year)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"math"
+	"time"
+)
+
+// Shadow is the shadow cast on level ground by a vertical object such as a pole, building or tree
+type Shadow struct {
+	Length       float64 `json:"length"`       // meters from the base of the object to the tip of the shadow
+	Direction    float64 `json:"direction"`    // degrees eastward from north, opposite the sun's azimuth
+	TipLatitude  float64 `json:"tip_latitude"` // where the shadow of the top of the object falls
+	TipLongitude float64 `json:"tip_longitude"`
+}
+
+// ShadowPoint is the sun's position and the shadow at one moment of a day
+type ShadowPoint struct {
+	Time        string  `json:"time"` // Format: HH:MM local time
+	Hour        float64 `json:"hour"` // Local clock time in decimal hours
+	SunAltitude float64 `json:"sun_altitude"`
+	SunAzimuth  float64 `json:"sun_azimuth"`
+	Shadow
+}
+
+// CalculateShadow returns the shadow of an object of the given height in meters standing at the coordinates,
+// for the sun's altitude and azimuth in degrees. There is no shadow when the sun is not above the horizon.
+func CalculateShadow(height, latitude, longitude, altitude, azimuth float64) (Shadow, bool) {
+	if altitude <= 0 {
+		return Shadow{}, false
+	}
+
+	shadow := Shadow{
+		Length:    height / math.Tan(degToRad(altitude)),
+		Direction: limitDegrees(azimuth + 180),
+	}
+	shadow.TipLatitude, shadow.TipLongitude = DestinationPoint(latitude, longitude, shadow.Direction, shadow.Length/1000)
+	return shadow, true
+}
+
+// CalculateShadowPath samples the shadow of an object every step through the local day of date,
+// keeping only the moments when the sun is up
+func CalculateShadowPath(algorithm Algorithm, observer Observer, height float64, date time.Time, step time.Duration) []ShadowPoint {
+	points := []ShadowPoint{}
+	for _, point := range CalculateSunPath(algorithm, observer, date, step) {
+		shadow, ok := CalculateShadow(height, observer.Latitude, observer.Longitude, point.Altitude, point.Azimuth)
+		if !ok {
+			continue
+		}
+		points = append(points, ShadowPoint{
+			Time:        point.Time,
+			Hour:        point.Hour,
+			SunAltitude: point.Altitude,
+			SunAzimuth:  point.Azimuth,
+			Shadow:      shadow,
+		})
+	}
+	return points
+}
@@ -0,0 +1,92 @@
+package utils
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestCalculateShadow(t *testing.T) {
+	testCases := []struct {
+		name      string
+		height    float64
+		altitude  float64
+		azimuth   float64
+		length    float64
+		direction float64
+	}{
+		{"Sun at 45°", 10, 45, 180, 10, 0},
+		{"Low morning sun", 2, 10, 90, 2 / math.Tan(degToRad(10)), 270},
+		{"High sun in the north", 30, 60, 350, 30 / math.Tan(degToRad(60)), 170},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			shadow, ok := CalculateShadow(tc.height, 51.5, -0.12, tc.altitude, tc.azimuth)
+			if !ok {
+				t.Fatalf("Expected a shadow, but got none")
+			}
+			if math.Abs(shadow.Length-tc.length) > 1e-9 || math.Abs(shadow.Direction-tc.direction) > 1e-9 {
+				t.Errorf("Expected %.3f m toward %.0f°, but got %.3f m toward %.3f°", tc.length, tc.direction, shadow.Length, shadow.Direction)
+			}
+
+			// The tip lies the shadow's length away in the shadow's direction
+			distance := GreatCircleDistance(51.5, -0.12, shadow.TipLatitude, shadow.TipLongitude) * 1000
+			if math.Abs(distance-tc.length) > 1e-3 {
+				t.Errorf("Expected the tip %.3f m away, but got %.3f m", tc.length, distance)
+			}
+			if bearing := InitialBearing(51.5, -0.12, shadow.TipLatitude, shadow.TipLongitude); math.Abs(bearing-tc.direction) > 0.01 && math.Abs(bearing-tc.direction) < 359.99 {
+				t.Errorf("Expected the tip toward %.0f°, but got %.3f°", tc.direction, bearing)
+			}
+		})
+	}
+
+	if _, ok := CalculateShadow(10, 51.5, -0.12, -5, 180); ok {
+		t.Errorf("Expected no shadow with the sun below the horizon")
+	}
+}
+
+func TestDestinationPoint(t *testing.T) {
+	// One degree of arc along the equator and along a meridian
+	arc := EarthRadiusKm * math.Pi / 180
+	if lat, lon := DestinationPoint(0, 0, 90, arc); math.Abs(lat) > 1e-9 || math.Abs(lon-1) > 1e-9 {
+		t.Errorf("Expected 0, 1, but got %f, %f", lat, lon)
+	}
+	if lat, lon := DestinationPoint(10, 20, 0, arc); math.Abs(lat-11) > 1e-9 || math.Abs(lon-20) > 1e-9 {
+		t.Errorf("Expected 11, 20, but got %f, %f", lat, lon)
+	}
+	// Crossing the antimeridian wraps the longitude
+	if _, lon := DestinationPoint(0, 179.5, 90, arc); math.Abs(lon+179.5) > 1e-9 {
+		t.Errorf("Expected -179.5, but got %f", lon)
+	}
+}
+
+func TestCalculateShadowPath(t *testing.T) {
+	madrid, _ := time.LoadLocation("Europe/Madrid")
+	observer := NewObserver(40.4168, -3.7038)
+	points := CalculateShadowPath(AlgorithmFast, observer, 10, time.Date(2026, time.June, 21, 0, 0, 0, 0, madrid), 30*time.Minute)
+
+	// About 15 hours of daylight at midsummer
+	if len(points) < 28 || len(points) > 32 {
+		t.Fatalf("Expected about 30 daylight points, but got %d", len(points))
+	}
+
+	// The shadow is shortest near solar noon, when it points north
+	shortest := points[0]
+	for _, point := range points {
+		if point.SunAltitude <= 0 {
+			t.Errorf("Expected only daylight points, but got altitude %f at %s", point.SunAltitude, point.Time)
+		}
+		if point.Length < shortest.Length {
+			shortest = point
+		}
+	}
+	// Solar noon is at 14:15 local time, so the nearest sample is a few degrees either side of north
+	if fromNorth := math.Min(shortest.Direction, 360-shortest.Direction); fromNorth > 15 {
+		t.Errorf("Expected the shortest shadow to point north, but got %f° at %s", shortest.Direction, shortest.Time)
+	}
+	// 10 / tan(73°) at the solstice noon
+	if math.Abs(shortest.Length-3.06) > 0.1 {
+		t.Errorf("Expected a noon shadow of about 3.06 m, but got %f", shortest.Length)
+	}
+}
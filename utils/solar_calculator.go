@@ -1,12 +1,55 @@
 package utils
 
 import (
+	"fmt"
 	"math"
+	"strings"
 	"time"
 )
 
+// Algorithm selects the model used to calculate the sun's position
+type Algorithm string
+
+const (
+	// AlgorithmFast uses the day-of-year Fourier series approximation
+	AlgorithmFast Algorithm = "fast"
+	// AlgorithmSPA uses the NREL Solar Position Algorithm (±0.0003°)
+	AlgorithmSPA Algorithm = "spa"
+)
+
+// ParseAlgorithm converts a user supplied name into an Algorithm, defaulting to AlgorithmFast when empty
+func ParseAlgorithm(name string) (Algorithm, error) {
+	switch Algorithm(strings.ToLower(strings.TrimSpace(name))) {
+	case "", AlgorithmFast:
+		return AlgorithmFast, nil
+	case AlgorithmSPA:
+		return AlgorithmSPA, nil
+	}
+	return "", fmt.Errorf("unknown algorithm: %s", name)
+}
+
+// CalculateSunPositionWithAlgorithm calculates the sun's altitude and azimuth using the selected algorithm
+func CalculateSunPositionWithAlgorithm(algorithm Algorithm, latitude, longitude float64, dateTime time.Time) (altitude, azimuth float64) {
+	return CalculateSunPositionForObserver(algorithm, NewObserver(latitude, longitude), dateTime)
+}
+
+// CalculateSunPositionForObserver calculates the sun's altitude and azimuth as seen by an observer,
+// correcting refraction for the observer's pressure and temperature
+func CalculateSunPositionForObserver(algorithm Algorithm, observer Observer, dateTime time.Time) (altitude, azimuth float64) {
+	if algorithm == AlgorithmSPA {
+		result := calculateSPAForObserver(observer, dateTime)
+		return result.elevation, result.azimuth
+	}
+	return calculateSunPositionFast(observer.Latitude, observer.Longitude, dateTime, observer.RefractionScale())
+}
+
 // CalculateSunPosition calculates the sun's altitude and azimuth for a given location and time
 func CalculateSunPosition(latitude, longitude float64, dateTime time.Time) (altitude, azimuth float64) {
+	return calculateSunPositionFast(latitude, longitude, dateTime, 1.0)
+}
+
+// calculateSunPositionFast implements the fast algorithm, scaling standard refraction by refractionScale
+func calculateSunPositionFast(latitude, longitude float64, dateTime time.Time, refractionScale float64) (altitude, azimuth float64) {
 	// Convert degrees to radians
 	latRad := latitude * math.Pi / 180
 
@@ -48,8 +91,8 @@ func CalculateSunPosition(latitude, longitude float64, dateTime time.Time) (alti
 		refractionCorrection = 0.57644 * math.Exp(-0.00149*altitude) - 0.07156
 	}
 
-	// Apply the refraction correction
-	altitude += refractionCorrection
+	// Apply the refraction correction, adjusted for the local atmosphere
+	altitude += refractionCorrection * refractionScale
 
 	// Calculate solar azimuth (γ_s) - more accurate calculation
 	// Using the formula from Reda and Andreas (2005)
@@ -64,7 +107,8 @@ func CalculateSunPosition(latitude, longitude float64, dateTime time.Time) (alti
 		cosAzimuth = -1
 	}
 
-	sinAzimuth := math.Sin(hourAngle) * math.Cos(declinationRad) / math.Cos(altitude*math.Pi/180)
+	// Hour angle is positive in the afternoon, when the sun is west of the meridian (azimuth > 180)
+	sinAzimuth := -math.Sin(hourAngle) * math.Cos(declinationRad) / math.Cos(altitude*math.Pi/180)
 
 	azimuth = math.Atan2(sinAzimuth, cosAzimuth) * 180 / math.Pi
 
@@ -114,9 +158,9 @@ func calculateSolarTimeAccurate(dateTime time.Time, longitude float64, equationO
 	// Time correction factor (minutes)
 	// 4 minutes per degree difference between longitude and the standard meridian for the time zone
 	// Plus the equation of time correction
-	// Standard longitude for the time zone (multiples of 15 degrees from Greenwich)
-	// Using round instead of floor to get the closest standard meridian
-	standardMeridian := math.Round(longitude / 15.0) * 15.0
+	// Standard meridian for the time's actual UTC offset, including daylight saving time
+	_, offsetSeconds := dateTime.Zone()
+	standardMeridian := float64(offsetSeconds) / 3600.0 * 15.0
 
 	// 4 minutes per degree longitude difference from standard meridian
 	longitudeCorrection := 4.0 * (longitude - standardMeridian)
@@ -171,12 +215,32 @@ func isLeapYear(year int) bool {
 	return (year%4 == 0) && (year%100 != 0) || (year%400 == 0)
 }
 
+// Altitudes of the sun's center that define sunrise and the twilight boundaries, in degrees
+const (
+	StandardSunriseAltitude      = -0.833
+	CivilTwilightAltitude        = -6.0
+	NauticalTwilightAltitude     = -12.0
+	AstronomicalTwilightAltitude = -18.0
+)
+
 // CalculateSunriseSunset computes approximate sunrise and sunset times for the given date and location.
 // Returns zero times when sunrise or sunset cannot be determined (e.g., polar day/night).
 func CalculateSunriseSunset(latitude, longitude float64, date time.Time) (time.Time, time.Time) {
+	return CalculateAltitudeCrossings(latitude, longitude, date, StandardSunriseAltitude)
+}
+
+// CalculateSunriseSunsetForObserver computes sunrise and sunset times for an observer,
+// using the observer's refraction and horizon dip instead of the standard -0.833°
+func CalculateSunriseSunsetForObserver(observer Observer, date time.Time) (time.Time, time.Time) {
+	return CalculateAltitudeCrossings(observer.Latitude, observer.Longitude, date, observer.SunriseAltitude())
+}
+
+// CalculateAltitudeCrossings computes the times at which the sun's center rises through and sets below
+// the given altitude in degrees on the given date. Returns zero times when the sun stays above or below it all day.
+func CalculateAltitudeCrossings(latitude, longitude float64, date time.Time, altitudeDeg float64) (time.Time, time.Time) {
 	// NOAA-based sunrise/sunset calculation (approximate)
 	// Reference: https://gml.noaa.gov/grad/solcalc/solareqns.PDF (simplified)
-	year, month, day := date.Date()
+	year, month, day := solarDate(longitude, date)
 	dayOfYear := daysSinceJan1(year, month, day)
 
 	// Solar declination (radians) and equation of time (minutes)
@@ -187,7 +251,7 @@ func CalculateSunriseSunset(latitude, longitude float64, date time.Time) (time.T
 	latRad := latitude * math.Pi / 180.0
 
 	// Sun altitude for sunrise/sunset including refraction
-	h0 := -0.833 * math.Pi / 180.0
+	h0 := altitudeDeg * math.Pi / 180.0
 
 	// Calculate the hour angle H0 (radians)
 	cosH0 := (math.Sin(h0) - math.Sin(latRad)*math.Sin(decl)) / (math.Cos(latRad) * math.Cos(decl))
@@ -198,8 +262,7 @@ func CalculateSunriseSunset(latitude, longitude float64, date time.Time) (time.T
 	H0 := math.Acos(cosH0) // radians
 
 	// Solar noon in UTC (hours)
-	// solarNoonUTC = 12 - (longitude / 15) - (EoT / 60)
-	solarNoonUTC := 12.0 - (longitude / 15.0) - (eot / 60.0)
+	solarNoonUTC := solarNoonUTCHours(longitude, eot)
 
 	// Convert hour angle to hours: H0 (radians) -> hours = H0 * 12 / pi
 	deltaHours := H0 * 12.0 / math.Pi
@@ -214,10 +277,120 @@ func CalculateSunriseSunset(latitude, longitude float64, date time.Time) (time.T
 	sunriseTimeUTC := startOfDayUTC.Add(time.Duration(sunriseUTC * float64(time.Hour)))
 	sunsetTimeUTC := startOfDayUTC.Add(time.Duration(sunsetUTC * float64(time.Hour)))
 
-	// Approximate local timezone offset from longitude (hours)
-	tzOffsetHours := int(math.Round(longitude / 15.0))
-	localLoc := time.FixedZone("Local", tzOffsetHours*3600)
+	// Return times converted to the date's time zone
+	return sunriseTimeUTC.In(date.Location()), sunsetTimeUTC.In(date.Location())
+}
+
+// solarDate returns the UTC calendar day whose solar noon is closest to noon local time on the given date.
+// Near the date line, zones such as UTC+13 and UTC+14 keep a local day that is mostly the previous UTC day.
+func solarDate(longitude float64, date time.Time) (int, time.Month, int) {
+	year, month, day := date.Date()
+	_, offset := time.Date(year, month, day, 12, 0, 0, 0, date.Location()).Zone()
+
+	// Local noon is at 12 - offset hours UTC and solar noon at about 12 - longitude/15
+	shift := math.Round((longitude/15.0 - float64(offset)/3600.0) / 24.0)
+	if shift == 1 || shift == -1 {
+		day += int(shift)
+	}
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC).Date()
+}
+
+// solarNoonUTCHours returns the time of solar noon in hours after midnight UTC
+func solarNoonUTCHours(longitude, equationOfTime float64) float64 {
+	// solarNoonUTC = 12 - (longitude / 15) - (EoT / 60)
+	return 12.0 - (longitude / 15.0) - (equationOfTime / 60.0)
+}
+
+// CalculateSolarNoon returns the time the sun crosses the meridian on the given date, in the date's time zone
+func CalculateSolarNoon(longitude float64, date time.Time) time.Time {
+	year, month, day := solarDate(longitude, date)
+	eot := calculateEquationOfTimeAccurate(daysSinceJan1(year, month, day))
+
+	startOfDayUTC := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	noon := startOfDayUTC.Add(time.Duration(solarNoonUTCHours(longitude, eot) * float64(time.Hour)))
+	return noon.In(date.Location())
+}
+
+// DaylightState says whether the sun rises and sets on a date
+type DaylightState string
+
+// Daylight states
+const (
+	DaylightNormal DaylightState = "normal"      // the sun rises and sets
+	PolarDay       DaylightState = "polar_day"   // the sun stays above the horizon all day
+	PolarNight     DaylightState = "polar_night" // the sun stays below the horizon all day
+)
+
+// CalculateDaylightState reports whether the sun's center crosses the given altitude on the given date,
+// and if not, whether it stays above or below it
+func CalculateDaylightState(latitude, longitude float64, date time.Time, altitudeDeg float64) DaylightState {
+	rise, set := CalculateAltitudeCrossings(latitude, longitude, date, altitudeDeg)
+	if !rise.IsZero() && !set.IsZero() {
+		return DaylightNormal
+	}
+	// No crossing: the sun is either up all day or down all day, so check it at solar noon
+	noon := CalculateSolarNoon(longitude, date)
+	if altitude, _ := calculateSunPositionFast(latitude, longitude, noon, 0); altitude > altitudeDeg {
+		return PolarDay
+	}
+	return PolarNight
+}
+
+// CalculateDayLength returns how long the sun's center stays above the given altitude on the given date.
+// Returns 24 hours during polar day and zero during polar night.
+func CalculateDayLength(latitude, longitude float64, date time.Time, altitudeDeg float64) time.Duration {
+	switch CalculateDaylightState(latitude, longitude, date, altitudeDeg) {
+	case PolarDay:
+		return 24 * time.Hour
+	case PolarNight:
+		return 0
+	}
+	rise, set := CalculateAltitudeCrossings(latitude, longitude, date, altitudeDeg)
+	return set.Sub(rise)
+}
+
+// maxPolarSearchDays bounds the search for the end of a polar day or night; even at the poles
+// the sun crosses the horizon within half a year
+const maxPolarSearchDays = 366
+
+// FindNextSunrise returns the first time after the given date that the sun's center rises through
+// the given altitude, searching day by day. Returns a zero time if it doesn't rise within a year.
+func FindNextSunrise(latitude, longitude float64, date time.Time, altitudeDeg float64) time.Time {
+	return findNextCrossing(latitude, longitude, date, altitudeDeg, true)
+}
+
+// FindNextSunset returns the first time after the given date that the sun's center sets below
+// the given altitude, searching day by day. Returns a zero time if it doesn't set within a year.
+func FindNextSunset(latitude, longitude float64, date time.Time, altitudeDeg float64) time.Time {
+	return findNextCrossing(latitude, longitude, date, altitudeDeg, false)
+}
+
+// findNextCrossing looks for the first rise or set on the days following the given date
+func findNextCrossing(latitude, longitude float64, date time.Time, altitudeDeg float64, rising bool) time.Time {
+	year, month, day := date.Date()
+	for i := 1; i <= maxPolarSearchDays; i++ {
+		next := time.Date(year, month, day+i, 0, 0, 0, 0, date.Location())
+		rise, set := CalculateAltitudeCrossings(latitude, longitude, next, altitudeDeg)
+		if rising && !rise.IsZero() {
+			return rise
+		}
+		if !rising && !set.IsZero() {
+			return set
+		}
+	}
+	return time.Time{}
+}
+
+// TwilightTimes holds the morning and evening crossings of one twilight altitude; zero times mean no crossing
+type TwilightTimes struct {
+	Dawn time.Time
+	Dusk time.Time
+}
 
-	// Return times converted to approximate local timezone
-	return sunriseTimeUTC.In(localLoc), sunsetTimeUTC.In(localLoc)
+// CalculateTwilight returns civil, nautical and astronomical dawn and dusk for the given date and location
+func CalculateTwilight(latitude, longitude float64, date time.Time) (civil, nautical, astronomical TwilightTimes) {
+	civil.Dawn, civil.Dusk = CalculateAltitudeCrossings(latitude, longitude, date, CivilTwilightAltitude)
+	nautical.Dawn, nautical.Dusk = CalculateAltitudeCrossings(latitude, longitude, date, NauticalTwilightAltitude)
+	astronomical.Dawn, astronomical.Dusk = CalculateAltitudeCrossings(latitude, longitude, date, AstronomicalTwilightAltitude)
+	return civil, nautical, astronomical
 }
@@ -0,0 +1,123 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCalculateTwilightOrder(t *testing.T) {
+	khartoum := TimeZoneForCoordinates(15.5007, 32.5599)
+	date := time.Date(2026, time.January, 28, 12, 0, 0, 0, khartoum)
+
+	sunrise, sunset := CalculateSunriseSunset(15.5007, 32.5599, date)
+	civil, nautical, astronomical := CalculateTwilight(15.5007, 32.5599, date)
+	noon := CalculateSolarNoon(32.5599, date)
+
+	// Events must occur in order from astronomical dawn to astronomical dusk
+	events := []struct {
+		name string
+		time time.Time
+	}{
+		{"astronomical dawn", astronomical.Dawn},
+		{"nautical dawn", nautical.Dawn},
+		{"civil dawn", civil.Dawn},
+		{"sunrise", sunrise},
+		{"solar noon", noon},
+		{"sunset", sunset},
+		{"civil dusk", civil.Dusk},
+		{"nautical dusk", nautical.Dusk},
+		{"astronomical dusk", astronomical.Dusk},
+	}
+	for i := 1; i < len(events); i++ {
+		if !events[i-1].time.Before(events[i].time) {
+			t.Errorf("Expected %s (%v) before %s (%v)", events[i-1].name, events[i-1].time, events[i].name, events[i].time)
+		}
+	}
+
+	// Solar noon in Khartoum (UTC+2, 32.56°E) in late January is around 12:03
+	if noon.Hour() != 12 || noon.Minute() > 10 {
+		t.Errorf("Expected solar noon around 12:03, but got %s", noon.Format("15:04"))
+	}
+
+	// Civil twilight near the tropics lasts a little over 20 minutes
+	if d := sunrise.Sub(civil.Dawn); d < 18*time.Minute || d > 30*time.Minute {
+		t.Errorf("Expected civil twilight of about 22 minutes, but got %v", d)
+	}
+}
+
+func TestCalculateDayLength(t *testing.T) {
+	testCases := []struct {
+		name      string
+		latitude  float64
+		longitude float64
+		date      time.Time
+		min       time.Duration
+		max       time.Duration
+	}{
+		{"Equator at equinox", 0, 0, time.Date(2026, time.March, 20, 12, 0, 0, 0, time.UTC), 12 * time.Hour, 12*time.Hour + 15*time.Minute},
+		{"Tromsø midnight sun", 69.6492, 18.9553, time.Date(2026, time.June, 21, 12, 0, 0, 0, time.UTC), 24 * time.Hour, 24 * time.Hour},
+		{"Tromsø polar night", 69.6492, 18.9553, time.Date(2026, time.December, 21, 12, 0, 0, 0, time.UTC), 0, 0},
+		{"London summer solstice", 51.5074, -0.1278, time.Date(2026, time.June, 21, 12, 0, 0, 0, time.UTC), 16*time.Hour + 30*time.Minute, 16*time.Hour + 45*time.Minute},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := CalculateDayLength(tc.latitude, tc.longitude, tc.date, StandardSunriseAltitude)
+			if result < tc.min || result > tc.max {
+				t.Errorf("Expected day length between %v and %v, but got %v", tc.min, tc.max, result)
+			}
+		})
+	}
+}
+
+func TestCalculateAltitudeCrossingsNoCrossing(t *testing.T) {
+	// Around the June solstice the sun never gets 18° below the horizon in London
+	date := time.Date(2026, time.June, 21, 12, 0, 0, 0, time.UTC)
+	dawn, dusk := CalculateAltitudeCrossings(51.5074, -0.1278, date, AstronomicalTwilightAltitude)
+	if !dawn.IsZero() || !dusk.IsZero() {
+		t.Errorf("Expected no astronomical twilight, but got %v and %v", dawn, dusk)
+	}
+}
+
+func TestCalculateDaylightState(t *testing.T) {
+	testCases := []struct {
+		name      string
+		latitude  float64
+		longitude float64
+		date      time.Time
+		state     DaylightState
+		next      string // date of the next sunrise or sunset that ends a polar period
+	}{
+		{"Reykjavik in midwinter", 64.1466, -21.9426, time.Date(2026, time.December, 21, 0, 0, 0, 0, time.UTC), DaylightNormal, ""},
+		{"Reykjavik in midsummer", 64.1466, -21.9426, time.Date(2026, time.June, 21, 0, 0, 0, 0, time.UTC), DaylightNormal, ""},
+		{"Tromsø polar night", 69.6492, 18.9553, time.Date(2026, time.December, 21, 0, 0, 0, 0, time.UTC), PolarNight, "2027-01-15"},
+		{"Tromsø midnight sun", 69.6492, 18.9553, time.Date(2026, time.June, 21, 0, 0, 0, 0, time.UTC), PolarDay, "2026-07-26"},
+		{"McMurdo in December", -77.8419, 166.6863, time.Date(2026, time.December, 21, 0, 0, 0, 0, time.UTC), PolarDay, "2027-02-20"},
+		{"McMurdo in June", -77.8419, 166.6863, time.Date(2026, time.June, 21, 0, 0, 0, 0, time.UTC), PolarNight, "2026-08-21"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			state := CalculateDaylightState(tc.latitude, tc.longitude, tc.date, StandardSunriseAltitude)
+			if state != tc.state {
+				t.Fatalf("Expected %s, but got %s", tc.state, state)
+			}
+
+			var next time.Time
+			switch state {
+			case PolarNight:
+				next = FindNextSunrise(tc.latitude, tc.longitude, tc.date, StandardSunriseAltitude)
+			case PolarDay:
+				next = FindNextSunset(tc.latitude, tc.longitude, tc.date, StandardSunriseAltitude)
+			default:
+				return
+			}
+
+			// The exact day depends on refraction and the approximation, so allow two days either side
+			expected, _ := time.Parse("2006-01-02", tc.next)
+			if diff := next.Sub(expected); next.IsZero() || diff < -48*time.Hour || diff > 72*time.Hour {
+				t.Errorf("Expected the polar period to end around %s, but got %v", tc.next, next)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package utils
+
+import (
+	"time"
+)
+
+// SolarDay holds the sun's daily events and statistics for one date at a location
+type SolarDay struct {
+	Date        time.Time     // local midnight
+	Sunrise     time.Time     // zero when the sun does not rise
+	Sunset      time.Time     // zero when the sun does not set
+	SolarNoon   time.Time     // solar transit
+	MaxAltitude float64       // altitude at solar transit, degrees
+	DayLength   time.Duration // 24 hours during polar day, zero during polar night
+	State       DaylightState
+}
+
+// SolarCalendarSummary picks out the notable days of a solar calendar. Sunrise and sunset are compared
+// by local clock time; EarliestSunrise and LatestSunset are zero when the sun never rises or sets.
+type SolarCalendarSummary struct {
+	LongestDay      SolarDay
+	ShortestDay     SolarDay
+	EarliestSunrise SolarDay
+	LatestSunset    SolarDay
+}
+
+// CalculateSolarCalendar returns the sunrise, sunset, solar noon, transit altitude and day length
+// for every day of a year, with dates and times in the given time zone
+func CalculateSolarCalendar(algorithm Algorithm, observer Observer, year int, location *time.Location) []SolarDay {
+	var days []SolarDay
+	for date := time.Date(year, time.January, 1, 0, 0, 0, 0, location); date.Year() == year; date = date.AddDate(0, 0, 1) {
+		sunrise, sunset := CalculateSunriseSunsetForObserver(observer, date)
+		noon := CalculateSolarNoon(observer.Longitude, date)
+		maxAltitude, _ := CalculateSunPositionForObserver(algorithm, observer, noon)
+
+		days = append(days, SolarDay{
+			Date:        date,
+			Sunrise:     sunrise,
+			Sunset:      sunset,
+			SolarNoon:   noon,
+			MaxAltitude: maxAltitude,
+			DayLength:   CalculateDayLength(observer.Latitude, observer.Longitude, date, observer.SunriseAltitude()),
+			State:       CalculateDaylightState(observer.Latitude, observer.Longitude, date, observer.SunriseAltitude()),
+		})
+	}
+	return days
+}
+
+// SummarizeSolarCalendar finds the longest and shortest days and the earliest sunrise and latest sunset.
+// Ties go to the first date in the calendar.
+func SummarizeSolarCalendar(days []SolarDay) SolarCalendarSummary {
+	var summary SolarCalendarSummary
+	for i, day := range days {
+		if i == 0 || day.DayLength > summary.LongestDay.DayLength {
+			summary.LongestDay = day
+		}
+		if i == 0 || day.DayLength < summary.ShortestDay.DayLength {
+			summary.ShortestDay = day
+		}
+		if !day.Sunrise.IsZero() && (summary.EarliestSunrise.Sunrise.IsZero() || clockTime(day.Sunrise) < clockTime(summary.EarliestSunrise.Sunrise)) {
+			summary.EarliestSunrise = day
+		}
+		if !day.Sunset.IsZero() && (summary.LatestSunset.Sunset.IsZero() || clockTime(day.Sunset) > clockTime(summary.LatestSunset.Sunset)) {
+			summary.LatestSunset = day
+		}
+	}
+	return summary
+}
+
+// clockTime returns the time of day on the local clock
+func clockTime(t time.Time) time.Duration {
+	hour, min, sec := t.Clock()
+	return time.Duration(hour)*time.Hour + time.Duration(min)*time.Minute + time.Duration(sec)*time.Second
+}
@@ -0,0 +1,77 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCalculateSolarCalendar(t *testing.T) {
+	london, err := time.LoadLocation("Europe/London")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		year int
+		days int
+	}{
+		{2025, 365},
+		{2024, 366},
+	}
+
+	for _, tc := range testCases {
+		days := CalculateSolarCalendar(AlgorithmFast, NewObserver(51.5074, -0.1278), tc.year, london)
+		if len(days) != tc.days {
+			t.Errorf("Expected %d days in %d, but got %d", tc.days, tc.year, len(days))
+		}
+	}
+
+	days := CalculateSolarCalendar(AlgorithmFast, NewObserver(51.5074, -0.1278), 2025, london)
+	summary := SummarizeSolarCalendar(days)
+
+	testDates := []struct {
+		name     string
+		got      time.Time
+		expected string
+		slack    int // days either side
+	}{
+		{"Longest day", summary.LongestDay.Date, "2025-06-21", 1},
+		{"Shortest day", summary.ShortestDay.Date, "2025-12-21", 1},
+		// Earliest sunrise and latest sunset fall around a week before and after the solstice
+		// on the clock, and shift by an hour with daylight saving time
+		{"Earliest sunrise", summary.EarliestSunrise.Date, "2025-06-17", 4},
+		{"Latest sunset", summary.LatestSunset.Date, "2025-06-25", 4},
+	}
+
+	for _, tc := range testDates {
+		t.Run(tc.name, func(t *testing.T) {
+			expected, _ := time.ParseInLocation("2006-01-02", tc.expected, london)
+			if diff := tc.got.Sub(expected).Hours() / 24; diff < -float64(tc.slack) || diff > float64(tc.slack) {
+				t.Errorf("Expected %s within %d days of %s, but got %s", tc.name, tc.slack, tc.expected, tc.got.Format("2006-01-02"))
+			}
+		})
+	}
+
+	if longest := summary.LongestDay.DayLength.Hours(); longest < 16.4 || longest > 16.8 {
+		t.Errorf("Expected a longest day of about 16.6 hours, but got %.2f", longest)
+	}
+	if altitude := summary.LongestDay.MaxAltitude; altitude < 61.5 || altitude > 62.3 {
+		t.Errorf("Expected a transit altitude near 62° at midsummer, but got %.2f", altitude)
+	}
+}
+
+func TestSummarizeSolarCalendarPolar(t *testing.T) {
+	// Longyearbyen has months of polar day and polar night
+	days := CalculateSolarCalendar(AlgorithmFast, NewObserver(78.22, 15.65), 2025, time.UTC)
+	summary := SummarizeSolarCalendar(days)
+
+	if summary.LongestDay.DayLength != 24*time.Hour {
+		t.Errorf("Expected a 24 hour longest day, but got %v", summary.LongestDay.DayLength)
+	}
+	if summary.ShortestDay.DayLength != 0 {
+		t.Errorf("Expected a zero length shortest day, but got %v", summary.ShortestDay.DayLength)
+	}
+	if summary.EarliestSunrise.Sunrise.IsZero() || summary.LatestSunset.Sunset.IsZero() {
+		t.Errorf("Expected sunrises and sunsets in spring and autumn")
+	}
+}
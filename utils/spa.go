@@ -0,0 +1,670 @@
+package utils
+
+import (
+	"math"
+	"time"
+)
+
+// Solar Position Algorithm (SPA) as published by the National Renewable Energy Laboratory.
+// Reference: Reda, I. and Andreas, A. (2004), "Solar Position Algorithm for Solar Radiation
+// Applications", NREL/TP-560-34302. Accuracy is ±0.0003° for the years -2000 to 6000.
+
+// Apparent radius of the sun and refraction at the horizon, in degrees
+const (
+	spaSunRadius    = 0.26667
+	spaAtmosRefract = 0.5667
+)
+
+// spaInput holds everything the SPA needs for a single calculation
+type spaInput struct {
+	dateTime    time.Time
+	deltaT      float64 // TT - UT1 in seconds
+	latitude    float64 // degrees, north positive
+	longitude   float64 // degrees, east positive
+	elevation   float64 // meters above sea level
+	pressure    float64 // millibars
+	temperature float64 // degrees Celsius
+}
+
+// spaResult holds the intermediate and final values of the SPA
+type spaResult struct {
+	jd              float64 // Julian day
+	l               float64 // Earth heliocentric longitude (degrees)
+	b               float64 // Earth heliocentric latitude (degrees)
+	r               float64 // Earth radius vector (AU)
+	deltaPsi        float64 // nutation in longitude (degrees)
+	deltaEpsilon    float64 // nutation in obliquity (degrees)
+	epsilon         float64 // true obliquity of the ecliptic (degrees)
+	lambda          float64 // apparent sun longitude (degrees)
+	alpha           float64 // geocentric right ascension (degrees)
+	delta           float64 // geocentric declination (degrees)
+	deltaPrime      float64 // topocentric declination (degrees)
+	hourAngle       float64 // topocentric local hour angle (degrees)
+	elevationNoRefr float64 // topocentric elevation without refraction (degrees)
+	elevation       float64 // topocentric elevation with refraction (degrees)
+	zenith          float64 // topocentric zenith angle (degrees)
+	azimuth         float64 // topocentric azimuth, eastward from north (degrees)
+	equationOfTime  float64 // equation of time (minutes)
+	siderealTime    float64 // apparent sidereal time at Greenwich (degrees)
+}
+
+// Earth periodic terms: each row is {A, B, C} and contributes A*cos(B + C*JME)
+var spaLTerms = [][][3]float64{
+	{
+		{175347046.0, 0, 0},
+		{3341656.0, 4.6692568, 6283.07585},
+		{34894.0, 4.6261, 12566.1517},
+		{3497.0, 2.7441, 5753.3849},
+		{3418.0, 2.8289, 3.5231},
+		{3136.0, 3.6277, 77713.7715},
+		{2676.0, 4.4181, 7860.4194},
+		{2343.0, 6.1352, 3930.2097},
+		{1324.0, 0.7425, 11506.7698},
+		{1273.0, 2.0371, 529.691},
+		{1199.0, 1.1096, 1577.3435},
+		{990, 5.233, 5884.927},
+		{902, 2.045, 26.298},
+		{857, 3.508, 398.149},
+		{780, 1.179, 5223.694},
+		{753, 2.533, 5507.553},
+		{505, 4.583, 18849.228},
+		{492, 4.205, 775.523},
+		{357, 2.92, 0.067},
+		{317, 5.849, 11790.629},
+		{284, 1.899, 796.298},
+		{271, 0.315, 10977.079},
+		{243, 0.345, 5486.778},
+		{206, 4.806, 2544.314},
+		{205, 1.869, 5573.143},
+		{202, 2.458, 6069.777},
+		{156, 0.833, 213.299},
+		{132, 3.411, 2942.463},
+		{126, 1.083, 20.775},
+		{115, 0.645, 0.98},
+		{103, 0.636, 4694.003},
+		{102, 0.976, 15720.839},
+		{102, 4.267, 7.114},
+		{99, 6.21, 2146.17},
+		{98, 0.68, 155.42},
+		{86, 5.98, 161000.69},
+		{85, 1.3, 6275.96},
+		{85, 3.67, 71430.7},
+		{80, 1.81, 17260.15},
+		{79, 3.04, 12036.46},
+		{75, 1.76, 5088.63},
+		{74, 3.5, 3154.69},
+		{74, 4.68, 801.82},
+		{70, 0.83, 9437.76},
+		{62, 3.98, 8827.39},
+		{61, 1.82, 7084.9},
+		{57, 2.78, 6286.6},
+		{56, 4.39, 14143.5},
+		{56, 3.47, 6279.55},
+		{52, 0.19, 12139.55},
+		{52, 1.33, 1748.02},
+		{51, 0.28, 5856.48},
+		{49, 0.49, 1194.45},
+		{41, 5.37, 8429.24},
+		{41, 2.4, 19651.05},
+		{39, 6.17, 10447.39},
+		{37, 6.04, 10213.29},
+		{37, 2.57, 1059.38},
+		{36, 1.71, 2352.87},
+		{36, 1.78, 6812.77},
+		{33, 0.59, 17789.85},
+		{30, 0.44, 83996.85},
+		{30, 2.74, 1349.87},
+		{25, 3.16, 4690.48},
+	},
+	{
+		{628331966747.0, 0, 0},
+		{206059.0, 2.678235, 6283.07585},
+		{4303.0, 2.6351, 12566.1517},
+		{425.0, 1.59, 3.523},
+		{119.0, 5.796, 26.298},
+		{109.0, 2.966, 1577.344},
+		{93, 2.59, 18849.23},
+		{72, 1.14, 529.69},
+		{68, 1.87, 398.15},
+		{67, 4.41, 5507.55},
+		{59, 2.89, 5223.69},
+		{56, 2.17, 155.42},
+		{45, 0.4, 796.3},
+		{36, 0.47, 775.52},
+		{29, 2.65, 7.11},
+		{21, 5.34, 0.98},
+		{19, 1.85, 5486.78},
+		{19, 4.97, 213.3},
+		{17, 2.99, 6275.96},
+		{16, 0.03, 2544.31},
+		{16, 1.43, 2146.17},
+		{15, 1.21, 10977.08},
+		{12, 2.83, 1748.02},
+		{12, 3.26, 5088.63},
+		{12, 5.27, 1194.45},
+		{12, 2.08, 4694},
+		{11, 0.77, 553.57},
+		{10, 1.3, 6286.6},
+		{10, 4.24, 1349.87},
+		{9, 2.7, 242.73},
+		{9, 5.64, 951.72},
+		{8, 5.3, 2352.87},
+		{6, 2.65, 9437.76},
+		{6, 4.67, 4690.48},
+	},
+	{
+		{52919.0, 0, 0},
+		{8720.0, 1.0721, 6283.0758},
+		{309.0, 0.867, 12566.152},
+		{27, 0.05, 3.52},
+		{16, 5.19, 26.3},
+		{16, 3.68, 155.42},
+		{10, 0.76, 18849.23},
+		{9, 2.06, 77713.77},
+		{7, 0.83, 775.52},
+		{5, 4.66, 1577.34},
+		{4, 1.03, 7.11},
+		{4, 3.44, 5573.14},
+		{3, 5.14, 796.3},
+		{3, 6.05, 5507.55},
+		{3, 1.19, 242.73},
+		{3, 6.12, 529.69},
+		{3, 0.31, 398.15},
+		{3, 2.28, 553.57},
+		{2, 4.38, 5223.69},
+		{2, 3.75, 0.98},
+	},
+	{
+		{289.0, 5.844, 6283.076},
+		{35, 0, 0},
+		{17, 5.49, 12566.15},
+		{3, 5.2, 155.42},
+		{1, 4.72, 3.52},
+		{1, 5.3, 18849.23},
+		{1, 5.97, 242.73},
+	},
+	{
+		{114.0, 3.142, 0},
+		{8, 4.13, 6283.08},
+		{1, 3.84, 12566.15},
+	},
+	{
+		{1, 3.14, 0},
+	},
+}
+
+var spaBTerms = [][][3]float64{
+	{
+		{280.0, 3.199, 84334.662},
+		{102.0, 5.422, 5507.553},
+		{80, 3.88, 5223.69},
+		{44, 3.7, 2352.87},
+		{32, 4, 1577.34},
+	},
+	{
+		{9, 3.9, 5507.55},
+		{6, 1.73, 5223.69},
+	},
+}
+
+var spaRTerms = [][][3]float64{
+	{
+		{100013989.0, 0, 0},
+		{1670700.0, 3.0984635, 6283.07585},
+		{13956.0, 3.05525, 12566.1517},
+		{3084.0, 5.1985, 77713.7715},
+		{1628.0, 1.1739, 5753.3849},
+		{1576.0, 2.8469, 7860.4194},
+		{925.0, 5.453, 11506.77},
+		{542.0, 4.564, 3930.21},
+		{472.0, 3.661, 5884.927},
+		{346.0, 0.964, 5507.553},
+		{329.0, 5.9, 5223.694},
+		{307.0, 0.299, 5573.143},
+		{243.0, 4.273, 11790.629},
+		{212.0, 5.847, 1577.344},
+		{186.0, 5.022, 10977.079},
+		{175.0, 3.012, 18849.228},
+		{110.0, 5.055, 5486.778},
+		{98, 0.89, 6069.78},
+		{86, 5.69, 15720.84},
+		{86, 1.27, 161000.69},
+		{65, 0.27, 17260.15},
+		{63, 0.92, 529.69},
+		{57, 2.01, 83996.85},
+		{56, 5.24, 71430.7},
+		{49, 3.25, 2544.31},
+		{47, 2.58, 775.52},
+		{45, 5.54, 9437.76},
+		{43, 6.01, 6275.96},
+		{39, 5.36, 4694},
+		{38, 2.39, 8827.39},
+		{37, 0.83, 19651.05},
+		{37, 4.9, 12139.55},
+		{36, 1.67, 12036.46},
+		{35, 1.84, 2942.46},
+		{33, 0.24, 7084.9},
+		{32, 0.18, 5088.63},
+		{32, 1.78, 398.15},
+		{28, 1.21, 6286.6},
+		{28, 1.9, 6279.55},
+		{26, 4.59, 10447.39},
+	},
+	{
+		{103019.0, 1.10749, 6283.07585},
+		{1721.0, 1.0644, 12566.1517},
+		{702.0, 3.142, 0},
+		{32, 1.02, 18849.23},
+		{31, 2.84, 5507.55},
+		{25, 1.32, 5223.69},
+		{18, 1.42, 1577.34},
+		{10, 5.91, 10977.08},
+		{9, 1.42, 6275.96},
+		{9, 0.27, 5486.78},
+	},
+	{
+		{4359.0, 5.7846, 6283.0758},
+		{124.0, 5.579, 12566.152},
+		{12, 3.14, 0},
+		{9, 3.63, 77713.77},
+		{6, 1.87, 5573.14},
+		{3, 5.47, 18849.23},
+	},
+	{
+		{145.0, 4.273, 6283.076},
+		{7, 3.92, 12566.15},
+	},
+	{
+		{4, 2.56, 6283.08},
+	},
+}
+
+// Periodic terms for nutation: multipliers of the fundamental arguments X0..X4
+var spaYTerms = [][5]float64{
+	{0, 0, 0, 0, 1},
+	{-2, 0, 0, 2, 2},
+	{0, 0, 0, 2, 2},
+	{0, 0, 0, 0, 2},
+	{0, 1, 0, 0, 0},
+	{0, 0, 1, 0, 0},
+	{-2, 1, 0, 2, 2},
+	{0, 0, 0, 2, 1},
+	{0, 0, 1, 2, 2},
+	{-2, -1, 0, 2, 2},
+	{-2, 0, 1, 0, 0},
+	{-2, 0, 0, 2, 1},
+	{0, 0, -1, 2, 2},
+	{2, 0, 0, 0, 0},
+	{0, 0, 1, 0, 1},
+	{2, 0, -1, 2, 2},
+	{0, 0, -1, 0, 1},
+	{0, 0, 1, 2, 1},
+	{-2, 0, 2, 0, 0},
+	{0, 0, -2, 2, 1},
+	{2, 0, 0, 2, 2},
+	{0, 0, 2, 2, 2},
+	{0, 0, 2, 0, 0},
+	{-2, 0, 1, 2, 2},
+	{0, 0, 0, 2, 0},
+	{-2, 0, 0, 2, 0},
+	{0, 0, -1, 2, 1},
+	{0, 2, 0, 0, 0},
+	{2, 0, -1, 0, 1},
+	{-2, 2, 0, 2, 2},
+	{0, 1, 0, 0, 1},
+	{-2, 0, 1, 0, 1},
+	{0, -1, 0, 0, 1},
+	{0, 0, 2, -2, 0},
+	{2, 0, -1, 2, 1},
+	{2, 0, 1, 2, 2},
+	{0, 1, 0, 2, 2},
+	{-2, 1, 1, 0, 0},
+	{0, -1, 0, 2, 2},
+	{2, 0, 0, 2, 1},
+	{2, 0, 1, 0, 0},
+	{-2, 0, 2, 2, 2},
+	{-2, 0, 1, 2, 1},
+	{2, 0, -2, 0, 1},
+	{2, 0, 0, 0, 1},
+	{0, -1, 1, 0, 0},
+	{-2, -1, 0, 2, 1},
+	{-2, 0, 0, 0, 1},
+	{0, 0, 2, 2, 1},
+	{-2, 0, 2, 0, 1},
+	{-2, 1, 0, 2, 1},
+	{0, 0, 1, -2, 0},
+	{-1, 0, 1, 0, 0},
+	{-2, 1, 0, 0, 0},
+	{1, 0, 0, 0, 0},
+	{0, 0, 1, 2, 0},
+	{0, 0, -2, 2, 2},
+	{-1, -1, 1, 0, 0},
+	{0, 1, 1, 0, 0},
+	{0, -1, 1, 2, 2},
+	{2, -1, -1, 2, 2},
+	{0, 0, 3, 2, 2},
+	{2, -1, 0, 2, 2},
+}
+
+// Nutation coefficients {a, b, c, d} for longitude (a + b*JCE) and obliquity (c + d*JCE)
+var spaPETerms = [][4]float64{
+	{-171996, -174.2, 92025, 8.9},
+	{-13187, -1.6, 5736, -3.1},
+	{-2274, -0.2, 977, -0.5},
+	{2062, 0.2, -895, 0.5},
+	{1426, -3.4, 54, -0.1},
+	{712, 0.1, -7, 0},
+	{-517, 1.2, 224, -0.6},
+	{-386, -0.4, 200, 0},
+	{-301, 0, 129, -0.1},
+	{217, -0.5, -95, 0.3},
+	{-158, 0, 0, 0},
+	{129, 0.1, -70, 0},
+	{123, 0, -53, 0},
+	{63, 0, 0, 0},
+	{63, 0.1, -33, 0},
+	{-59, 0, 26, 0},
+	{-58, -0.1, 32, 0},
+	{-51, 0, 27, 0},
+	{48, 0, 0, 0},
+	{46, 0, -24, 0},
+	{-38, 0, 16, 0},
+	{-31, 0, 13, 0},
+	{29, 0, 0, 0},
+	{29, 0, -12, 0},
+	{26, 0, 0, 0},
+	{-22, 0, 0, 0},
+	{21, 0, -10, 0},
+	{17, -0.1, 0, 0},
+	{16, 0, -8, 0},
+	{-16, 0.1, 7, 0},
+	{-15, 0, 9, 0},
+	{-13, 0, 7, 0},
+	{-12, 0, 6, 0},
+	{11, 0, 0, 0},
+	{-10, 0, 5, 0},
+	{-8, 0, 3, 0},
+	{7, 0, -3, 0},
+	{-7, 0, 0, 0},
+	{-7, 0, 3, 0},
+	{-7, 0, 3, 0},
+	{6, 0, 0, 0},
+	{6, 0, -3, 0},
+	{6, 0, -3, 0},
+	{-6, 0, 3, 0},
+	{-6, 0, 3, 0},
+	{5, 0, 0, 0},
+	{-5, 0, 3, 0},
+	{-5, 0, 3, 0},
+	{-5, 0, 3, 0},
+	{4, 0, 0, 0},
+	{4, 0, 0, 0},
+	{4, 0, 0, 0},
+	{-4, 0, 0, 0},
+	{-4, 0, 0, 0},
+	{-4, 0, 0, 0},
+	{3, 0, 0, 0},
+	{-3, 0, 0, 0},
+	{-3, 0, 0, 0},
+	{-3, 0, 0, 0},
+	{-3, 0, 0, 0},
+	{-3, 0, 0, 0},
+	{-3, 0, 0, 0},
+	{-3, 0, 0, 0},
+}
+
+// CalculateSunPositionSPA calculates the sun's altitude and azimuth using the NREL Solar Position Algorithm.
+// The observer is assumed to be at sea level in a standard atmosphere.
+func CalculateSunPositionSPA(latitude, longitude float64, dateTime time.Time) (altitude, azimuth float64) {
+	result := calculateSPAForObserver(NewObserver(latitude, longitude), dateTime)
+	return result.elevation, result.azimuth
+}
+
+// calculateSPAForObserver runs the SPA for an observer's location and atmosphere
+func calculateSPAForObserver(observer Observer, dateTime time.Time) spaResult {
+	return calculateSPA(spaInput{
+		dateTime:    dateTime,
+		deltaT:      EstimateDeltaT(dateTime),
+		latitude:    observer.Latitude,
+		longitude:   observer.Longitude,
+		elevation:   observer.Elevation,
+		pressure:    observer.Pressure,
+		temperature: observer.Temperature,
+	})
+}
+
+// calculateSPA runs the full SPA for the given input
+func calculateSPA(in spaInput) spaResult {
+	var res spaResult
+
+	// Julian day, ephemeris day, century and millennium
+	res.jd = julianDay(in.dateTime)
+	jde := res.jd + in.deltaT/86400.0
+	jce := (jde - 2451545.0) / 36525.0
+	jme := jce / 10.0
+
+	// Earth heliocentric position
+	res.l = limitDegrees(radToDeg(spaEarthValue(spaLTerms, jme)))
+	res.b = radToDeg(spaEarthValue(spaBTerms, jme))
+	res.r = spaEarthValue(spaRTerms, jme)
+
+	// Geocentric longitude and latitude
+	theta := limitDegrees(res.l + 180.0)
+	beta := -res.b
+
+	// Nutation in longitude and obliquity
+	res.deltaPsi, res.deltaEpsilon = spaNutation(jce)
+
+	// True obliquity of the ecliptic
+	u := jme / 10.0
+	epsilon0 := 84381.448 + u*(-4680.93+u*(-1.55+u*(1999.25+u*(-51.38+u*(-249.67+
+		u*(-39.05+u*(7.12+u*(27.87+u*(5.79+u*2.45)))))))))
+	res.epsilon = epsilon0/3600.0 + res.deltaEpsilon
+
+	// Aberration correction and apparent sun longitude
+	deltaTau := -20.4898 / (3600.0 * res.r)
+	res.lambda = theta + res.deltaPsi + deltaTau
+
+	// Apparent sidereal time at Greenwich
+	nu := apparentSiderealTime(res.jd, res.deltaPsi, res.epsilon)
+	res.siderealTime = nu
+
+	// Geocentric sun right ascension and declination
+	lambdaRad := degToRad(res.lambda)
+	epsilonRad := degToRad(res.epsilon)
+	betaRad := degToRad(beta)
+	res.alpha = limitDegrees(radToDeg(math.Atan2(
+		math.Sin(lambdaRad)*math.Cos(epsilonRad)-math.Tan(betaRad)*math.Sin(epsilonRad),
+		math.Cos(lambdaRad))))
+	res.delta = radToDeg(math.Asin(math.Sin(betaRad)*math.Cos(epsilonRad) +
+		math.Cos(betaRad)*math.Sin(epsilonRad)*math.Sin(lambdaRad)))
+
+	// Observer local hour angle
+	h := limitDegrees(nu + in.longitude - res.alpha)
+
+	// Topocentric correction for parallax
+	xi := 8.794 / (3600.0 * res.r)
+	res.hourAngle, res.deltaPrime = topocentricCorrection(h, res.delta, xi, in.latitude, in.elevation)
+
+	// Topocentric elevation angle without and with refraction, and azimuth
+	res.elevationNoRefr, res.azimuth = horizontalCoordinates(res.hourAngle, res.deltaPrime, in.latitude)
+	res.elevation = res.elevationNoRefr + spaRefraction(res.elevationNoRefr, in.pressure, in.temperature)
+	res.zenith = 90.0 - res.elevation
+
+	// Equation of time
+	m := 280.4664567 + jme*(360007.6982779+jme*(0.03032028+
+		jme*(1.0/49931.0+jme*(-1.0/15300.0+jme*(-1.0/2000000.0)))))
+	res.equationOfTime = limitMinutes(4.0 * (m - 0.0057183 - res.alpha +
+		res.deltaPsi*math.Cos(epsilonRad)))
+
+	return res
+}
+
+// apparentSiderealTime returns the apparent sidereal time at Greenwich in degrees for a Julian day (UT),
+// given the nutation in longitude and the true obliquity in degrees
+func apparentSiderealTime(jd, deltaPsi, epsilon float64) float64 {
+	jc := (jd - 2451545.0) / 36525.0
+	nu0 := limitDegrees(280.46061837 + 360.98564736629*(jd-2451545.0) +
+		jc*jc*(0.000387933-jc/38710000.0))
+	return nu0 + deltaPsi*math.Cos(degToRad(epsilon))
+}
+
+// topocentricCorrection shifts a geocentric hour angle and declination to those seen by an observer
+// at the given latitude and elevation, for a body with equatorial horizontal parallax xi (all in degrees)
+func topocentricCorrection(hourAngle, declination, xi, latitude, elevation float64) (hourAnglePrime, declinationPrime float64) {
+	latRad := degToRad(latitude)
+	xiRad := degToRad(xi)
+	hRad := degToRad(hourAngle)
+	deltaRad := degToRad(declination)
+	uTerm := math.Atan(0.99664719 * math.Tan(latRad))
+	x := math.Cos(uTerm) + elevation*math.Cos(latRad)/6378140.0
+	y := 0.99664719*math.Sin(uTerm) + elevation*math.Sin(latRad)/6378140.0
+
+	deltaAlphaRad := math.Atan2(-x*math.Sin(xiRad)*math.Sin(hRad),
+		math.Cos(deltaRad)-x*math.Sin(xiRad)*math.Cos(hRad))
+	deltaPrimeRad := math.Atan2((math.Sin(deltaRad)-y*math.Sin(xiRad))*math.Cos(deltaAlphaRad),
+		math.Cos(deltaRad)-x*math.Sin(xiRad)*math.Cos(hRad))
+	return hourAngle - radToDeg(deltaAlphaRad), radToDeg(deltaPrimeRad)
+}
+
+// horizontalCoordinates converts a local hour angle and declination to elevation and azimuth
+// measured eastward from north, for an observer at the given latitude (all in degrees)
+func horizontalCoordinates(hourAngle, declination, latitude float64) (elevation, azimuth float64) {
+	latRad := degToRad(latitude)
+	hRad := degToRad(hourAngle)
+	deltaRad := degToRad(declination)
+	elevation = radToDeg(math.Asin(math.Sin(latRad)*math.Sin(deltaRad) +
+		math.Cos(latRad)*math.Cos(deltaRad)*math.Cos(hRad)))
+	astronomersAzimuth := radToDeg(math.Atan2(math.Sin(hRad),
+		math.Cos(hRad)*math.Sin(latRad)-math.Tan(deltaRad)*math.Cos(latRad)))
+	return elevation, limitDegrees(astronomersAzimuth + 180.0)
+}
+
+// spaEarthValue sums the periodic term tables into a polynomial in JME
+func spaEarthValue(terms [][][3]float64, jme float64) float64 {
+	total := 0.0
+	power := 1.0
+	for _, series := range terms {
+		sum := 0.0
+		for _, term := range series {
+			sum += term[0] * math.Cos(term[1]+term[2]*jme)
+		}
+		total += sum * power
+		power *= jme
+	}
+	return total / 1.0e8
+}
+
+// spaNutation returns the nutation in longitude and obliquity in degrees
+func spaNutation(jce float64) (deltaPsi, deltaEpsilon float64) {
+	x := [5]float64{
+		// Mean elongation of the moon from the sun
+		297.85036 + jce*(445267.111480+jce*(-0.0019142+jce/189474.0)),
+		// Mean anomaly of the sun
+		357.52772 + jce*(35999.050340+jce*(-0.0001603-jce/300000.0)),
+		// Mean anomaly of the moon
+		134.96298 + jce*(477198.867398+jce*(0.0086972+jce/56250.0)),
+		// Moon's argument of latitude
+		93.27191 + jce*(483202.017538+jce*(-0.0036825+jce/327270.0)),
+		// Longitude of the ascending node of the moon's mean orbit
+		125.04452 + jce*(-1934.136261+jce*(0.0020708+jce/450000.0)),
+	}
+
+	sumPsi, sumEpsilon := 0.0, 0.0
+	for i, y := range spaYTerms {
+		arg := 0.0
+		for j := range x {
+			arg += x[j] * y[j]
+		}
+		argRad := degToRad(arg)
+		pe := spaPETerms[i]
+		sumPsi += (pe[0] + pe[1]*jce) * math.Sin(argRad)
+		sumEpsilon += (pe[2] + pe[3]*jce) * math.Cos(argRad)
+	}
+
+	return sumPsi / 36000000.0, sumEpsilon / 36000000.0
+}
+
+// spaRefraction returns the atmospheric refraction correction in degrees for a true elevation
+func spaRefraction(elevation, pressure, temperature float64) float64 {
+	if elevation < -(spaSunRadius + spaAtmosRefract) {
+		return 0
+	}
+	return (pressure / 1010.0) * (283.0 / (273.0 + temperature)) *
+		1.02 / (60.0 * math.Tan(degToRad(elevation+10.3/(elevation+5.11))))
+}
+
+// julianDay converts a time to its Julian day number (UT)
+func julianDay(t time.Time) float64 {
+	return float64(t.UnixNano())/float64(24*time.Hour) + 2440587.5
+}
+
+// EstimateDeltaT estimates TT - UT1 in seconds for a date using the Espenak and Meeus polynomials
+func EstimateDeltaT(t time.Time) float64 {
+	year, month, _ := t.UTC().Date()
+	y := float64(year) + (float64(month)-0.5)/12.0
+
+	switch {
+	case y < 1860:
+		u := (y - 1820) / 100
+		return -20 + 32*u*u
+	case y < 1900:
+		t := y - 1860
+		return 7.62 + 0.5737*t - 0.251754*t*t + 0.01680668*t*t*t -
+			0.0004473624*math.Pow(t, 4) + math.Pow(t, 5)/233174
+	case y < 1920:
+		t := y - 1900
+		return -2.79 + 1.494119*t - 0.0598939*t*t + 0.0061966*t*t*t - 0.000197*math.Pow(t, 4)
+	case y < 1941:
+		t := y - 1920
+		return 21.20 + 0.84493*t - 0.076100*t*t + 0.0020936*t*t*t
+	case y < 1961:
+		t := y - 1950
+		return 29.07 + 0.407*t - t*t/233 + t*t*t/2547
+	case y < 1986:
+		t := y - 1975
+		return 45.45 + 1.067*t - t*t/260 - t*t*t/718
+	case y < 2005:
+		t := y - 2000
+		return 63.86 + 0.3345*t - 0.060374*t*t + 0.0017275*t*t*t +
+			0.000651814*math.Pow(t, 4) + 0.00002373599*math.Pow(t, 5)
+	case y < 2050:
+		t := y - 2000
+		return 62.92 + 0.32217*t + 0.005589*t*t
+	case y < 2150:
+		u := (y - 1820) / 100
+		return -20 + 32*u*u - 0.5628*(2150-y)
+	default:
+		u := (y - 1820) / 100
+		return -20 + 32*u*u
+	}
+}
+
+// degToRad converts degrees to radians
+func degToRad(degrees float64) float64 {
+	return degrees * math.Pi / 180.0
+}
+
+// radToDeg converts radians to degrees
+func radToDeg(radians float64) float64 {
+	return radians * 180.0 / math.Pi
+}
+
+// limitDegrees wraps an angle into the 0-360 range
+func limitDegrees(degrees float64) float64 {
+	degrees = math.Mod(degrees, 360.0)
+	if degrees < 0 {
+		degrees += 360.0
+	}
+	return degrees
+}
+
+// limitMinutes wraps a time difference in minutes into the -20..20 range
+func limitMinutes(minutes float64) float64 {
+	if minutes < -20.0 {
+		minutes += 1440.0
+	} else if minutes > 20.0 {
+		minutes -= 1440.0
+	}
+	return minutes
+}
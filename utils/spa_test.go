@@ -0,0 +1,91 @@
+package utils
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// spaReferenceInput is the worked example from the NREL SPA report (NREL/TP-560-34302, Table A5.1)
+func spaReferenceInput() spaInput {
+	return spaInput{
+		dateTime:    time.Date(2003, time.October, 17, 12, 30, 30, 0, time.FixedZone("MST", -7*3600)),
+		deltaT:      67,
+		latitude:    39.742476,
+		longitude:   -105.1786,
+		elevation:   1830.14,
+		pressure:    820,
+		temperature: 11,
+	}
+}
+
+func TestCalculateSPAReferenceValues(t *testing.T) {
+	result := calculateSPA(spaReferenceInput())
+
+	testCases := []struct {
+		name      string
+		got       float64
+		expected  float64
+		tolerance float64
+	}{
+		{"Julian day", result.jd, 2452930.312847, 1e-6},
+		{"Heliocentric longitude", result.l, 24.0182616917, 1e-6},
+		{"Heliocentric latitude", result.b, -0.0001011219, 1e-8},
+		{"Radius vector", result.r, 0.9965422974, 1e-8},
+		{"Nutation in longitude", result.deltaPsi, -0.00399840, 1e-7},
+		{"Nutation in obliquity", result.deltaEpsilon, 0.00166657, 1e-7},
+		{"True obliquity", result.epsilon, 23.440465, 1e-6},
+		{"Apparent sun longitude", result.lambda, 204.0085519281, 1e-6},
+		{"Right ascension", result.alpha, 202.22741, 1e-5},
+		{"Declination", result.delta, -9.31434, 1e-5},
+		{"Topocentric zenith", result.zenith, 50.11162, 1e-5},
+		{"Topocentric azimuth", result.azimuth, 194.34024, 1e-5},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if math.Abs(tc.got-tc.expected) > tc.tolerance {
+				t.Errorf("Expected %s %.10f, but got %.10f", tc.name, tc.expected, tc.got)
+			}
+		})
+	}
+}
+
+func TestParseAlgorithm(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected Algorithm
+		valid    bool
+	}{
+		{"", AlgorithmFast, true},
+		{"fast", AlgorithmFast, true},
+		{"SPA", AlgorithmSPA, true},
+		{"unknown", "", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			result, err := ParseAlgorithm(tc.input)
+			if (err == nil) != tc.valid {
+				t.Errorf("Expected valid=%t for %q, but got error %v", tc.valid, tc.input, err)
+			}
+			if result != tc.expected {
+				t.Errorf("Expected algorithm %q for %q, but got %q", tc.expected, tc.input, result)
+			}
+		})
+	}
+}
+
+func TestCalculateSunPositionSPAAgreesWithFast(t *testing.T) {
+	// Both algorithms should agree to within a fraction of a degree on a Khartoum morning
+	dateTime := time.Date(2026, time.January, 28, 9, 0, 0, 0, time.FixedZone("Local", 2*3600))
+	fastAlt, fastAz := CalculateSunPosition(15.5007, 32.5599, dateTime)
+	spaAlt, spaAz := CalculateSunPositionSPA(15.5007, 32.5599, dateTime)
+
+	if math.Abs(fastAlt-spaAlt) > 0.5 {
+		t.Errorf("Expected altitudes to agree, but got fast %.4f and SPA %.4f", fastAlt, spaAlt)
+	}
+	if math.Abs(fastAz-spaAz) > 1.0 {
+		t.Errorf("Expected azimuths to agree, but got fast %.4f and SPA %.4f", fastAz, spaAz)
+	}
+}
@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"math"
+	"time"
+)
+
+// SolsticeBearings holds the sunrise and sunset azimuths on the June and December solstices, which bound
+// the directions the sun rises and sets in through the year. A bearing is nil when the sun doesn't rise
+// or set on that solstice.
+type SolsticeBearings struct {
+	JuneSunrise     *float64 `json:"june_sunrise,omitempty"`
+	JuneSunset      *float64 `json:"june_sunset,omitempty"`
+	DecemberSunrise *float64 `json:"december_sunrise,omitempty"`
+	DecemberSunset  *float64 `json:"december_sunset,omitempty"`
+}
+
+// CalculateRiseSetAzimuths returns the sun's azimuth, in degrees eastward from north, when its center
+// rises through and sets below the given altitude on a date. ok is false when the sun stays above
+// or below that altitude all day. The declination is taken on the same solar day as CalculateAltitudeCrossings.
+func CalculateRiseSetAzimuths(latitude, longitude float64, date time.Time, altitudeDeg float64) (rise, set float64, ok bool) {
+	year, month, day := solarDate(longitude, date)
+	decl := calculateDeclinationAccurate(daysSinceJan1(year, month, day)) // radians
+
+	latRad := latitude * math.Pi / 180.0
+	h0 := altitudeDeg * math.Pi / 180.0
+
+	// Same crossing condition as CalculateAltitudeCrossings
+	cosH0 := (math.Sin(h0) - math.Sin(latRad)*math.Sin(decl)) / (math.Cos(latRad) * math.Cos(decl))
+	if cosH0 > 1 || cosH0 < -1 {
+		return 0, 0, false
+	}
+
+	// Azimuth from the altitude and declination; the sun rises east of the meridian and sets symmetrically west
+	cosA := (math.Sin(decl) - math.Sin(latRad)*math.Sin(h0)) / (math.Cos(latRad) * math.Cos(h0))
+	rise = math.Acos(math.Max(-1, math.Min(1, cosA))) * 180 / math.Pi
+	return rise, 360 - rise, true
+}
+
+// CalculateSolsticeBearings returns the sunrise and sunset azimuths on the solstices of a year for an observer,
+// taking the solstice dates in the given time zone
+func CalculateSolsticeBearings(observer Observer, year int, location *time.Location) SolsticeBearings {
+	june := time.Date(year, time.June, 21, 12, 0, 0, 0, location)
+	december := time.Date(year, time.December, 21, 12, 0, 0, 0, location)
+	if seasons, err := CalculateSeasons(year); err == nil {
+		june = seasons.JuneSolstice.In(location)
+		december = seasons.DecemberSolstice.In(location)
+	}
+
+	var bearings SolsticeBearings
+	if rise, set, ok := CalculateRiseSetAzimuths(observer.Latitude, observer.Longitude, june, observer.SunriseAltitude()); ok {
+		bearings.JuneSunrise, bearings.JuneSunset = &rise, &set
+	}
+	if rise, set, ok := CalculateRiseSetAzimuths(observer.Latitude, observer.Longitude, december, observer.SunriseAltitude()); ok {
+		bearings.DecemberSunrise, bearings.DecemberSunset = &rise, &set
+	}
+	return bearings
+}
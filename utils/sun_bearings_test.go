@@ -0,0 +1,70 @@
+package utils
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestCalculateRiseSetAzimuths(t *testing.T) {
+	testCases := []struct {
+		name     string
+		latitude float64
+		date     time.Time
+		rise     float64
+	}{
+		// Published azimuths for London: about 49° at the June solstice and 128° at the December solstice
+		{"London June solstice", 51.5074, time.Date(2026, time.June, 21, 0, 0, 0, 0, time.UTC), 49.3},
+		{"London December solstice", 51.5074, time.Date(2026, time.December, 21, 0, 0, 0, 0, time.UTC), 128.0},
+		// At the equinox the sun rises almost due east everywhere
+		{"Equator at the equinox", 0, time.Date(2026, time.March, 20, 0, 0, 0, 0, time.UTC), 90},
+		// In the southern summer the sun rises south of east
+		{"Sydney December solstice", -33.8688, time.Date(2026, time.December, 21, 0, 0, 0, 0, time.UTC), 119.2},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rise, set, ok := CalculateRiseSetAzimuths(tc.latitude, 0, tc.date, StandardSunriseAltitude)
+			if !ok {
+				t.Fatalf("Expected the sun to rise and set, but it did not")
+			}
+			if math.Abs(rise-tc.rise) > 1 {
+				t.Errorf("Expected sunrise azimuth %.1f, but got %.2f", tc.rise, rise)
+			}
+			if math.Abs(rise+set-360) > 1e-9 {
+				t.Errorf("Expected sunset mirrored across the meridian, but got %.2f and %.2f", rise, set)
+			}
+		})
+	}
+
+	// The sun's azimuth at the computed sunrise time matches the bearing
+	observer := NewObserver(51.5074, -0.1278)
+	date := time.Date(2026, time.June, 21, 0, 0, 0, 0, time.UTC)
+	sunrise, _ := CalculateSunriseSunsetForObserver(observer, date)
+	_, azimuth := CalculateSunPositionForObserver(AlgorithmSPA, observer, sunrise)
+	if rise, _, _ := CalculateRiseSetAzimuths(observer.Latitude, observer.Longitude, date, observer.SunriseAltitude()); math.Abs(rise-azimuth) > 0.5 {
+		t.Errorf("Expected the SPA azimuth at sunrise %.2f to match the bearing %.2f", azimuth, rise)
+	}
+
+	if _, _, ok := CalculateRiseSetAzimuths(78.2232, 15.6267, time.Date(2026, time.June, 21, 0, 0, 0, 0, time.UTC), StandardSunriseAltitude); ok {
+		t.Errorf("Expected no sunrise during the midnight sun in Longyearbyen")
+	}
+}
+
+func TestCalculateSolsticeBearings(t *testing.T) {
+	london, _ := time.LoadLocation("Europe/London")
+	bearings := CalculateSolsticeBearings(NewObserver(51.5074, -0.1278), 2026, london)
+	if bearings.JuneSunrise == nil || bearings.DecemberSunset == nil {
+		t.Fatalf("Expected all four bearings for London, but got %+v", bearings)
+	}
+	// The sunrise direction swings about 79° between the solstices
+	if swing := *bearings.DecemberSunrise - *bearings.JuneSunrise; math.Abs(swing-78.7) > 1.5 {
+		t.Errorf("Expected a swing of about 78.7°, but got %.2f", swing)
+	}
+
+	// Above the Arctic Circle the sun neither rises nor sets on either solstice
+	tromso := CalculateSolsticeBearings(NewObserver(69.6492, 18.9553), 2026, time.UTC)
+	if tromso.JuneSunrise != nil || tromso.DecemberSunrise != nil {
+		t.Errorf("Expected no solstice bearings in Tromsø, but got %+v", tromso)
+	}
+}
@@ -0,0 +1,38 @@
+package utils
+
+import (
+	"time"
+)
+
+// SunPathPoint is the sun's position at one moment of a daily sun path
+type SunPathPoint struct {
+	Time     string  `json:"time"` // Format: HH:MM local time
+	Hour     float64 `json:"hour"` // Local clock time in decimal hours
+	Altitude float64 `json:"altitude"`
+	Azimuth  float64 `json:"azimuth"`
+}
+
+// CalculateSunPath samples the sun's position every step from local midnight to the next midnight
+// of the given date, in the date's time zone
+func CalculateSunPath(algorithm Algorithm, observer Observer, date time.Time, step time.Duration) []SunPathPoint {
+	if step <= 0 {
+		return nil
+	}
+
+	year, month, day := date.Date()
+	start := time.Date(year, month, day, 0, 0, 0, 0, date.Location())
+	end := time.Date(year, month, day+1, 0, 0, 0, 0, date.Location())
+
+	var points []SunPathPoint
+	for t := start; t.Before(end); t = t.Add(step) {
+		altitude, azimuth := CalculateSunPositionForObserver(algorithm, observer, t)
+		hour, min, sec := t.Clock()
+		points = append(points, SunPathPoint{
+			Time:     t.Format("15:04"),
+			Hour:     float64(hour) + float64(min)/60.0 + float64(sec)/3600.0,
+			Altitude: altitude,
+			Azimuth:  azimuth,
+		})
+	}
+	return points
+}
@@ -0,0 +1,46 @@
+package utils
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestCalculateSunPath(t *testing.T) {
+	khartoum := TimeZoneForCoordinates(15.5007, 32.5599)
+	date := time.Date(2026, time.January, 28, 0, 0, 0, 0, khartoum)
+	observer := NewObserver(15.5007, 32.5599)
+
+	points := CalculateSunPath(AlgorithmFast, observer, date, 30*time.Minute)
+	if len(points) != 48 {
+		t.Fatalf("Expected 48 points at a 30 minute step, but got %d", len(points))
+	}
+
+	// Each point should match a direct calculation at the same time
+	for _, i := range []int{0, 18, 24, 47} {
+		point := points[i]
+		at, err := time.ParseInLocation("2006-01-02 15:04", "2026-01-28 "+point.Time, khartoum)
+		if err != nil {
+			t.Fatal(err)
+		}
+		altitude, azimuth := CalculateSunPosition(observer.Latitude, observer.Longitude, at)
+		if math.Abs(point.Altitude-altitude) > 1e-9 || math.Abs(point.Azimuth-azimuth) > 1e-9 {
+			t.Errorf("Expected (%.4f, %.4f) at %s, but got (%.4f, %.4f)", altitude, azimuth, point.Time, point.Altitude, point.Azimuth)
+		}
+	}
+
+	if points[24].Time != "12:00" || points[24].Hour != 12 {
+		t.Errorf("Expected point 24 at 12:00, but got %s (%.2f)", points[24].Time, points[24].Hour)
+	}
+}
+
+func TestCalculateSunPathDaylightSavingDay(t *testing.T) {
+	// Clocks in Madrid skip from 02:00 to 03:00 on 29 March 2026, so the day has 23 hours
+	madrid := TimeZoneForCoordinates(40.4168, -3.7038)
+	date := time.Date(2026, time.March, 29, 0, 0, 0, 0, madrid)
+
+	points := CalculateSunPath(AlgorithmFast, NewObserver(40.4168, -3.7038), date, time.Hour)
+	if len(points) != 23 {
+		t.Errorf("Expected 23 hourly points on the daylight saving day, but got %d", len(points))
+	}
+}
@@ -0,0 +1,311 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sync"
+	"time"
+	_ "time/tzdata" // Embed the IANA time zone database so zones resolve offline
+)
+
+// nearestZoneMaxDegrees is how far outside every boundary a point may lie and still be
+// assigned to the closest zone, which covers coastlines and the simplified outlines
+const nearestZoneMaxDegrees = 1.0
+
+// TimeZoneFinder looks up the IANA time zone for a coordinate from a set of boundaries
+type TimeZoneFinder struct {
+	zones []timeZoneShape
+}
+
+// timeZoneShape is one zone's polygons with a bounding box for quick rejection
+type timeZoneShape struct {
+	tzid                           string
+	polygons                       [][][][2]float64 // polygon -> ring -> point (lon, lat)
+	area                           float64
+	minLon, minLat, maxLon, maxLat float64
+}
+
+// geoJSONFeatureCollection is the subset of GeoJSON needed to read time zone boundaries
+type geoJSONFeatureCollection struct {
+	Features []struct {
+		Properties struct {
+			TZID string `json:"tzid"`
+		} `json:"properties"`
+		Geometry struct {
+			Type        string          `json:"type"`
+			Coordinates json.RawMessage `json:"coordinates"`
+		} `json:"geometry"`
+	} `json:"features"`
+}
+
+var (
+	timeZoneFinderMu sync.RWMutex
+	timeZoneFinder   *TimeZoneFinder
+
+	// loadedLocations caches time.LoadLocation results by zone name
+	loadedLocations sync.Map
+)
+
+// NewTimeZoneFinder parses GeoJSON boundaries where each feature has a tzid property
+// and a Polygon or MultiPolygon geometry
+func NewTimeZoneFinder(data []byte) (*TimeZoneFinder, error) {
+	var collection geoJSONFeatureCollection
+	if err := json.Unmarshal(data, &collection); err != nil {
+		return nil, fmt.Errorf("failed to parse time zone boundaries: %w", err)
+	}
+
+	finder := &TimeZoneFinder{}
+	for _, feature := range collection.Features {
+		tzid := feature.Properties.TZID
+		if tzid == "" {
+			continue
+		}
+
+		var polygons [][][][2]float64
+		switch feature.Geometry.Type {
+		case "Polygon":
+			var polygon [][][2]float64
+			if err := json.Unmarshal(feature.Geometry.Coordinates, &polygon); err != nil {
+				return nil, fmt.Errorf("invalid polygon for %s: %w", tzid, err)
+			}
+			polygons = append(polygons, polygon)
+		case "MultiPolygon":
+			if err := json.Unmarshal(feature.Geometry.Coordinates, &polygons); err != nil {
+				return nil, fmt.Errorf("invalid multipolygon for %s: %w", tzid, err)
+			}
+		default:
+			continue
+		}
+
+		finder.zones = append(finder.zones, newTimeZoneShape(tzid, polygons))
+	}
+
+	if len(finder.zones) == 0 {
+		return nil, fmt.Errorf("no time zone boundaries found")
+	}
+	return finder, nil
+}
+
+// newTimeZoneShape computes the bounding box and area of a zone's polygons
+func newTimeZoneShape(tzid string, polygons [][][][2]float64) timeZoneShape {
+	shape := timeZoneShape{
+		tzid:     tzid,
+		polygons: polygons,
+		minLon:   math.Inf(1),
+		minLat:   math.Inf(1),
+		maxLon:   math.Inf(-1),
+		maxLat:   math.Inf(-1),
+	}
+	for _, polygon := range polygons {
+		for i, ring := range polygon {
+			ringArea := 0.0
+			for j, p := range ring {
+				shape.minLon = math.Min(shape.minLon, p[0])
+				shape.maxLon = math.Max(shape.maxLon, p[0])
+				shape.minLat = math.Min(shape.minLat, p[1])
+				shape.maxLat = math.Max(shape.maxLat, p[1])
+				q := ring[(j+1)%len(ring)]
+				ringArea += p[0]*q[1] - q[0]*p[1]
+			}
+			// Holes (inner rings) reduce the area of the outer ring
+			if i == 0 {
+				shape.area += math.Abs(ringArea) / 2
+			} else {
+				shape.area -= math.Abs(ringArea) / 2
+			}
+		}
+	}
+	return shape
+}
+
+// contains reports whether a point lies inside any of the zone's polygons
+func (s *timeZoneShape) contains(lat, lon float64) bool {
+	if lon < s.minLon || lon > s.maxLon || lat < s.minLat || lat > s.maxLat {
+		return false
+	}
+	for _, polygon := range s.polygons {
+		if len(polygon) == 0 || !ringContains(polygon[0], lat, lon) {
+			continue
+		}
+		inHole := false
+		for _, hole := range polygon[1:] {
+			if ringContains(hole, lat, lon) {
+				inHole = true
+				break
+			}
+		}
+		if !inHole {
+			return true
+		}
+	}
+	return false
+}
+
+// distance returns the approximate distance in degrees from a point to the zone's outline
+func (s *timeZoneShape) distance(lat, lon float64) float64 {
+	cosLat := math.Cos(degToRad(lat))
+	best := math.Inf(1)
+	for _, polygon := range s.polygons {
+		if len(polygon) == 0 {
+			continue
+		}
+		ring := polygon[0]
+		for i := range ring {
+			a, b := ring[i], ring[(i+1)%len(ring)]
+			best = math.Min(best, segmentDistance(lon*cosLat, lat, a[0]*cosLat, a[1], b[0]*cosLat, b[1]))
+		}
+	}
+	return best
+}
+
+// ringContains implements the even-odd ray casting test for a closed ring of (lon, lat) points
+func ringContains(ring [][2]float64, lat, lon float64) bool {
+	inside := false
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		xi, yi := ring[i][0], ring[i][1]
+		xj, yj := ring[j][0], ring[j][1]
+		if (yi > lat) != (yj > lat) && lon < (xj-xi)*(lat-yi)/(yj-yi)+xi {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// segmentDistance returns the planar distance from point p to the segment ab
+func segmentDistance(px, py, ax, ay, bx, by float64) float64 {
+	dx, dy := bx-ax, by-ay
+	t := 0.0
+	if lengthSq := dx*dx + dy*dy; lengthSq > 0 {
+		t = math.Max(0, math.Min(1, ((px-ax)*dx+(py-ay)*dy)/lengthSq))
+	}
+	return math.Hypot(px-(ax+t*dx), py-(ay+t*dy))
+}
+
+// Lookup returns the IANA name of the time zone containing the coordinate.
+// When several boundaries overlap the smallest wins; points outside every boundary
+// fall back to the closest zone nearby, and then to the nautical zone for the longitude.
+func (f *TimeZoneFinder) Lookup(lat, lon float64) string {
+	best := -1
+	for i := range f.zones {
+		if f.zones[i].contains(lat, lon) && (best < 0 || f.zones[i].area < f.zones[best].area) {
+			best = i
+		}
+	}
+	if best >= 0 {
+		return f.zones[best].tzid
+	}
+
+	nearestDistance := nearestZoneMaxDegrees
+	for i := range f.zones {
+		s := &f.zones[i]
+		if lon < s.minLon-nearestZoneMaxDegrees*2 || lon > s.maxLon+nearestZoneMaxDegrees*2 ||
+			lat < s.minLat-nearestZoneMaxDegrees || lat > s.maxLat+nearestZoneMaxDegrees {
+			continue
+		}
+		if d := s.distance(lat, lon); d < nearestDistance {
+			nearestDistance = d
+			best = i
+		}
+	}
+	if best >= 0 {
+		return f.zones[best].tzid
+	}
+
+	return NauticalTimeZone(lon)
+}
+
+// NauticalTimeZone returns the Etc/GMT zone for a longitude, as used at sea.
+// Note that Etc/GMT names use inverted signs: Etc/GMT-3 is three hours ahead of UTC.
+func NauticalTimeZone(longitude float64) string {
+	offset := nauticalOffset(longitude)
+	switch {
+	case offset == 0:
+		return "Etc/GMT"
+	case offset > 0:
+		return fmt.Sprintf("Etc/GMT-%d", offset)
+	default:
+		return fmt.Sprintf("Etc/GMT+%d", -offset)
+	}
+}
+
+// nauticalOffset returns the whole hours ahead of UTC at a longitude, or 0 when it is not a valid longitude
+func nauticalOffset(longitude float64) int {
+	if !(longitude >= -180 && longitude <= 180) {
+		return 0
+	}
+	return int(math.Round(longitude / 15.0))
+}
+
+// nauticalLocation returns the Etc/GMT zone for a longitude, or an equivalent fixed zone
+// when the time zone database is unavailable, so it never returns nil
+func nauticalLocation(longitude float64) *time.Location {
+	name := NauticalTimeZone(longitude)
+	if location, err := time.LoadLocation(name); err == nil {
+		return location
+	}
+	return time.FixedZone(name, nauticalOffset(longitude)*3600)
+}
+
+// LoadTimeZoneBoundariesFile replaces the embedded boundaries with a GeoJSON file, such as
+// the combined output of timezone-boundary-builder for full-resolution lookups
+func LoadTimeZoneBoundariesFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read time zone boundaries: %w", err)
+	}
+	finder, err := NewTimeZoneFinder(data)
+	if err != nil {
+		return err
+	}
+
+	timeZoneFinderMu.Lock()
+	timeZoneFinder = finder
+	timeZoneFinderMu.Unlock()
+	return nil
+}
+
+// defaultTimeZoneFinder returns the active finder, parsing the embedded boundaries on first use
+func defaultTimeZoneFinder() *TimeZoneFinder {
+	timeZoneFinderMu.RLock()
+	finder := timeZoneFinder
+	timeZoneFinderMu.RUnlock()
+	if finder != nil {
+		return finder
+	}
+
+	timeZoneFinderMu.Lock()
+	defer timeZoneFinderMu.Unlock()
+	if timeZoneFinder == nil {
+		parsed, err := NewTimeZoneFinder(timeZoneBoundariesGeoJSON)
+		if err != nil {
+			// The embedded data is part of the build, so this only happens if it is corrupted
+			panic(fmt.Sprintf("invalid embedded time zone boundaries: %v", err))
+		}
+		timeZoneFinder = parsed
+	}
+	return timeZoneFinder
+}
+
+// LookupTimeZone returns the IANA time zone name for a coordinate
+func LookupTimeZone(latitude, longitude float64) string {
+	return defaultTimeZoneFinder().Lookup(latitude, longitude)
+}
+
+// TimeZoneForCoordinates returns the time zone location for a coordinate
+func TimeZoneForCoordinates(latitude, longitude float64) *time.Location {
+	name := LookupTimeZone(latitude, longitude)
+	if cached, ok := loadedLocations.Load(name); ok {
+		return cached.(*time.Location)
+	}
+
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		// Boundary files can name zones newer than the embedded database. The fallback depends
+		// on the longitude, so it is not cached under the zone name
+		return nauticalLocation(longitude)
+	}
+	loadedLocations.Store(name, location)
+	return location
+}
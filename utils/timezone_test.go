@@ -0,0 +1,174 @@
+package utils
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestLookupTimeZone(t *testing.T) {
+	testCases := []struct {
+		name      string
+		latitude  float64
+		longitude float64
+		expected  string
+	}{
+		{"Khartoum", 15.5007, 32.5599, "Africa/Khartoum"},
+		{"Riyadh", 24.7136, 46.6753, "Asia/Riyadh"},
+		{"Madrid", 40.4168, -3.7038, "Europe/Madrid"},
+		{"Beijing", 39.9042, 116.4074, "Asia/Shanghai"},
+		{"Mumbai", 19.0760, 72.8777, "Asia/Kolkata"},
+		{"El Paso", 31.7619, -106.4850, "America/Denver"},
+		{"Ciudad Juarez", 31.6904, -106.4245, "America/Ciudad_Juarez"},
+		{"Geneva", 46.2044, 6.1432, "Europe/Zurich"},
+		{"Annemasse", 46.1934, 6.2342, "Europe/Paris"},
+		{"Detroit", 42.3314, -83.0458, "America/Detroit"},
+		{"Windsor", 42.3149, -83.0364, "America/Toronto"},
+		{"Kaliningrad", 54.7104, 20.4522, "Europe/Kaliningrad"},
+		{"Mid Pacific", 0, -150, "Etc/GMT+10"},
+		{"Mid Indian Ocean", -30, 80, "Etc/GMT-5"},
+		{"Gulf of Guinea", -2, 0, "Etc/GMT"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := LookupTimeZone(tc.latitude, tc.longitude)
+			if result != tc.expected {
+				t.Errorf("Expected time zone %s, but got %s", tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestTimeZoneForInvalidCoordinates(t *testing.T) {
+	if name := NauticalTimeZone(math.NaN()); name != "Etc/GMT" {
+		t.Errorf("Expected Etc/GMT for a NaN longitude, but got %s", name)
+	}
+	for _, longitude := range []float64{math.NaN(), math.Inf(1), 500} {
+		if location := TimeZoneForCoordinates(math.NaN(), longitude); location == nil {
+			t.Errorf("Expected a time zone for longitude %g, but got nil", longitude)
+		}
+	}
+}
+
+func TestTimeZoneForUnknownZoneName(t *testing.T) {
+	finder, err := NewTimeZoneFinder([]byte(`{"type":"FeatureCollection","features":[{"type":"Feature",
+		"properties":{"tzid":"Test/Unknown"},"geometry":{"type":"Polygon",
+		"coordinates":[[[0,-10],[60,-10],[60,10],[0,10],[0,-10]]]}}]}`))
+	if err != nil {
+		t.Fatalf("Expected valid boundaries, but got %v", err)
+	}
+	previous := defaultTimeZoneFinder()
+	timeZoneFinderMu.Lock()
+	timeZoneFinder = finder
+	timeZoneFinderMu.Unlock()
+	defer func() {
+		timeZoneFinderMu.Lock()
+		timeZoneFinder = previous
+		timeZoneFinderMu.Unlock()
+	}()
+
+	// Both points are in the same unknown zone but fall back to different nautical zones
+	if location := TimeZoneForCoordinates(0, 5); location.String() != "Etc/GMT" {
+		t.Errorf("Expected Etc/GMT at longitude 5, but got %s", location)
+	}
+	if location := TimeZoneForCoordinates(0, 50); location.String() != "Etc/GMT-3" {
+		t.Errorf("Expected Etc/GMT-3 at longitude 50, but got %s", location)
+	}
+	if _, ok := loadedLocations.Load("Test/Unknown"); ok {
+		t.Errorf("Expected the fallback for an unknown zone not to be cached")
+	}
+}
+
+func TestCommonCitiesTimeZones(t *testing.T) {
+	for _, city := range CommonCities {
+		if _, err := time.LoadLocation(city.TimeZone); err != nil {
+			t.Errorf("Expected a valid time zone for %s, but got %q: %v", city.Name, city.TimeZone, err)
+		}
+		if result := LookupTimeZone(city.Latitude, city.Longitude); result != city.TimeZone {
+			t.Errorf("Expected lookup for %s to return %s, but got %s", city.Name, city.TimeZone, result)
+		}
+	}
+}
+
+func TestSunriseSunsetUsesDaylightSavingTime(t *testing.T) {
+	madrid := TimeZoneForCoordinates(40.4168, -3.7038)
+	summer := time.Date(2026, time.June, 21, 12, 0, 0, 0, madrid)
+
+	sunrise, sunset := CalculateSunriseSunset(40.4168, -3.7038, summer)
+
+	// Madrid observes CEST (UTC+2) in summer, so sunrise is around 06:45 and sunset around 21:48
+	if _, offset := sunrise.Zone(); offset != 2*3600 {
+		t.Errorf("Expected sunrise in UTC+2, but got offset %d", offset)
+	}
+	if sunrise.Hour() != 6 {
+		t.Errorf("Expected sunrise around 06:45, but got %s", sunrise.Format("15:04"))
+	}
+	if sunset.Hour() != 21 {
+		t.Errorf("Expected sunset around 21:48, but got %s", sunset.Format("15:04"))
+	}
+}
+
+func TestSunriseSunsetNearDateLine(t *testing.T) {
+	testCases := []struct {
+		name      string
+		latitude  float64
+		longitude float64
+	}{
+		{"Kiritimati UTC+14", 1.87, -157.43},
+		{"Nuku'alofa UTC+13", -21.14, -175.2},
+		{"Pago Pago UTC-11", -14.28, -170.7},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			location := TimeZoneForCoordinates(tc.latitude, tc.longitude)
+			date := time.Date(2026, time.January, 28, 0, 0, 0, 0, location)
+
+			sunrise, sunset := CalculateSunriseSunset(tc.latitude, tc.longitude, date)
+			noon := CalculateSolarNoon(tc.longitude, date)
+			for _, event := range []time.Time{sunrise, noon, sunset} {
+				if y, m, d := event.Date(); y != 2026 || m != time.January || d != 28 {
+					t.Errorf("Expected events on 2026-01-28 in %s, but got %v", location, event)
+				}
+			}
+			if !sunrise.Before(noon) || !noon.Before(sunset) {
+				t.Errorf("Expected sunrise, noon and sunset in order, but got %v, %v and %v", sunrise, noon, sunset)
+			}
+		})
+	}
+}
+
+func TestRiseSetAzimuthsNearDateLine(t *testing.T) {
+	// At 60°N near the equinox the sunrise bearing moves about 0.7° a day, so the bearing must come
+	// from the same solar day as the sunrise time
+	location, err := time.LoadLocation("Pacific/Kiritimati")
+	if err != nil {
+		t.Fatalf("Expected Pacific/Kiritimati to load, but got %v", err)
+	}
+	observer := NewObserver(60, -157.43)
+	date := time.Date(2026, time.March, 20, 0, 0, 0, 0, location)
+
+	sunrise, _ := CalculateSunriseSunsetForObserver(observer, date)
+	rise, _, ok := CalculateRiseSetAzimuths(observer.Latitude, observer.Longitude, date, observer.SunriseAltitude())
+	if !ok {
+		t.Fatalf("Expected the sun to rise and set on %v", date)
+	}
+	_, azimuth := CalculateSunPositionForObserver(AlgorithmSPA, observer, sunrise)
+	if math.Abs(rise-azimuth) > 0.3 {
+		t.Errorf("Expected the SPA azimuth at sunrise %.2f to match the bearing %.2f", azimuth, rise)
+	}
+}
+
+func TestSunPositionIndependentOfTimeZone(t *testing.T) {
+	// The same instant expressed in different zones must give the same sun position
+	instant := time.Date(2026, time.March, 10, 9, 0, 0, 0, time.UTC)
+	riyadh := TimeZoneForCoordinates(24.7136, 46.6753)
+
+	utcAlt, utcAz := CalculateSunPosition(24.7136, 46.6753, instant)
+	localAlt, localAz := CalculateSunPosition(24.7136, 46.6753, instant.In(riyadh))
+
+	if math.Abs(utcAlt-localAlt) > 1e-9 || math.Abs(utcAz-localAz) > 1e-9 {
+		t.Errorf("Expected identical positions, but got (%.4f, %.4f) and (%.4f, %.4f)", utcAlt, utcAz, localAlt, localAz)
+	}
+}